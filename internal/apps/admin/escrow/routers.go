@@ -0,0 +1,148 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package escrow
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/escrow"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"gorm.io/gorm"
+)
+
+// listEscrowTradesRequest 担保交易列表查询请求
+type listEscrowTradesRequest struct {
+	Page     int                `form:"page" binding:"min=1"`
+	PageSize int                `form:"page_size" binding:"min=1,max=100"`
+	Status   model.EscrowStatus `form:"status" binding:"omitempty,oneof=funded delivered disputing released refunded"`
+}
+
+// listEscrowTradesResponse 担保交易列表响应
+type listEscrowTradesResponse struct {
+	Trades []model.EscrowTrade `json:"trades"`
+	Total  int64               `json:"total"`
+}
+
+// arbitrateRequest 仲裁请求
+type arbitrateRequest struct {
+	Result string `json:"result" binding:"required,oneof=release refund"`
+	Remark string `json:"remark" binding:"max=255"`
+}
+
+// ListEscrowTrades 获取担保交易列表，默认展示争议中的交易
+// @Tags admin
+// @Produce json
+// @Param request query listEscrowTradesRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/admin/escrow-trades [get]
+func ListEscrowTrades(c *gin.Context) {
+	var req listEscrowTradesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	if req.Status == "" {
+		req.Status = model.EscrowStatusDisputing
+	}
+
+	query := db.DB(c.Request.Context()).Model(&model.EscrowTrade{}).
+		Where("escrow_trades.status = ?", req.Status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	trades := []model.EscrowTrade{}
+	if err := escrow.TradeQuery(query).
+		Order("escrow_trades.updated_at ASC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&trades).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(listEscrowTradesResponse{
+		Trades: trades,
+		Total:  total,
+	}))
+}
+
+// ArbitrateEscrowTrade 仲裁争议中的担保交易，放款给卖家或退款给买家
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "担保交易ID"
+// @Param request body arbitrateRequest true "仲裁结果"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/admin/escrow-trades/{id}/arbitrate [post]
+func ArbitrateEscrowTrade(c *gin.Context) {
+	var req arbitrateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	operator, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var trade model.EscrowTrade
+	if err := db.DB(ctx).Where("id = ?", id).First(&trade).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(tradeNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := escrow.SettleTrade(tx, escrow.SettleOptions{
+			TradeID: trade.ID,
+			From:    []model.EscrowStatus{model.EscrowStatusDisputing},
+			Release: req.Result == "release",
+			Updates: map[string]interface{}{
+				"arbitrator_user_id": operator.ID,
+				"arbitration_remark": req.Remark,
+			},
+		})
+		return err
+	}); err != nil {
+		if err.Error() == escrow.TradeStatusInvalid {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(arbitrateFail))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package user
 
 const (
-	userNotFound     = "用户不存在"
-	cannotDisable    = "不能禁用管理员用户"
-	updateUserFailed = "更新用户状态失败"
+	userNotFound       = "用户不存在"
+	cannotDisable      = "不能禁用管理员用户"
+	updateUserFailed   = "更新用户状态失败"
+	unlockPayKeyFail   = "解除支付密码锁定失败"
+	revokeSessionsFail = "强制下线用户失败"
 )
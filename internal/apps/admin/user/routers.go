@@ -17,12 +17,17 @@ limitations under the License.
 package user
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
 	"github.com/linux-do/credit/internal/util"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
@@ -48,6 +53,7 @@ type user struct {
 	TotalCommunity   decimal.Decimal  `json:"total_community"`
 	CommunityBalance decimal.Decimal  `json:"community_balance"`
 	AvailableBalance decimal.Decimal  `json:"available_balance"`
+	HeldBalance      decimal.Decimal  `json:"held_balance"`
 	IsActive         bool             `json:"is_active"`
 	IsAdmin          bool             `json:"is_admin"`
 	LastLoginAt      time.Time        `json:"last_login_at"`
@@ -92,7 +98,7 @@ func ListUsers(c *gin.Context) {
 	if err := query.
 		Select("id, username, nickname, avatar_url, trust_level, pay_score, " +
 			"total_receive, total_payment, total_transfer, total_community, " +
-			"community_balance, available_balance, is_active, is_admin, " +
+			"community_balance, available_balance, held_balance, is_active, is_admin, " +
 			"last_login_at, created_at, updated_at").
 		Order("id DESC").
 		Offset(offset).
@@ -159,5 +165,62 @@ func UpdateUserStatus(c *gin.Context) {
 		return
 	}
 
+	// 封禁时强制下线该用户的全部会话
+	if !req.IsActive {
+		if err := service.RevokeUserSessions(c.Request.Context(), targetUser.ID, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(revokeSessionsFail))
+			return
+		}
+
+		operator, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+		model.RecordSecurityEvent(c.Request.Context(), &model.UserSecurityLog{
+			UserID:     targetUser.ID,
+			EventType:  model.SecurityEventSessionRevoked,
+			OperatorID: &operator.ID,
+			IP:         c.ClientIP(),
+			Remark:     fmt.Sprintf("管理员 %s 封禁用户，强制下线全部会话", operator.Username),
+		})
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// UnlockUserPayKey 解除用户支付密码锁定
+// @Tags admin
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/admin/users/{id}/pay-key/unlock [put]
+func UnlockUserPayKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	var targetUser model.User
+	if err := targetUser.GetByID(db.DB(c.Request.Context()), id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(userNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := service.UnlockPayKey(c.Request.Context(), targetUser.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(unlockPayKeyFail))
+		return
+	}
+
+	operator, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	model.RecordSecurityEvent(c.Request.Context(), &model.UserSecurityLog{
+		UserID:     targetUser.ID,
+		EventType:  model.SecurityEventPayKeyUnlocked,
+		OperatorID: &operator.ID,
+		IP:         c.ClientIP(),
+		Remark:     fmt.Sprintf("管理员 %s 解除支付密码锁定", operator.Username),
+	})
+
 	c.JSON(http.StatusOK, util.OKNil())
 }
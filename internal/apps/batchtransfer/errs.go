@@ -0,0 +1,32 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchtransfer
+
+const (
+	RecipientNotFound      = "收款人不存在"
+	CannotTransferToSelf   = "不能转账给自己"
+	TooManyRecipients      = "批量转账人数超过上限"
+	RemarkTooLong          = "备注不能超过100个字符"
+	CSVFileRequired        = "请上传CSV文件"
+	CSVFileTooLarge        = "CSV文件不能超过1MB"
+	CSVFormatInvalid       = "CSV格式错误，每行应为：用户名,金额[,备注]"
+	CSVEmpty               = "CSV文件中没有转账记录"
+	BatchValidationFailed  = "部分转账记录校验失败，请修正后重新提交"
+	BatchAllFailed         = "批量转账全部失败"
+	LineNotExecuted        = "未执行"
+	BatchLineErrorTemplate = "第%d行：%s"
+)
@@ -0,0 +1,285 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchtransfer
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// BatchTransferItem 批量转账中的单行记录
+type BatchTransferItem struct {
+	// RecipientID 收款人 ID，填写时需与用户名匹配，CSV 导入时仅按用户名匹配
+	RecipientID       uint64          `json:"recipient_id,string"`
+	RecipientUsername string          `json:"recipient_username" binding:"required"`
+	Amount            decimal.Decimal `json:"amount" binding:"required"`
+	Remark            string          `json:"remark" binding:"max=100"`
+
+	line int
+}
+
+// BatchTransferRequest 批量转账请求
+type BatchTransferRequest struct {
+	Items []BatchTransferItem `json:"items" binding:"required,min=1,dive"`
+	// Atomic 为 true 时全部成功或全部回滚，否则逐行执行并返回每行结果
+	Atomic   bool   `json:"atomic"`
+	PayKey   string `json:"pay_key" binding:"required,max=6"`
+	TOTPCode string `json:"totp_code" binding:"max=16"`
+}
+
+// BatchTransferCSVRequest CSV 批量转账请求
+type BatchTransferCSVRequest struct {
+	Atomic   bool   `form:"atomic"`
+	PayKey   string `form:"pay_key" binding:"required,max=6"`
+	TOTPCode string `form:"totp_code" binding:"max=16"`
+}
+
+// BatchTransferLineResult 单行转账结果
+type BatchTransferLineResult struct {
+	Line              int             `json:"line"`
+	RecipientUsername string          `json:"recipient_username"`
+	Amount            decimal.Decimal `json:"amount"`
+	Success           bool            `json:"success"`
+	OrderID           *uint64         `json:"order_id,string"`
+	Error             string          `json:"error"`
+}
+
+// BatchTransferResponse 批量转账结果
+type BatchTransferResponse struct {
+	Total         int                       `json:"total"`
+	SuccessCount  int                       `json:"success_count"`
+	FailedCount   int                       `json:"failed_count"`
+	TotalAmount   decimal.Decimal           `json:"total_amount"`
+	SuccessAmount decimal.Decimal           `json:"success_amount"`
+	Results       []BatchTransferLineResult `json:"results"`
+}
+
+// CreateBatchTransfer 批量转账，支付密码仅校验一次
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Param request body BatchTransferRequest true "批量转账请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/payment/transfer/batch [post]
+func CreateBatchTransfer(c *gin.Context) {
+	var req BatchTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	for i := range req.Items {
+		req.Items[i].line = i + 1
+	}
+
+	executeBatch(c, req.Items, req.Atomic, req.PayKey, req.TOTPCode)
+}
+
+// CreateBatchTransferFromCSV 通过 CSV 文件批量转账，每行为：用户名,金额[,备注]
+// @Tags payment
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV 文件"
+// @Param pay_key formData string true "支付密码"
+// @Param totp_code formData string false "二次验证码"
+// @Param atomic formData bool false "是否全部成功或全部回滚"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/payment/transfer/batch/csv [post]
+func CreateBatchTransferFromCSV(c *gin.Context) {
+	var req BatchTransferCSVRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(CSVFileRequired))
+		return
+	}
+	if file.Size > maxCSVFileSize {
+		c.JSON(http.StatusBadRequest, util.Err(CSVFileTooLarge))
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	defer src.Close()
+
+	items, err := parseCSV(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	executeBatch(c, items, req.Atomic, req.PayKey, req.TOTPCode)
+}
+
+// executeBatch 校验并执行批量转账
+// 逐行预校验后按总金额校验余额与限额，原子模式在同一事务内执行，否则每行独立执行
+func executeBatch(c *gin.Context, items []BatchTransferItem, atomic bool, payKey, totpCode string) {
+	ctx := c.Request.Context()
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	accessToken, _ := oauth.GetAccessTokenFromContext(c)
+
+	maxRecipients, err := model.GetIntByKey(ctx, model.ConfigKeyBatchTransferMaxRecipients)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if len(items) > maxRecipients {
+		c.JSON(http.StatusBadRequest, util.Err(TooManyRecipients))
+		return
+	}
+
+	results, total, valid, err := validateItems(db.DB(ctx), currentUser, items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	response := BatchTransferResponse{
+		Total:         len(items),
+		TotalAmount:   total,
+		SuccessAmount: decimal.Zero,
+		Results:       results,
+	}
+
+	// 原子模式下任一行校验失败或没有可执行的记录时整批不执行
+	if !valid && (atomic || total.IsZero()) {
+		response.FailedCount = len(items)
+		for i := range response.Results {
+			if response.Results[i].Error == "" {
+				response.Results[i].Error = LineNotExecuted
+			}
+		}
+		c.JSON(http.StatusBadRequest, util.Response[BatchTransferResponse]{ErrorMsg: BatchValidationFailed, Data: response})
+		return
+	}
+
+	if err := service.VerifyPaymentAuth(ctx, currentUser, payKey, totpCode, total, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	transferOptions := func(item *BatchTransferItem) service.TransferOptions {
+		opts := service.TransferOptions{
+			PayerID:   currentUser.ID,
+			PayeeID:   item.RecipientID,
+			Amount:    item.Amount,
+			OrderName: "批量转账",
+			Remark:    item.Remark,
+		}
+		if accessToken != nil {
+			opts.AccessTokenID = &accessToken.ID
+			opts.BeforeTransfer = func(tx *gorm.DB, payer *model.User) error {
+				return service.CheckAccessTokenTransferLimit(tx, accessToken, item.Amount)
+			}
+		}
+		return opts
+	}
+
+	if atomic {
+		failedLine := -1
+		if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := checkBatchTotal(tx, currentUser.ID, total, accessToken); err != nil {
+				return err
+			}
+			for i := range items {
+				order, err := service.Transfer(tx, transferOptions(&items[i]))
+				if err != nil {
+					failedLine = i
+					return err
+				}
+				response.Results[i].OrderID = &order.ID
+			}
+			return nil
+		}); err != nil {
+			if failedLine < 0 {
+				c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+				return
+			}
+			response.FailedCount = len(items)
+			for i := range response.Results {
+				response.Results[i].OrderID = nil
+				response.Results[i].Error = LineNotExecuted
+			}
+			response.Results[failedLine].Error = err.Error()
+			c.JSON(http.StatusBadRequest, util.Response[BatchTransferResponse]{
+				ErrorMsg: fmt.Sprintf(BatchLineErrorTemplate, items[failedLine].line, err.Error()),
+				Data:     response,
+			})
+			return
+		}
+
+		for i := range response.Results {
+			response.Results[i].Success = true
+		}
+		response.SuccessCount = len(items)
+		response.SuccessAmount = total
+		c.JSON(http.StatusOK, util.OK(response))
+		return
+	}
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		return checkBatchTotal(tx, currentUser.ID, total, accessToken)
+	}); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	for i := range items {
+		if response.Results[i].Error != "" {
+			response.FailedCount++
+			continue
+		}
+
+		var order *model.Order
+		if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+			var errTransfer error
+			order, errTransfer = service.Transfer(tx, transferOptions(&items[i]))
+			return errTransfer
+		}); err != nil {
+			response.Results[i].Error = err.Error()
+			response.FailedCount++
+			continue
+		}
+
+		response.Results[i].Success = true
+		response.Results[i].OrderID = &order.ID
+		response.SuccessCount++
+		response.SuccessAmount = response.SuccessAmount.Add(items[i].Amount)
+	}
+
+	if response.SuccessCount == 0 {
+		c.JSON(http.StatusBadRequest, util.Response[BatchTransferResponse]{ErrorMsg: BatchAllFailed, Data: response})
+		return
+	}
+	c.JSON(http.StatusOK, util.OK(response))
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package batchtransfer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// parseCSV 解析批量转账 CSV，每行为：用户名,金额[,备注]，首行为表头时跳过
+func parseCSV(r io.Reader) ([]BatchTransferItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var items []BatchTransferItem
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf(BatchLineErrorTemplate, line, CSVFormatInvalid)
+		}
+
+		if line == 1 && len(record) > 0 {
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+			if header := strings.ToLower(strings.TrimSpace(record[0])); header == "username" || header == "用户名" {
+				continue
+			}
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf(BatchLineErrorTemplate, line, CSVFormatInvalid)
+		}
+
+		amount, err := decimal.NewFromString(strings.TrimSpace(record[1]))
+		if err != nil {
+			return nil, fmt.Errorf(BatchLineErrorTemplate, line, CSVFormatInvalid)
+		}
+
+		item := BatchTransferItem{
+			RecipientUsername: strings.TrimSpace(record[0]),
+			Amount:            amount,
+			line:              line,
+		}
+		if len(record) == 3 {
+			item.Remark = strings.TrimSpace(record[2])
+		}
+		items = append(items, item)
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New(CSVEmpty)
+	}
+	return items, nil
+}
+
+// validateItems 逐行校验金额、备注与收款人，返回每行的预校验结果与批量总金额
+// 校验通过的行 RecipientID 会被补全为收款人 ID
+func validateItems(tx *gorm.DB, payer *model.User, items []BatchTransferItem) ([]BatchTransferLineResult, decimal.Decimal, bool, error) {
+	usernames := make([]string, 0, len(items))
+	for _, item := range items {
+		usernames = append(usernames, item.RecipientUsername)
+	}
+
+	var recipients []model.User
+	if err := tx.Select("id, username").Where("username IN ?", usernames).Find(&recipients).Error; err != nil {
+		return nil, decimal.Zero, false, err
+	}
+	recipientIDs := make(map[string]uint64, len(recipients))
+	for _, recipient := range recipients {
+		recipientIDs[recipient.Username] = recipient.ID
+	}
+
+	results := make([]BatchTransferLineResult, len(items))
+	total := decimal.Zero
+	valid := true
+	for i := range items {
+		item := &items[i]
+		results[i] = BatchTransferLineResult{
+			Line:              item.line,
+			RecipientUsername: item.RecipientUsername,
+			Amount:            item.Amount,
+		}
+
+		if lineErr := validateItem(item, recipientIDs, payer.ID); lineErr != "" {
+			results[i].Error = lineErr
+			valid = false
+			continue
+		}
+
+		item.RecipientID = recipientIDs[item.RecipientUsername]
+		total = total.Add(item.Amount)
+	}
+
+	return results, total, valid, nil
+}
+
+// validateItem 校验单行转账记录，返回错误信息，校验通过时返回空字符串
+func validateItem(item *BatchTransferItem, recipientIDs map[string]uint64, payerID uint64) string {
+	if err := util.ValidateAmount(item.Amount); err != nil {
+		return err.Error()
+	}
+	if len([]rune(item.Remark)) > maxRemarkLength {
+		return RemarkTooLong
+	}
+
+	recipientID, found := recipientIDs[item.RecipientUsername]
+	if !found || (item.RecipientID != 0 && item.RecipientID != recipientID) {
+		return RecipientNotFound
+	}
+	if recipientID == payerID {
+		return CannotTransferToSelf
+	}
+	return ""
+}
+
+// checkBatchTotal 锁定付款人，按批量总金额校验余额、冷静期限额与访问令牌限额
+func checkBatchTotal(tx *gorm.DB, payerID uint64, total decimal.Decimal, accessToken *model.UserAccessToken) error {
+	var payer model.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+		Where("id = ?", payerID).
+		First(&payer).Error; err != nil {
+		return err
+	}
+
+	if payer.AvailableBalance.LessThan(total) {
+		return errors.New(common.InsufficientBalance)
+	}
+
+	if err := service.CheckPayKeyResetCooling(tx, &payer, total); err != nil {
+		return err
+	}
+
+	if accessToken != nil {
+		return service.CheckAccessTokenTransferLimit(tx, accessToken, total)
+	}
+	return nil
+}
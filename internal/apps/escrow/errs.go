@@ -0,0 +1,26 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package escrow
+
+const (
+	EscrowTradeNotFound     = "担保交易不存在"
+	SellerNotFound          = "卖家不存在或用户名不匹配"
+	CannotTradeWithSelf     = "不能与自己发起担保交易"
+	TradeStatusInvalid      = "当前交易状态不允许该操作"
+	TradeNotDeliverable     = "交易已发货或已结束，无法标记发货"
+	EscrowConfigUnavailable = "获取担保交易配置失败"
+)
@@ -0,0 +1,433 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package escrow
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// CreateEscrowTradeRequest 发起担保交易请求
+type CreateEscrowTradeRequest struct {
+	SellerID       uint64          `json:"seller_id,string" binding:"required"`
+	SellerUsername string          `json:"seller_username" binding:"required"`
+	Amount         decimal.Decimal `json:"amount" binding:"required"`
+	Title          string          `json:"title" binding:"required,max=64"`
+	Note           string          `json:"note" binding:"max=255"`
+	PayKey         string          `json:"pay_key" binding:"required,max=6"`
+	TOTPCode       string          `json:"totp_code" binding:"max=16"`
+}
+
+// ListEscrowTradesRequest 担保交易列表查询参数
+type ListEscrowTradesRequest struct {
+	Page     int                `form:"page" binding:"min=1"`
+	PageSize int                `form:"page_size" binding:"min=1,max=100"`
+	Role     string             `form:"role" binding:"required,oneof=buyer seller"`
+	Status   model.EscrowStatus `form:"status" binding:"omitempty,oneof=funded delivered disputing released refunded"`
+}
+
+// ListEscrowTradesResponse 担保交易列表响应
+type ListEscrowTradesResponse struct {
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Trades   []model.EscrowTrade `json:"trades"`
+}
+
+// DeliverRequest 卖家发货请求
+type DeliverRequest struct {
+	DeliveryNote string `json:"delivery_note" binding:"max=500"`
+}
+
+// ConfirmRequest 买家确认收货请求
+type ConfirmRequest struct {
+	PayKey   string `json:"pay_key" binding:"required,max=6"`
+	TOTPCode string `json:"totp_code" binding:"max=16"`
+}
+
+// DisputeRequest 发起争议请求
+type DisputeRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// CreateEscrowTrade 买家发起担保交易，付款金额冻结至确认收货或仲裁结束
+// @Tags escrow
+// @Accept json
+// @Produce json
+// @Param request body CreateEscrowTradeRequest true "创建请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/escrow [post]
+func CreateEscrowTrade(c *gin.Context) {
+	var req CreateEscrowTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	if err := util.ValidateAmount(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	if currentUser.ID == req.SellerID {
+		c.JSON(http.StatusBadRequest, util.Err(CannotTradeWithSelf))
+		return
+	}
+
+	deliveryTimeoutDays, err := model.GetIntByKey(ctx, model.ConfigKeyEscrowDeliveryTimeoutDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(EscrowConfigUnavailable))
+		return
+	}
+
+	if err := service.VerifyPaymentAuth(ctx, currentUser, req.PayKey, req.TOTPCode, req.Amount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	trade := model.EscrowTrade{
+		ID:           idgen.NextUint64ID(),
+		BuyerUserID:  currentUser.ID,
+		SellerUserID: req.SellerID,
+		Title:        req.Title,
+		Note:         req.Note,
+		Amount:       req.Amount,
+		Status:       model.EscrowStatusFunded,
+		DeliverBy:    now.AddDate(0, 0, deliveryTimeoutDays),
+	}
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var seller model.User
+		if err := tx.Where("id = ? AND username = ? AND is_active = ?", req.SellerID, req.SellerUsername, true).First(&seller).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(SellerNotFound)
+			}
+			return err
+		}
+
+		buyer, err := service.HoldBalance(tx, currentUser.ID, req.Amount)
+		if err != nil {
+			return err
+		}
+		if err := service.CheckPayKeyResetCooling(tx, buyer, req.Amount); err != nil {
+			return err
+		}
+
+		order := model.Order{
+			OrderName:     req.Title,
+			PayerUserID:   currentUser.ID,
+			PayeeUserID:   seller.ID,
+			Amount:        req.Amount,
+			Status:        model.OrderStatusHeld,
+			Type:          model.OrderTypeEscrow,
+			Remark:        req.Note,
+			EscrowTradeID: &trade.ID,
+			TradeTime:     now,
+			ExpiresAt:     now.Add(24 * time.Hour),
+		}
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+
+		trade.OrderID = order.ID
+		return tx.Create(&trade).Error
+	}); err != nil {
+		switch err.Error() {
+		case SellerNotFound, common.InsufficientBalance, common.PayKeyResetCoolingLimited:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	trade.BuyerUsername = currentUser.Username
+	trade.SellerUsername = req.SellerUsername
+	c.JSON(http.StatusOK, util.OK(trade))
+}
+
+// ListEscrowTrades 我作为买家或卖家的担保交易
+// @Tags escrow
+// @Produce json
+// @Param request query ListEscrowTradesRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/escrow [get]
+func ListEscrowTrades(c *gin.Context) {
+	var req ListEscrowTradesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	baseQuery := db.DB(c.Request.Context()).Model(&model.EscrowTrade{})
+	if req.Role == "buyer" {
+		baseQuery = baseQuery.Where("escrow_trades.buyer_user_id = ?", currentUser.ID)
+	} else {
+		baseQuery = baseQuery.Where("escrow_trades.seller_user_id = ?", currentUser.ID)
+	}
+	if req.Status != "" {
+		baseQuery = baseQuery.Where("escrow_trades.status = ?", req.Status)
+	}
+
+	response := ListEscrowTradesResponse{
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Trades:   []model.EscrowTrade{},
+	}
+	if err := baseQuery.Count(&response.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := TradeQuery(baseQuery).
+		Order("escrow_trades.created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&response.Trades).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
+}
+
+// GetEscrowTrade 查询担保交易详情，买卖双方可见
+// @Tags escrow
+// @Produce json
+// @Param id path string true "担保交易ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/escrow/{id} [get]
+func GetEscrowTrade(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var trade model.EscrowTrade
+	if err := TradeQuery(db.DB(c.Request.Context())).
+		Where("escrow_trades.id = ?", c.Param("id")).
+		Where("escrow_trades.buyer_user_id = ? OR escrow_trades.seller_user_id = ?", currentUser.ID, currentUser.ID).
+		First(&trade).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(EscrowTradeNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(trade))
+}
+
+// DeliverEscrowTrade 卖家标记已发货，买家未在期限内确认将自动放款
+// @Tags escrow
+// @Accept json
+// @Produce json
+// @Param id path string true "担保交易ID"
+// @Param request body DeliverRequest true "发货信息"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/escrow/{id}/deliver [post]
+func DeliverEscrowTrade(c *gin.Context) {
+	var req DeliverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	autoReleaseDays, err := model.GetIntByKey(ctx, model.ConfigKeyEscrowAutoReleaseDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(EscrowConfigUnavailable))
+		return
+	}
+
+	now := time.Now()
+	result := db.DB(ctx).Model(&model.EscrowTrade{}).
+		Where("id = ? AND seller_user_id = ? AND status = ?", c.Param("id"), currentUser.ID, model.EscrowStatusFunded).
+		Updates(map[string]interface{}{
+			"status":          model.EscrowStatusDelivered,
+			"delivery_note":   req.DeliveryNote,
+			"delivered_at":    now,
+			"auto_release_at": now.AddDate(0, 0, autoReleaseDays),
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, util.Err(TradeNotDeliverable))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// ConfirmEscrowTrade 买家确认收货，冻结资金放款给卖家
+// @Tags escrow
+// @Accept json
+// @Produce json
+// @Param id path string true "担保交易ID"
+// @Param request body ConfirmRequest true "确认请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/escrow/{id}/confirm [post]
+func ConfirmEscrowTrade(c *gin.Context) {
+	var req ConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var trade model.EscrowTrade
+	if err := db.DB(ctx).
+		Where("id = ? AND buyer_user_id = ?", c.Param("id"), currentUser.ID).
+		First(&trade).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(EscrowTradeNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := service.VerifyPaymentAuth(ctx, currentUser, req.PayKey, req.TOTPCode, trade.Amount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := SettleTrade(tx, SettleOptions{
+			TradeID: trade.ID,
+			From:    model.EscrowDisputableStatuses,
+			Release: true,
+		})
+		return err
+	}); err != nil {
+		if isTradeError(err) {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// RefundEscrowTrade 卖家主动退款，冻结资金退回买家
+// @Tags escrow
+// @Produce json
+// @Param id path string true "担保交易ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/escrow/{id}/refund [post]
+func RefundEscrowTrade(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	tradeID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	var count int64
+	if err := db.DB(ctx).Model(&model.EscrowTrade{}).
+		Where("id = ? AND seller_user_id = ?", tradeID, currentUser.ID).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, util.Err(EscrowTradeNotFound))
+		return
+	}
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := SettleTrade(tx, SettleOptions{
+			TradeID: tradeID,
+			From:    model.EscrowDisputableStatuses,
+		})
+		return err
+	}); err != nil {
+		if isTradeError(err) {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// DisputeEscrowTrade 买家或卖家在放款前发起争议，暂停自动放款并交由管理员仲裁
+// @Tags escrow
+// @Accept json
+// @Produce json
+// @Param id path string true "担保交易ID"
+// @Param request body DisputeRequest true "争议请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/escrow/{id}/dispute [post]
+func DisputeEscrowTrade(c *gin.Context) {
+	var req DisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	result := db.DB(c.Request.Context()).Model(&model.EscrowTrade{}).
+		Where("id = ? AND (buyer_user_id = ? OR seller_user_id = ?) AND status IN ?",
+			c.Param("id"), currentUser.ID, currentUser.ID, model.EscrowDisputableStatuses).
+		Updates(map[string]interface{}{
+			"status":              model.EscrowStatusDisputing,
+			"dispute_reason":      req.Reason,
+			"disputed_by_user_id": currentUser.ID,
+			"disputed_at":         time.Now(),
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, util.Err(TradeStatusInvalid))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
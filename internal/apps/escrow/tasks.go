@@ -0,0 +1,90 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package escrow
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
+	"github.com/linux-do/credit/internal/model"
+	"gorm.io/gorm"
+)
+
+// HandleAutoSettleEscrowTrades 自动结算到期的担保交易
+// 已发货且超过确认期限的交易放款给卖家，超过发货期限仍未发货的交易退款给买家
+func HandleAutoSettleEscrowTrades(ctx context.Context, t *asynq.Task) error {
+	now := time.Now()
+
+	released, err := autoSettleTrades(ctx,
+		db.DB(ctx).Where("status = ? AND auto_release_at <= ?", model.EscrowStatusDelivered, now),
+		model.EscrowStatusDelivered, true)
+	if err != nil {
+		return err
+	}
+
+	refunded, err := autoSettleTrades(ctx,
+		db.DB(ctx).Where("status = ? AND deliver_by <= ?", model.EscrowStatusFunded, now),
+		model.EscrowStatusFunded, false)
+	if err != nil {
+		return err
+	}
+
+	logger.InfoF(ctx, "担保交易自动结算完成: 自动放款[%d] 超时退款[%d]", released, refunded)
+	return nil
+}
+
+// autoSettleTrades 按游标分批结算符合条件的交易，单笔失败不影响其余交易
+func autoSettleTrades(ctx context.Context, query *gorm.DB, from model.EscrowStatus, release bool) (int, error) {
+	const batchSize = 100
+	var lastID uint64
+	settled := 0
+
+	for {
+		var tradeIDs []uint64
+		if err := query.Session(&gorm.Session{}).Model(&model.EscrowTrade{}).
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(batchSize).
+			Pluck("id", &tradeIDs).Error; err != nil {
+			logger.ErrorF(ctx, "查询待结算担保交易失败: %v", err)
+			return settled, err
+		}
+		if len(tradeIDs) == 0 {
+			return settled, nil
+		}
+
+		for _, tradeID := range tradeIDs {
+			if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+				_, err := SettleTrade(tx, SettleOptions{
+					TradeID: tradeID,
+					From:    []model.EscrowStatus{from},
+					Release: release,
+				})
+				return err
+			}); err != nil {
+				logger.ErrorF(ctx, "担保交易[%d]自动结算失败: %v", tradeID, err)
+				continue
+			}
+			settled++
+		}
+
+		lastID = tradeIDs[len(tradeIDs)-1]
+	}
+}
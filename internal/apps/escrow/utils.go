@@ -0,0 +1,104 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package escrow
+
+import (
+	"errors"
+	"time"
+
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SettleOptions 担保交易结算参数
+type SettleOptions struct {
+	TradeID uint64
+	// From 允许结算的交易状态
+	From []model.EscrowStatus
+	// Release 为 true 时放款给卖家，否则退款给买家
+	Release bool
+	// Updates 结算时额外更新的交易字段，如仲裁信息
+	Updates map[string]interface{}
+}
+
+// SettleTrade 在事务中结算担保交易，解冻买家资金并放款给卖家或退回买家
+func SettleTrade(tx *gorm.DB, opts SettleOptions) (*model.EscrowTrade, error) {
+	var trade model.EscrowTrade
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+		Where("id = ? AND status IN ?", opts.TradeID, opts.From).
+		First(&trade).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(TradeStatusInvalid)
+		}
+		return nil, err
+	}
+
+	tradeStatus := model.EscrowStatusRefunded
+	orderStatus := model.OrderStatusRefund
+	if opts.Release {
+		if err := service.SettleHeldBalance(tx, service.SettleHeldOptions{
+			PayerID: trade.BuyerUserID,
+			PayeeID: trade.SellerUserID,
+			Amount:  trade.Amount,
+		}); err != nil {
+			return nil, err
+		}
+		tradeStatus = model.EscrowStatusReleased
+		orderStatus = model.OrderStatusSuccess
+	} else {
+		if err := service.ReturnHeldBalance(tx, trade.BuyerUserID, trade.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Model(&model.Order{}).
+		Where("id = ? AND status = ?", trade.OrderID, model.OrderStatusHeld).
+		Update("status", orderStatus).Error; err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	for k, v := range opts.Updates {
+		updates[k] = v
+	}
+	updates["status"] = tradeStatus
+	updates["settled_at"] = time.Now()
+	if err := tx.Model(&trade).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	return &trade, nil
+}
+
+// isTradeError 是否为可直接返回给用户的业务错误
+func isTradeError(err error) bool {
+	switch err.Error() {
+	case EscrowTradeNotFound, SellerNotFound, TradeStatusInvalid, TradeNotDeliverable:
+		return true
+	}
+	return false
+}
+
+// TradeQuery 查询担保交易及买卖双方用户名
+func TradeQuery(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&model.EscrowTrade{}).
+		Select("escrow_trades.*, buyer.username AS buyer_username, seller.username AS seller_username").
+		Joins("LEFT JOIN users AS buyer ON buyer.id = escrow_trades.buyer_user_id").
+		Joins("LEFT JOIN users AS seller ON seller.id = escrow_trades.seller_user_id")
+}
@@ -0,0 +1,28 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package invoice
+
+import "time"
+
+const (
+	// remindInterval 同一账单两次提醒的最小间隔
+	remindInterval = 24 * time.Hour
+	// dueSoonWindow 到期前该时长内自动提醒
+	dueSoonWindow = 24 * time.Hour
+	// maxOverdueReminders 逾期后最多自动提醒次数
+	maxOverdueReminders = 3
+)
@@ -0,0 +1,28 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package invoice
+
+const (
+	InvoiceNotFound          = "账单不存在"
+	InvoiceRecipientNotFound = "账单接收人不存在"
+	CannotInvoiceSelf        = "不能向自己发起账单"
+	InvalidInvoiceDueAt      = "到期时间必须晚于当前时间"
+	InvoiceNotPayable        = "账单已支付或已取消"
+	InvoiceNotCancellable    = "账单已支付或已取消，无法取消"
+	InvoiceRemindTooFrequent = "提醒过于频繁，请稍后再试"
+	MerchantInvoiceNoExists  = "商户账单号已存在"
+)
@@ -0,0 +1,475 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package invoice
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/apps/payment"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"gorm.io/gorm"
+)
+
+// ListInvoicesRequest 账单列表查询参数
+type ListInvoicesRequest struct {
+	Page     int                 `form:"page" binding:"min=1"`
+	PageSize int                 `form:"page_size" binding:"min=1,max=100"`
+	Status   model.InvoiceStatus `form:"status" binding:"omitempty,oneof=pending overdue paid cancelled"`
+}
+
+// ListInvoicesResponse 账单列表响应
+type ListInvoicesResponse struct {
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+	Invoices []model.Invoice `json:"invoices"`
+}
+
+// InboxSummary 收件箱概要
+type InboxSummary struct {
+	Unread  int64 `json:"unread"`  // 未读的待付款账单数，包含被提醒后重新置为未读的账单
+	Pending int64 `json:"pending"` // 待付款账单数
+	Overdue int64 `json:"overdue"` // 已逾期账单数
+}
+
+// PayInvoiceRequest 支付账单请求
+type PayInvoiceRequest struct {
+	PayKey   string `json:"pay_key" binding:"required,max=6"`
+	TOTPCode string `json:"totp_code" binding:"max=16"`
+}
+
+// CreateMerchantInvoiceRequest 商户开具账单请求
+type CreateMerchantInvoiceRequest struct {
+	CreateInvoiceRequest
+	MerchantInvoiceNo string `json:"out_invoice_no" binding:"required,max=64"`
+}
+
+// QueryMerchantInvoiceRequest 商户查询/取消账单请求
+type QueryMerchantInvoiceRequest struct {
+	MerchantInvoiceNo string `form:"out_invoice_no" json:"out_invoice_no" binding:"required,max=64"`
+}
+
+// invoiceQuery 账单查询，附带双方用户名
+func invoiceQuery(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&model.Invoice{}).
+		Select("invoices.*, issuer.username AS issuer_username, recipient.username AS recipient_username").
+		Joins("LEFT JOIN users AS issuer ON issuer.id = invoices.issuer_user_id").
+		Joins("LEFT JOIN users AS recipient ON recipient.id = invoices.recipient_user_id")
+}
+
+// listInvoices 按用户角色分页查询账单
+func listInvoices(c *gin.Context, userColumn string) {
+	var req ListInvoicesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	baseQuery := db.DB(c.Request.Context()).
+		Model(&model.Invoice{}).
+		Where("invoices."+userColumn+" = ?", currentUser.ID)
+	if req.Status != "" {
+		baseQuery = baseQuery.Where("invoices.status = ?", req.Status)
+	}
+
+	response := ListInvoicesResponse{
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Invoices: []model.Invoice{},
+	}
+	if err := baseQuery.Count(&response.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := invoiceQuery(baseQuery).
+		Order("invoices.created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&response.Invoices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
+}
+
+// CreateInvoice 向指定用户发起账单
+// @Tags invoice
+// @Accept json
+// @Produce json
+// @Param request body CreateInvoiceRequest true "创建账单请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/invoices [post]
+func CreateInvoice(c *gin.Context) {
+	var req CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	invoice, items, err := req.buildInvoice(currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	if err := createInvoice(db.DB(c.Request.Context()), invoice, items, req.RecipientUsername); err != nil {
+		switch err.Error() {
+		case CannotInvoiceSelf, InvoiceRecipientNotFound:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+	invoice.IssuerUsername = currentUser.Username
+
+	c.JSON(http.StatusOK, util.OK(invoice))
+}
+
+// ListInboxInvoices 收件箱：我需要支付的账单
+// @Tags invoice
+// @Produce json
+// @Param request query ListInvoicesRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/invoices/inbox [get]
+func ListInboxInvoices(c *gin.Context) {
+	listInvoices(c, "recipient_user_id")
+}
+
+// ListIssuedInvoices 我发起的账单
+// @Tags invoice
+// @Produce json
+// @Param request query ListInvoicesRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/invoices/issued [get]
+func ListIssuedInvoices(c *gin.Context) {
+	listInvoices(c, "issuer_user_id")
+}
+
+// GetInboxSummary 收件箱未读与待付款统计
+// @Tags invoice
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/invoices/inbox/summary [get]
+func GetInboxSummary(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var summary InboxSummary
+	if err := db.DB(c.Request.Context()).
+		Model(&model.Invoice{}).
+		Select("COUNT(*) FILTER (WHERE read_at IS NULL) AS unread, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS pending, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS overdue",
+			model.InvoiceStatusPending, model.InvoiceStatusOverdue).
+		Where("recipient_user_id = ? AND status IN ?", currentUser.ID, model.InvoicePayableStatuses).
+		Scan(&summary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(summary))
+}
+
+// GetInvoice 查询账单详情，接收人查看时标记为已读
+// @Tags invoice
+// @Produce json
+// @Param id path string true "账单ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/invoices/{id} [get]
+func GetInvoice(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var invoice model.Invoice
+	if err := invoiceQuery(db.DB(ctx)).
+		Where("invoices.id = ? AND (invoices.issuer_user_id = ? OR invoices.recipient_user_id = ?)", c.Param("id"), currentUser.ID, currentUser.ID).
+		First(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(InvoiceNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := loadInvoiceItems(db.DB(ctx), &invoice); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if invoice.RecipientUserID == currentUser.ID && invoice.ReadAt == nil {
+		now := time.Now()
+		if err := db.DB(ctx).Model(&model.Invoice{}).
+			Where("id = ?", invoice.ID).
+			UpdateColumn("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		invoice.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, util.OK(invoice))
+}
+
+// PayInvoice 使用支付密码支付账单
+// @Tags invoice
+// @Accept json
+// @Produce json
+// @Param id path string true "账单ID"
+// @Param request body PayInvoiceRequest true "支付请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/invoices/{id}/pay [post]
+func PayInvoice(c *gin.Context) {
+	var req PayInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var invoice model.Invoice
+	if err := db.DB(ctx).
+		Where("id = ? AND recipient_user_id = ?", invoiceID, currentUser.ID).
+		First(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(InvoiceNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := service.VerifyPaymentAuth(ctx, currentUser, req.PayKey, req.TOTPCode, invoice.Amount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	var order *model.Order
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = payInvoice(tx, invoiceID, currentUser.ID)
+		return err
+	}); err != nil {
+		switch err.Error() {
+		case InvoiceNotFound:
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+		case InvoiceNotPayable, common.InsufficientBalance, common.DailyLimitExceeded,
+			common.PayKeyResetCoolingLimited, common.MerchantUnavailable, common.TestModeCannotProcessOrder:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(gin.H{
+		"order_id": strconv.FormatUint(order.ID, 10),
+	}))
+}
+
+// CancelInvoice 发起人取消未付款的账单
+// @Tags invoice
+// @Produce json
+// @Param id path string true "账单ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/invoices/{id}/cancel [post]
+func CancelInvoice(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	query := db.DB(c.Request.Context()).Model(&model.Invoice{}).
+		Where("id = ? AND issuer_user_id = ? AND client_id = ''", c.Param("id"), currentUser.ID)
+	if err := cancelInvoice(query); err != nil {
+		if err.Error() == InvoiceNotCancellable {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// RemindInvoice 发起人提醒接收人付款，同一账单每 24 小时可提醒一次
+// @Tags invoice
+// @Produce json
+// @Param id path string true "账单ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/invoices/{id}/remind [post]
+func RemindInvoice(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	query := db.DB(c.Request.Context()).Model(&model.Invoice{}).
+		Where("id = ? AND issuer_user_id = ?", c.Param("id"), currentUser.ID)
+	reminded, err := remindInvoice(query, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if reminded == 0 {
+		c.JSON(http.StatusBadRequest, util.Err(InvoiceRemindTooFrequent))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// CreateMerchantInvoice 商户向指定用户开具账单，付款后按商户订单回调
+// @Tags invoice
+// @Accept json
+// @Produce json
+// @Param request body CreateMerchantInvoiceRequest true "开具账单请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/invoice [post]
+func CreateMerchantInvoice(c *gin.Context) {
+	var req CreateMerchantInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, payment.APIKeyObjKey)
+
+	invoice, items, err := req.buildInvoice(apiKey.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	invoice.ClientID = apiKey.ClientID
+	invoice.MerchantInvoiceNo = &req.MerchantInvoiceNo
+
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		// 账单号付款后作为商户订单号回调，需同时避免与已有订单冲突
+		var count int64
+		if err := tx.Model(&model.Invoice{}).
+			Where("client_id = ? AND merchant_invoice_no = ?", apiKey.ClientID, req.MerchantInvoiceNo).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := tx.Model(&model.Order{}).
+				Where("client_id = ? AND merchant_order_no = ?", apiKey.ClientID, req.MerchantInvoiceNo).
+				Count(&count).Error; err != nil {
+				return err
+			}
+		}
+		if count > 0 {
+			return errors.New(MerchantInvoiceNoExists)
+		}
+
+		return createInvoice(tx, invoice, items, req.RecipientUsername)
+	}); err != nil {
+		switch err.Error() {
+		case MerchantInvoiceNoExists, CannotInvoiceSelf, InvoiceRecipientNotFound:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(invoice))
+}
+
+// QueryMerchantInvoice 商户查询账单
+// @Tags invoice
+// @Produce json
+// @Param request query QueryMerchantInvoiceRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/invoice [get]
+func QueryMerchantInvoice(c *gin.Context) {
+	var req QueryMerchantInvoiceRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, payment.APIKeyObjKey)
+	ctx := c.Request.Context()
+
+	var invoice model.Invoice
+	if err := invoiceQuery(db.DB(ctx)).
+		Where("invoices.client_id = ? AND invoices.merchant_invoice_no = ?", apiKey.ClientID, req.MerchantInvoiceNo).
+		First(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(InvoiceNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := loadInvoiceItems(db.DB(ctx), &invoice); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(invoice))
+}
+
+// CancelMerchantInvoice 商户取消未付款的账单
+// @Tags invoice
+// @Accept json
+// @Produce json
+// @Param request body QueryMerchantInvoiceRequest true "取消请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/invoice/cancel [post]
+func CancelMerchantInvoice(c *gin.Context) {
+	var req QueryMerchantInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, payment.APIKeyObjKey)
+
+	query := db.DB(c.Request.Context()).Model(&model.Invoice{}).
+		Where("client_id = ? AND merchant_invoice_no = ?", apiKey.ClientID, req.MerchantInvoiceNo)
+	if err := cancelInvoice(query); err != nil {
+		if err.Error() == InvoiceNotCancellable {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package invoice
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
+	"github.com/linux-do/credit/internal/model"
+)
+
+// HandleInvoiceReminders 标记逾期账单，并对即将到期和已逾期的账单发送提醒
+// 提醒会将账单重新置为未读，在收款人的收件箱中突出显示
+func HandleInvoiceReminders(ctx context.Context, t *asynq.Task) error {
+	now := time.Now()
+
+	overdue := db.DB(ctx).Model(&model.Invoice{}).
+		Where("status = ? AND due_at <= ?", model.InvoiceStatusPending, now).
+		Updates(map[string]interface{}{
+			"status":  model.InvoiceStatusOverdue,
+			"read_at": nil,
+		})
+	if overdue.Error != nil {
+		logger.ErrorF(ctx, "标记逾期账单失败: %v", overdue.Error)
+		return overdue.Error
+	}
+
+	dueSoon, err := remindInvoice(db.DB(ctx).Model(&model.Invoice{}).
+		Where("status = ? AND due_at <= ?", model.InvoiceStatusPending, now.Add(dueSoonWindow)), now)
+	if err != nil {
+		logger.ErrorF(ctx, "提醒即将到期账单失败: %v", err)
+		return err
+	}
+
+	overdueReminded, err := remindInvoice(db.DB(ctx).Model(&model.Invoice{}).
+		Where("status = ? AND remind_count < ?", model.InvoiceStatusOverdue, maxOverdueReminders), now)
+	if err != nil {
+		logger.ErrorF(ctx, "提醒逾期账单失败: %v", err)
+		return err
+	}
+
+	logger.InfoF(ctx, "账单提醒完成: 新逾期[%d] 即将到期提醒[%d] 逾期提醒[%d]", overdue.RowsAffected, dueSoon, overdueReminded)
+	return nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package invoice
+
+import (
+	"errors"
+	"time"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InvoiceItemRequest 账单明细
+type InvoiceItemRequest struct {
+	Name      string          `json:"name" binding:"required,max=64"`
+	Quantity  uint            `json:"quantity" binding:"required,min=1,max=9999"`
+	UnitPrice decimal.Decimal `json:"unit_price" binding:"required"`
+}
+
+// CreateInvoiceRequest 创建账单请求，接收人需同时提供 ID 与用户名
+type CreateInvoiceRequest struct {
+	RecipientID       uint64               `json:"recipient_id,string" binding:"required"`
+	RecipientUsername string               `json:"recipient_username" binding:"required"`
+	Title             string               `json:"title" binding:"required,max=64"`
+	Note              string               `json:"note" binding:"max=255"`
+	DueAt             time.Time            `json:"due_at" binding:"required"`
+	Items             []InvoiceItemRequest `json:"items" binding:"required,min=1,max=50,dive"`
+}
+
+// buildInvoice 校验请求并计算账单金额
+func (r *CreateInvoiceRequest) buildInvoice(issuerID uint64) (*model.Invoice, []model.InvoiceItem, error) {
+	if !r.DueAt.After(time.Now()) {
+		return nil, nil, errors.New(InvalidInvoiceDueAt)
+	}
+
+	invoice := &model.Invoice{
+		IssuerUserID:    issuerID,
+		RecipientUserID: r.RecipientID,
+		Title:           r.Title,
+		Note:            r.Note,
+		DueAt:           r.DueAt,
+		Status:          model.InvoiceStatusPending,
+	}
+
+	items := make([]model.InvoiceItem, 0, len(r.Items))
+	total := decimal.Zero
+	for _, item := range r.Items {
+		if err := util.ValidateAmount(item.UnitPrice); err != nil {
+			return nil, nil, err
+		}
+		amount := item.UnitPrice.Mul(decimal.NewFromInt(int64(item.Quantity)))
+		total = total.Add(amount)
+		items = append(items, model.InvoiceItem{
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Amount:    amount,
+		})
+	}
+	invoice.Amount = total
+
+	return invoice, items, nil
+}
+
+// createInvoice 校验接收人后保存账单及明细
+func createInvoice(tx *gorm.DB, invoice *model.Invoice, items []model.InvoiceItem, recipientUsername string) error {
+	if invoice.IssuerUserID == invoice.RecipientUserID {
+		return errors.New(CannotInvoiceSelf)
+	}
+
+	var recipient model.User
+	if err := tx.Where("id = ? AND username = ? AND is_active = ?", invoice.RecipientUserID, recipientUsername, true).
+		First(&recipient).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(InvoiceRecipientNotFound)
+		}
+		return err
+	}
+
+	if err := tx.Create(invoice).Error; err != nil {
+		return err
+	}
+
+	for i := range items {
+		items[i].InvoiceID = invoice.ID
+	}
+	if err := tx.Create(&items).Error; err != nil {
+		return err
+	}
+
+	invoice.Items = items
+	invoice.RecipientUsername = recipient.Username
+	return nil
+}
+
+// loadInvoiceItems 查询账单明细
+func loadInvoiceItems(tx *gorm.DB, invoice *model.Invoice) error {
+	return tx.Where("invoice_id = ?", invoice.ID).Order("id ASC").Find(&invoice.Items).Error
+}
+
+// payInvoice 在事务中完成账单付款并返回生成的订单
+// 商户开具的账单按商户订单结算并回调商户，个人账单按转账结算
+func payInvoice(tx *gorm.DB, invoiceID uint64, payerID uint64) (*model.Order, error) {
+	var invoice model.Invoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+		Where("id = ? AND recipient_user_id = ?", invoiceID, payerID).
+		First(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(InvoiceNotFound)
+		}
+		return nil, err
+	}
+	if invoice.Status != model.InvoiceStatusPending && invoice.Status != model.InvoiceStatusOverdue {
+		return nil, errors.New(InvoiceNotPayable)
+	}
+
+	var order *model.Order
+	var err error
+	if invoice.ClientID != "" {
+		order, err = chargeMerchantInvoice(tx, &invoice)
+	} else {
+		order, err = transferInvoice(tx, &invoice)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := tx.Model(&invoice).Updates(map[string]interface{}{
+		"status":   model.InvoiceStatusPaid,
+		"order_id": order.ID,
+		"paid_at":  now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// chargeMerchantInvoice 商户账单按商户费率结算，商户账单号作为商户订单号回调
+func chargeMerchantInvoice(tx *gorm.DB, invoice *model.Invoice) (*model.Order, error) {
+	var apiKey model.MerchantAPIKey
+	if err := apiKey.GetByClientID(tx, invoice.ClientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(common.MerchantUnavailable)
+		}
+		return nil, err
+	}
+
+	return service.DirectCharge(tx, service.DirectChargeOptions{
+		PayerID:         invoice.RecipientUserID,
+		APIKey:          &apiKey,
+		Amount:          invoice.Amount,
+		OrderName:       invoice.Title,
+		MerchantOrderNo: invoice.MerchantInvoiceNo,
+		Remark:          invoice.Note,
+		PaymentType:     common.PayTypeInvoice,
+	})
+}
+
+// transferInvoice 个人账单按转账结算
+func transferInvoice(tx *gorm.DB, invoice *model.Invoice) (*model.Order, error) {
+	return service.Transfer(tx, service.TransferOptions{
+		PayerID:   invoice.RecipientUserID,
+		PayeeID:   invoice.IssuerUserID,
+		Amount:    invoice.Amount,
+		OrderName: invoice.Title,
+		Remark:    invoice.Note,
+	})
+}
+
+// cancelInvoice 取消待付款或已逾期的账单
+func cancelInvoice(query *gorm.DB) error {
+	result := query.Where("status IN ?", model.InvoicePayableStatuses).
+		Updates(map[string]interface{}{
+			"status":       model.InvoiceStatusCancelled,
+			"cancelled_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(InvoiceNotCancellable)
+	}
+	return nil
+}
+
+// remindInvoice 提醒接收人：记录提醒次数并将账单重置为未读
+func remindInvoice(query *gorm.DB, now time.Time) (int64, error) {
+	result := query.Where("status IN ? AND (last_reminded_at IS NULL OR last_reminded_at <= ?)",
+		model.InvoicePayableStatuses, now.Add(-remindInterval)).
+		Updates(map[string]interface{}{
+			"remind_count":     gorm.Expr("remind_count + 1"),
+			"last_reminded_at": now,
+			"read_at":          nil,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,28 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mandate
+
+const (
+	MandateNotFound               = "代扣协议不存在"
+	MandateNotActive              = "代扣协议未生效或已解约"
+	MandateSignExpired            = "签约链接已过期"
+	MandateNoExists               = "商户协议号已存在"
+	InvalidMandatePeriod          = "不支持的扣款周期"
+	PerChargeLimitTooLarge        = "单笔上限不能超过周期上限"
+	MandatePeriodLimitExceeded    = "已超过代扣协议周期额度"
+	MandatePerChargeLimitExceeded = "已超过代扣协议单笔额度"
+)
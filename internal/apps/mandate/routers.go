@@ -0,0 +1,466 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mandate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/apps/payment"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// CreateMandateRequest 商户发起代扣签约请求
+type CreateMandateRequest struct {
+	Name              string              `json:"name" binding:"required,max=64"`
+	MerchantMandateNo *string             `json:"out_mandate_no" binding:"omitempty,min=1,max=64"`
+	Period            model.MandatePeriod `json:"period" binding:"required"`
+	PeriodLimit       decimal.Decimal     `json:"period_limit" binding:"required"`
+	PerChargeLimit    decimal.Decimal     `json:"per_charge_limit"`
+}
+
+// CreateMandate 商户发起代扣签约，返回用户签约页地址
+// @Tags mandate
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Basic Auth (base64(client_id:client_secret))"
+// @Param request body CreateMandateRequest true "签约请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/mandate [post]
+func CreateMandate(c *gin.Context) {
+	var req CreateMandateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	if !req.Period.Valid() {
+		c.JSON(http.StatusBadRequest, util.Err(InvalidMandatePeriod))
+		return
+	}
+	if err := util.ValidateAmount(req.PeriodLimit); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	if !req.PerChargeLimit.IsZero() {
+		if err := util.ValidateAmount(req.PerChargeLimit); err != nil {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		if req.PerChargeLimit.GreaterThan(req.PeriodLimit) {
+			c.JSON(http.StatusBadRequest, util.Err(PerChargeLimitTooLarge))
+			return
+		}
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, payment.APIKeyObjKey)
+	ctx := c.Request.Context()
+
+	if req.MerchantMandateNo != nil {
+		var count int64
+		if err := db.DB(ctx).Model(&model.PaymentMandate{}).
+			Where("client_id = ? AND merchant_mandate_no = ?", apiKey.ClientID, *req.MerchantMandateNo).
+			Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusBadRequest, util.Err(MandateNoExists))
+			return
+		}
+	}
+
+	mandate := model.PaymentMandate{
+		Token:             util.GenerateUniqueIDSimple(),
+		APIKeyID:          apiKey.ID,
+		ClientID:          apiKey.ClientID,
+		MerchantMandateNo: req.MerchantMandateNo,
+		MerchantUserID:    apiKey.UserID,
+		Name:              req.Name,
+		Period:            req.Period,
+		PeriodLimit:       req.PeriodLimit,
+		PerChargeLimit:    req.PerChargeLimit,
+		Status:            model.MandateStatusPending,
+		SignExpiresAt:     time.Now().Add(MandateSignExpiration),
+	}
+	if err := db.DB(ctx).Create(&mandate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(gin.H{
+		"mandate_id":      strconv.FormatUint(mandate.ID, 10),
+		"out_mandate_no":  mandate.MerchantMandateNo,
+		"sign_url":        fmt.Sprintf("%s?mandate_token=%s", config.Config.App.FrontendPayURL, url.QueryEscape(mandate.Token)),
+		"sign_expires_at": mandate.SignExpiresAt,
+	}))
+}
+
+// QueryMandateRequest 商户查询代扣协议请求
+type QueryMandateRequest struct {
+	MandateID uint64 `form:"mandate_id" binding:"required"`
+}
+
+// QueryMandate 商户查询代扣协议状态及本周期已用额度
+// @Tags mandate
+// @Produce json
+// @Param Authorization header string true "Basic Auth (base64(client_id:client_secret))"
+// @Param request query QueryMandateRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/mandate [get]
+func QueryMandate(c *gin.Context) {
+	var req QueryMandateRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, payment.APIKeyObjKey)
+	ctx := c.Request.Context()
+
+	var mandate model.PaymentMandate
+	if err := db.DB(ctx).
+		Where("id = ? AND client_id = ?", req.MandateID, apiKey.ClientID).
+		First(&mandate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(MandateNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	used, err := periodUsedAmount(db.DB(ctx), &mandate, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	var username string
+	if mandate.UserID != nil {
+		if err := db.DB(ctx).Model(&model.User{}).
+			Where("id = ?", *mandate.UserID).
+			Pluck("username", &username).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, util.OK(gin.H{
+		"mandate_id":       strconv.FormatUint(mandate.ID, 10),
+		"out_mandate_no":   mandate.MerchantMandateNo,
+		"status":           mandate.Status,
+		"user_id":          mandate.UserID,
+		"username":         username,
+		"period":           mandate.Period,
+		"period_limit":     mandate.PeriodLimit,
+		"per_charge_limit": mandate.PerChargeLimit,
+		"period_used":      used,
+		"period_start":     mandate.Period.PeriodStart(now),
+		"signed_at":        mandate.SignedAt,
+		"revoked_at":       mandate.RevokedAt,
+	}))
+}
+
+// ChargeMandateRequest 商户基于代扣协议扣款请求
+type ChargeMandateRequest struct {
+	MandateID       uint64          `json:"mandate_id,string" binding:"required"`
+	OrderName       string          `json:"order_name" binding:"required,max=64"`
+	MerchantOrderNo *string         `json:"out_trade_no" binding:"omitempty,min=1,max=64"`
+	Amount          decimal.Decimal `json:"amount" binding:"required"`
+	Remark          string          `json:"remark" binding:"max=100"`
+}
+
+// ChargeMandate 商户基于已签约的代扣协议免密扣款（服务端调用）
+// @Tags mandate
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Basic Auth (base64(client_id:client_secret))"
+// @Param request body ChargeMandateRequest true "扣款请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/mandate/charge [post]
+func ChargeMandate(c *gin.Context) {
+	var req ChargeMandateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	if err := util.ValidateAmount(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, payment.APIKeyObjKey)
+	ctx := c.Request.Context()
+
+	var mandate model.PaymentMandate
+	if err := db.DB(ctx).
+		Where("id = ? AND client_id = ?", req.MandateID, apiKey.ClientID).
+		First(&mandate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(MandateNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if mandate.Status != model.MandateStatusActive || mandate.UserID == nil {
+		c.JSON(http.StatusBadRequest, util.Err(MandateNotActive))
+		return
+	}
+
+	var order *model.Order
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = service.DirectCharge(tx, service.DirectChargeOptions{
+			PayerID:         *mandate.UserID,
+			APIKey:          apiKey,
+			Amount:          req.Amount,
+			OrderName:       req.OrderName,
+			MerchantOrderNo: req.MerchantOrderNo,
+			Remark:          req.Remark,
+			PaymentType:     common.PayTypeMandate,
+			MandateID:       &mandate.ID,
+			BeforeCharge: func(tx *gorm.DB, payer *model.User) error {
+				return checkMandateCharge(tx, mandate.ID, payer.ID, req.Amount)
+			},
+		})
+		return err
+	}); err != nil {
+		if isChargeBusinessError(err.Error()) {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(gin.H{
+		"trade_no":     strconv.FormatUint(order.ID, 10),
+		"out_trade_no": order.MerchantOrderNo,
+		"mandate_id":   strconv.FormatUint(mandate.ID, 10),
+		"amount":       order.Amount,
+		"status":       order.Status,
+		"trade_time":   order.TradeTime,
+	}))
+}
+
+// MandateSignInfo 签约页展示信息
+type MandateSignInfo struct {
+	model.PaymentMandate
+	AppName        string `json:"app_name"`
+	AppHomepageURL string `json:"app_homepage_url"`
+	RedirectURL    string `json:"redirect_url"`
+}
+
+// GetMandateByToken 查询待签约的代扣协议（用于收银台签约页）
+// @Tags mandate
+// @Produce json
+// @Param token path string true "签约凭证"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/mandates/{token} [get]
+func GetMandateByToken(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var mandate model.PaymentMandate
+	if err := mandate.GetByToken(db.DB(ctx), c.Param("token")); err != nil || mandate.Status != model.MandateStatusPending {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(MandateNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if mandate.SignExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, util.Err(MandateSignExpired))
+		return
+	}
+
+	var apiKey model.MerchantAPIKey
+	if err := apiKey.GetByID(db.DB(ctx), mandate.APIKeyID); err != nil {
+		c.JSON(http.StatusNotFound, util.Err(common.MerchantUnavailable))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(MandateSignInfo{
+		PaymentMandate: mandate,
+		AppName:        apiKey.AppName,
+		AppHomepageURL: apiKey.AppHomepageURL,
+		RedirectURL:    apiKey.RedirectURL,
+	}))
+}
+
+// SignMandateRequest 用户签约请求
+type SignMandateRequest struct {
+	Token    string `json:"token" binding:"required"`
+	PayKey   string `json:"pay_key" binding:"required,max=6"`
+	TOTPCode string `json:"totp_code" binding:"max=16"`
+}
+
+// SignMandate 用户验证支付密码后签约代扣协议
+// @Tags mandate
+// @Accept json
+// @Produce json
+// @Param request body SignMandateRequest true "签约请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/mandates/sign [post]
+func SignMandate(c *gin.Context) {
+	var req SignMandateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var mandate model.PaymentMandate
+	if err := mandate.GetByToken(db.DB(ctx), req.Token); err != nil || mandate.Status != model.MandateStatusPending {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(MandateNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if mandate.SignExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, util.Err(MandateSignExpired))
+		return
+	}
+	if mandate.MerchantUserID == currentUser.ID {
+		c.JSON(http.StatusBadRequest, util.Err(common.CannotPaySelf))
+		return
+	}
+
+	// 签约授权的是整个周期额度，按周期上限校验二次验证
+	if err := service.VerifyPaymentAuth(ctx, currentUser, req.PayKey, req.TOTPCode, mandate.PeriodLimit, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	result := db.DB(ctx).Model(&model.PaymentMandate{}).
+		Where("id = ? AND status = ? AND sign_expires_at > ?", mandate.ID, model.MandateStatusPending, now).
+		Updates(map[string]interface{}{
+			"user_id":   currentUser.ID,
+			"status":    model.MandateStatusActive,
+			"signed_at": now,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, util.Err(MandateNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// UserMandate 用户已签约的代扣协议
+type UserMandate struct {
+	model.PaymentMandate
+	AppName        string          `json:"app_name"`
+	AppHomepageURL string          `json:"app_homepage_url"`
+	PeriodUsed     decimal.Decimal `json:"period_used" gorm:"-"`
+}
+
+// ListMandates 查询当前用户的代扣协议
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/mandates [get]
+func ListMandates(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var mandates []UserMandate
+	if err := db.DB(ctx).
+		Table("payment_mandates AS m").
+		Select("m.*, k.app_name, k.app_homepage_url").
+		Joins("LEFT JOIN merchant_api_keys k ON k.id = m.api_key_id").
+		Where("m.user_id = ?", currentUser.ID).
+		Order("m.created_at DESC").
+		Scan(&mandates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	for i := range mandates {
+		if mandates[i].Status != model.MandateStatusActive {
+			continue
+		}
+		used, err := periodUsedAmount(db.DB(ctx), &mandates[i].PaymentMandate, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		mandates[i].PeriodUsed = used
+	}
+
+	c.JSON(http.StatusOK, util.OK(mandates))
+}
+
+// RevokeMandate 用户解约代扣协议，解约后商户无法继续扣款
+// @Tags user
+// @Produce json
+// @Param id path string true "协议ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/mandates/{id} [delete]
+func RevokeMandate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	result := db.DB(c.Request.Context()).Model(&model.PaymentMandate{}).
+		Where("id = ? AND user_id = ? AND status = ?", id, currentUser.ID, model.MandateStatusActive).
+		Updates(map[string]interface{}{
+			"status":     model.MandateStatusRevoked,
+			"revoked_at": time.Now(),
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, util.Err(MandateNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
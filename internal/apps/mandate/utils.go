@@ -0,0 +1,78 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mandate
+
+import (
+	"errors"
+	"time"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// periodUsedAmount 统计协议在当前周期内已成功扣款的金额
+func periodUsedAmount(tx *gorm.DB, mandate *model.PaymentMandate, now time.Time) (decimal.Decimal, error) {
+	var used decimal.Decimal
+	err := tx.Model(&model.Order{}).
+		Where("mandate_id = ? AND status = ? AND trade_time >= ?",
+			mandate.ID,
+			model.OrderStatusSuccess,
+			mandate.Period.PeriodStart(now)).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&used).Error
+	return used, err
+}
+
+// checkMandateCharge 在扣款事务中锁定协议并校验状态、单笔及周期额度
+func checkMandateCharge(tx *gorm.DB, mandateID uint64, payerID uint64, amount decimal.Decimal) error {
+	var mandate model.PaymentMandate
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ? AND status = ?", mandateID, payerID, model.MandateStatusActive).
+		First(&mandate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(MandateNotActive)
+		}
+		return err
+	}
+
+	if mandate.PerChargeLimit.IsPositive() && amount.GreaterThan(mandate.PerChargeLimit) {
+		return errors.New(MandatePerChargeLimitExceeded)
+	}
+
+	used, err := periodUsedAmount(tx, &mandate, time.Now())
+	if err != nil {
+		return err
+	}
+	if used.Add(amount).GreaterThan(mandate.PeriodLimit) {
+		return errors.New(MandatePeriodLimitExceeded)
+	}
+	return nil
+}
+
+// isChargeBusinessError 是否为可返回给商户的业务错误
+func isChargeBusinessError(msg string) bool {
+	switch msg {
+	case common.InsufficientBalance, common.DailyLimitExceeded, common.CannotPaySelf,
+		common.TestModeCannotProcessOrder, common.BannedAccount, common.MerchantUnavailable,
+		common.PayKeyResetCoolingLimited, MandateNotActive, MandatePeriodLimitExceeded, MandatePerChargeLimitExceeded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package coupon
+
+const (
+	CouponNotFound           = "优惠券不存在"
+	CouponCodeExists         = "优惠码已存在"
+	InvalidDiscountType      = "不支持的优惠方式"
+	InvalidDiscountPercent   = "折扣比例必须在 0 到 100 之间"
+	InvalidMinAmount         = "使用门槛不能为负数"
+	InvalidValidityWindow    = "结束时间必须晚于开始时间"
+	InvalidCouponPaymentLink = "适用的支付链接不存在"
+)
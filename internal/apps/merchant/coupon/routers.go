@@ -0,0 +1,373 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package coupon
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/merchant"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// CouponRequest 创建/更新优惠券请求
+type CouponRequest struct {
+	Code          string                   `json:"code" binding:"required,min=3,max=32,alphanum"`
+	Name          string                   `json:"name" binding:"max=30"`
+	DiscountType  model.CouponDiscountType `json:"discount_type" binding:"required"`
+	DiscountValue decimal.Decimal          `json:"discount_value"` // 按比例时为减免百分比，如 15 表示减 15%
+	MaxDiscount   *decimal.Decimal         `json:"max_discount"`
+	MinAmount     decimal.Decimal          `json:"min_amount"`
+	TotalLimit    *uint                    `json:"total_limit" binding:"omitempty,min=1"`
+	UserLimit     *uint                    `json:"user_limit" binding:"omitempty,min=1"`
+	Enabled       *bool                    `json:"enabled"` // 为空时默认启用
+	StartsAt      *time.Time               `json:"starts_at"`
+	EndsAt        *time.Time               `json:"ends_at"`
+	// PaymentLinkIDs 限定可用的支付链接，为空表示该 API Key 下的链接和商户订单均可使用
+	PaymentLinkIDs []string `json:"payment_link_ids" binding:"max=100"`
+}
+
+// validate 校验优惠参数，返回去重后的支付链接 ID
+func (r *CouponRequest) validate() ([]uint64, error) {
+	if !r.DiscountType.Valid() {
+		return nil, errors.New(InvalidDiscountType)
+	}
+
+	switch r.DiscountType {
+	case model.CouponDiscountPercent:
+		if r.DiscountValue.LessThanOrEqual(decimal.Zero) || r.DiscountValue.GreaterThanOrEqual(decimal.NewFromInt(100)) {
+			return nil, errors.New(InvalidDiscountPercent)
+		}
+		if r.MaxDiscount != nil {
+			if err := util.ValidateAmount(*r.MaxDiscount); err != nil {
+				return nil, err
+			}
+		}
+	case model.CouponDiscountFixed:
+		if err := util.ValidateAmount(r.DiscountValue); err != nil {
+			return nil, err
+		}
+		r.MaxDiscount = nil
+	}
+
+	if r.MinAmount.IsNegative() {
+		return nil, errors.New(InvalidMinAmount)
+	}
+	if !r.MinAmount.IsZero() {
+		if err := util.ValidateAmount(r.MinAmount); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+		return nil, errors.New(InvalidValidityWindow)
+	}
+
+	seen := make(map[uint64]struct{}, len(r.PaymentLinkIDs))
+	linkIDs := make([]uint64, 0, len(r.PaymentLinkIDs))
+	for _, raw := range r.PaymentLinkIDs {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, errors.New(InvalidCouponPaymentLink)
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		linkIDs = append(linkIDs, id)
+	}
+
+	r.Code = service.NormalizeCouponCode(r.Code)
+	return linkIDs, nil
+}
+
+// checkPaymentLinks 校验支付链接均属于当前 API Key
+func checkPaymentLinks(tx *gorm.DB, apiKeyID uint64, linkIDs []uint64) error {
+	if len(linkIDs) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&model.MerchantPaymentLink{}).
+		Where("id IN ? AND merchant_api_key_id = ?", linkIDs, apiKeyID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(linkIDs)) {
+		return errors.New(InvalidCouponPaymentLink)
+	}
+	return nil
+}
+
+// checkCodeUnique 同一 API Key 下优惠码不可重复
+func checkCodeUnique(tx *gorm.DB, apiKeyID uint64, code string, excludeID uint64) error {
+	var count int64
+	if err := tx.Model(&model.MerchantCoupon{}).
+		Where("merchant_api_key_id = ? AND code = ? AND id <> ?", apiKeyID, code, excludeID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New(CouponCodeExists)
+	}
+	return nil
+}
+
+// saveCouponLinks 覆盖保存优惠券适用的支付链接
+func saveCouponLinks(tx *gorm.DB, couponID uint64, linkIDs []uint64) error {
+	if err := tx.Where("coupon_id = ?", couponID).Delete(&model.MerchantCouponLink{}).Error; err != nil {
+		return err
+	}
+	if len(linkIDs) == 0 {
+		return nil
+	}
+
+	links := make([]model.MerchantCouponLink, 0, len(linkIDs))
+	for _, id := range linkIDs {
+		links = append(links, model.MerchantCouponLink{CouponID: couponID, PaymentLinkID: id})
+	}
+	return tx.Create(&links).Error
+}
+
+// respondCouponError 按错误类型返回响应
+func respondCouponError(c *gin.Context, err error) {
+	switch err.Error() {
+	case CouponCodeExists, InvalidCouponPaymentLink:
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+	case CouponNotFound:
+		c.JSON(http.StatusNotFound, util.Err(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+	}
+}
+
+// ListCoupons 获取优惠券列表
+// @Tags merchant
+// @Produce json
+// @Param id path uint64 true "API Key ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/api-keys/{id}/coupons [get]
+func ListCoupons(c *gin.Context) {
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, merchant.APIKeyObjKey)
+
+	var coupons []model.MerchantCoupon
+	if err := db.DB(c.Request.Context()).
+		Select("merchant_coupons.*, (SELECT COUNT(*) FROM orders WHERE orders.coupon_id = merchant_coupons.id AND orders.status = ? AND orders.type <> ?) AS used_count",
+			model.OrderStatusSuccess, model.OrderTypeTest).
+		Where("merchant_api_key_id = ?", apiKey.ID).
+		Order("created_at DESC").
+		Find(&coupons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if len(coupons) > 0 {
+		couponIDs := make([]uint64, 0, len(coupons))
+		for _, coupon := range coupons {
+			couponIDs = append(couponIDs, coupon.ID)
+		}
+
+		var links []model.MerchantCouponLink
+		if err := db.DB(c.Request.Context()).Where("coupon_id IN ?", couponIDs).Find(&links).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+
+		linkMap := make(map[uint64][]string, len(coupons))
+		for _, link := range links {
+			linkMap[link.CouponID] = append(linkMap[link.CouponID], strconv.FormatUint(link.PaymentLinkID, 10))
+		}
+		for i := range coupons {
+			coupons[i].PaymentLinkIDs = linkMap[coupons[i].ID]
+			if coupons[i].PaymentLinkIDs == nil {
+				coupons[i].PaymentLinkIDs = []string{}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, util.OK(coupons))
+}
+
+// CreateCoupon 创建优惠券
+// @Tags merchant
+// @Accept json
+// @Produce json
+// @Param id path uint64 true "API Key ID"
+// @Param request body CouponRequest true "创建优惠券请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/api-keys/{id}/coupons [post]
+func CreateCoupon(c *gin.Context) {
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	linkIDs, err := req.validate()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, merchant.APIKeyObjKey)
+
+	coupon := model.MerchantCoupon{
+		MerchantAPIKeyID: apiKey.ID,
+		Code:             req.Code,
+		Name:             req.Name,
+		DiscountType:     req.DiscountType,
+		DiscountValue:    req.DiscountValue,
+		MaxDiscount:      req.MaxDiscount,
+		MinAmount:        req.MinAmount,
+		TotalLimit:       req.TotalLimit,
+		UserLimit:        req.UserLimit,
+		Enabled:          req.Enabled == nil || *req.Enabled,
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+		PaymentLinkIDs:   req.PaymentLinkIDs,
+	}
+	if coupon.PaymentLinkIDs == nil {
+		coupon.PaymentLinkIDs = []string{}
+	}
+
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", apiKey.ID).Error; err != nil {
+			return err
+		}
+		if err := checkCodeUnique(tx, apiKey.ID, coupon.Code, 0); err != nil {
+			return err
+		}
+		if err := checkPaymentLinks(tx, apiKey.ID, linkIDs); err != nil {
+			return err
+		}
+		if err := tx.Create(&coupon).Error; err != nil {
+			return err
+		}
+		return saveCouponLinks(tx, coupon.ID, linkIDs)
+	}); err != nil {
+		respondCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(coupon))
+}
+
+// UpdateCoupon 更新优惠券，修改仅对后续支付生效
+// @Tags merchant
+// @Accept json
+// @Produce json
+// @Param id path uint64 true "API Key ID"
+// @Param couponId path uint64 true "Coupon ID"
+// @Param request body CouponRequest true "更新优惠券请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/api-keys/{id}/coupons/{couponId} [put]
+func UpdateCoupon(c *gin.Context) {
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	linkIDs, err := req.validate()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	couponID, err := strconv.ParseUint(c.Param("couponId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, merchant.APIKeyObjKey)
+
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", apiKey.ID).Error; err != nil {
+			return err
+		}
+		if err := checkCodeUnique(tx, apiKey.ID, req.Code, couponID); err != nil {
+			return err
+		}
+		if err := checkPaymentLinks(tx, apiKey.ID, linkIDs); err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.MerchantCoupon{}).
+			Where("id = ? AND merchant_api_key_id = ?", couponID, apiKey.ID).
+			Updates(map[string]interface{}{
+				"code":           req.Code,
+				"name":           req.Name,
+				"discount_type":  req.DiscountType,
+				"discount_value": req.DiscountValue,
+				"max_discount":   req.MaxDiscount,
+				"min_amount":     req.MinAmount,
+				"total_limit":    req.TotalLimit,
+				"user_limit":     req.UserLimit,
+				"enabled":        req.Enabled == nil || *req.Enabled,
+				"starts_at":      req.StartsAt,
+				"ends_at":        req.EndsAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(CouponNotFound)
+		}
+		return saveCouponLinks(tx, couponID, linkIDs)
+	}); err != nil {
+		respondCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// DeleteCoupon 删除优惠券，已使用该优惠券的订单不受影响
+// @Tags merchant
+// @Produce json
+// @Param id path uint64 true "API Key ID"
+// @Param couponId path uint64 true "Coupon ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/api-keys/{id}/coupons/{couponId} [delete]
+func DeleteCoupon(c *gin.Context) {
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, merchant.APIKeyObjKey)
+
+	result := db.DB(c.Request.Context()).
+		Where("id = ? AND merchant_api_key_id = ?", c.Param("couponId"), apiKey.ID).
+		Delete(&model.MerchantCoupon{})
+
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, util.Err(CouponNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
@@ -17,7 +17,22 @@ limitations under the License.
 package link
 
 const (
-	PaymentLinkNotFound           = "支付链接不存在"
-	PaymentLinkTotalLimitExceeded = "该支付链接已达到付款次数上限"
-	PaymentLinkUserLimitExceeded  = "您已达到该链接的付款次数限制"
+	PaymentLinkNotFound                 = "支付链接不存在"
+	PaymentLinkTotalLimitExceeded       = "该支付链接已达到付款次数上限"
+	PaymentLinkUserLimitExceeded        = "您已达到该链接的付款次数限制"
+	InvalidSubscriptionInterval         = "不支持的订阅周期"
+	SubscriptionLinkNotPayable          = "订阅链接请通过订阅接口开通"
+	SubscriptionAmountMustBeFixed       = "订阅链接不支持自定金额"
+	InvalidAmountRange                  = "最低金额不能高于最高金额"
+	SuggestedAmountOutOfRange           = "建议金额不在允许范围内"
+	AmountOutOfRange                    = "支付金额不在允许范围内"
+	PayAmountRequired                   = "请输入支付金额"
+	PayerRemarkRequired                 = "请填写付款备注"
+	InvalidAvailabilityWindow           = "结束时间必须晚于开始时间"
+	PaymentLinkNotStarted               = "支付链接尚未开放"
+	PaymentLinkEnded                    = "支付链接已截止"
+	SubscriptionAutoDeliveryUnsupported = "订阅链接不支持自动发卡"
+	PaymentLinkItemNotFound             = "卡密不存在或已售出"
+	PaymentLinkItemsEmpty               = "卡密内容不能为空"
+	NotAutoDeliveryLink                 = "该链接未开启自动发卡"
 )
@@ -40,3 +40,22 @@ func RequirePaymentLink() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireOwnedPaymentLink 查询当前 API Key 下的支付链接并保存到上下文
+func RequireOwnedPaymentLink() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, merchant.APIKeyObjKey)
+
+		var paymentLink model.MerchantPaymentLink
+		if err := db.DB(c.Request.Context()).
+			Where("id = ? AND merchant_api_key_id = ?", c.Param("linkId"), apiKey.ID).
+			First(&paymentLink).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, util.Err(PaymentLinkNotFound))
+			return
+		}
+
+		util.SetToContext(c, merchant.PaymentLinkObjKey, &paymentLink)
+
+		c.Next()
+	}
+}
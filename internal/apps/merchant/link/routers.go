@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -33,22 +34,93 @@ import (
 	"github.com/linux-do/credit/internal/util"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PayByLinkRequest 通过支付链接支付请求
 type PayByLinkRequest struct {
-	Token  string `json:"token" binding:"required"`
-	PayKey string `json:"pay_key" binding:"required,max=6"`
-	Remark string `json:"remark" binding:"max=100"`
+	Token    string          `json:"token" binding:"required"`
+	PayKey   string          `json:"pay_key" binding:"required,max=6"`
+	TOTPCode string          `json:"totp_code" binding:"max=16"`
+	Amount   decimal.Decimal `json:"amount"` // 自定金额链接由付款人填写
+	Remark   string          `json:"remark" binding:"max=100"`
+	// CouponCode 优惠码，为空表示不使用优惠券
+	CouponCode string `json:"coupon_code" binding:"max=32"`
 }
 
 // PaymentLinkRequest 创建支付链接请求
 type PaymentLinkRequest struct {
-	Amount      decimal.Decimal `json:"amount" binding:"required"`
+	Amount      decimal.Decimal `json:"amount"`
 	ProductName string          `json:"product_name" binding:"required,max=30"`
 	Remark      string          `json:"remark" binding:"max=100"`
 	TotalLimit  *uint           `json:"total_limit" binding:"omitempty,min=1"`
 	UserLimit   *uint           `json:"user_limit" binding:"omitempty,min=1"`
+	// 自定金额链接：min_amount 与 max_amount 至少填写一个，amount 作为建议金额可为 0
+	MinAmount     *decimal.Decimal `json:"min_amount"`
+	MaxAmount     *decimal.Decimal `json:"max_amount"`
+	RequireRemark bool             `json:"require_remark"`
+	RemarkHint    string           `json:"remark_hint" binding:"max=50"`
+	AutoDelivery  bool             `json:"auto_delivery"` // 发卡链接，付款成功后自动发放库存卡密
+	StartsAt      *time.Time       `json:"starts_at"`
+	EndsAt        *time.Time       `json:"ends_at"`
+	// 订阅链接：按 interval_count 个 interval 周期扣费，为空表示一次性链接
+	Interval      *model.SubscriptionInterval `json:"interval"`
+	IntervalCount uint                        `json:"interval_count" binding:"omitempty,min=1,max=365"`
+	GraceDays     uint                        `json:"grace_days" binding:"max=30"`
+}
+
+// validate 校验金额、开放时间与订阅参数，并为订阅链接补全默认周期数
+func (r *PaymentLinkRequest) validate() error {
+	if r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+		return errors.New(InvalidAvailabilityWindow)
+	}
+
+	if r.AutoDelivery && r.Interval != nil {
+		return errors.New(SubscriptionAutoDeliveryUnsupported)
+	}
+
+	if r.MinAmount == nil && r.MaxAmount == nil {
+		if err := util.ValidateAmount(r.Amount); err != nil {
+			return err
+		}
+	} else {
+		if r.Interval != nil {
+			return errors.New(SubscriptionAmountMustBeFixed)
+		}
+		for _, bound := range []*decimal.Decimal{r.MinAmount, r.MaxAmount} {
+			if bound == nil {
+				continue
+			}
+			if err := util.ValidateAmount(*bound); err != nil {
+				return err
+			}
+		}
+		if r.MinAmount != nil && r.MaxAmount != nil && r.MinAmount.GreaterThan(*r.MaxAmount) {
+			return errors.New(InvalidAmountRange)
+		}
+		if !r.Amount.IsZero() {
+			if err := util.ValidateAmount(r.Amount); err != nil {
+				return err
+			}
+			if (r.MinAmount != nil && r.Amount.LessThan(*r.MinAmount)) ||
+				(r.MaxAmount != nil && r.Amount.GreaterThan(*r.MaxAmount)) {
+				return errors.New(SuggestedAmountOutOfRange)
+			}
+		}
+	}
+
+	if r.Interval == nil {
+		r.IntervalCount = 0
+		r.GraceDays = 0
+		return nil
+	}
+	if !r.Interval.Valid() {
+		return errors.New(InvalidSubscriptionInterval)
+	}
+	if r.IntervalCount == 0 {
+		r.IntervalCount = 1
+	}
+	return nil
 }
 
 // CreatePaymentLink 创建支付链接
@@ -66,8 +138,7 @@ func CreatePaymentLink(c *gin.Context) {
 		return
 	}
 
-	// 验证金额
-	if err := util.ValidateAmount(req.Amount); err != nil {
+	if err := req.validate(); err != nil {
 		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
@@ -82,6 +153,16 @@ func CreatePaymentLink(c *gin.Context) {
 		Remark:           req.Remark,
 		TotalLimit:       req.TotalLimit,
 		UserLimit:        req.UserLimit,
+		MinAmount:        req.MinAmount,
+		MaxAmount:        req.MaxAmount,
+		RequireRemark:    req.RequireRemark,
+		RemarkHint:       req.RemarkHint,
+		AutoDelivery:     req.AutoDelivery,
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+		Interval:         req.Interval,
+		IntervalCount:    req.IntervalCount,
+		GraceDays:        req.GraceDays,
 	}
 
 	if err := db.DB(c.Request.Context()).Create(&paymentLink).Error; err != nil {
@@ -94,16 +175,27 @@ func CreatePaymentLink(c *gin.Context) {
 
 // PaymentLinkDetail 支付链接详情
 type PaymentLinkDetail struct {
-	ID          uint64          `json:"id,string"`
-	Token       string          `json:"token"`
-	Amount      decimal.Decimal `json:"amount"`
-	ProductName string          `json:"product_name"`
-	Remark      string          `json:"remark"`
-	TotalLimit  *uint           `json:"total_limit"`
-	UserLimit   *uint           `json:"user_limit"`
-	CreatedAt   time.Time       `json:"created_at"`
-	AppName     string          `json:"app_name"`
-	RedirectURL string          `json:"redirect_url"`
+	ID            uint64                      `json:"id,string"`
+	Token         string                      `json:"token"`
+	Amount        decimal.Decimal             `json:"amount"`
+	ProductName   string                      `json:"product_name"`
+	Remark        string                      `json:"remark"`
+	TotalLimit    *uint                       `json:"total_limit"`
+	UserLimit     *uint                       `json:"user_limit"`
+	Interval      *model.SubscriptionInterval `json:"interval"`
+	IntervalCount uint                        `json:"interval_count"`
+	GraceDays     uint                        `json:"grace_days"`
+	MinAmount     *decimal.Decimal            `json:"min_amount"`
+	MaxAmount     *decimal.Decimal            `json:"max_amount"`
+	RequireRemark bool                        `json:"require_remark"`
+	RemarkHint    string                      `json:"remark_hint"`
+	AutoDelivery  bool                        `json:"auto_delivery"`
+	Stock         int64                       `json:"stock"` // 剩余卡密数量，仅发卡链接有效
+	StartsAt      *time.Time                  `json:"starts_at"`
+	EndsAt        *time.Time                  `json:"ends_at"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	AppName       string                      `json:"app_name"`
+	RedirectURL   string                      `json:"redirect_url"`
 }
 
 // ListPaymentLinks 获取支付链接列表
@@ -118,7 +210,7 @@ func ListPaymentLinks(c *gin.Context) {
 	var paymentLinks []PaymentLinkDetail
 	if err := db.DB(c.Request.Context()).
 		Table("merchant_payment_links").
-		Select("merchant_payment_links.id, merchant_payment_links.token, merchant_payment_links.amount, merchant_payment_links.product_name, merchant_payment_links.remark, merchant_payment_links.total_limit, merchant_payment_links.user_limit, merchant_payment_links.created_at, merchant_api_keys.app_name, merchant_api_keys.redirect_url").
+		Select("merchant_payment_links.id, merchant_payment_links.token, merchant_payment_links.amount, merchant_payment_links.product_name, merchant_payment_links.remark, merchant_payment_links.total_limit, merchant_payment_links.user_limit, merchant_payment_links.interval, merchant_payment_links.interval_count, merchant_payment_links.grace_days, merchant_payment_links.min_amount, merchant_payment_links.max_amount, merchant_payment_links.require_remark, merchant_payment_links.remark_hint, merchant_payment_links.starts_at, merchant_payment_links.ends_at, merchant_payment_links.created_at, merchant_payment_links.auto_delivery, (SELECT COUNT(*) FROM payment_link_items WHERE payment_link_items.payment_link_id = merchant_payment_links.id AND payment_link_items.order_id IS NULL) AS stock, merchant_api_keys.app_name, merchant_api_keys.redirect_url").
 		Joins("JOIN merchant_api_keys ON merchant_api_keys.id = merchant_payment_links.merchant_api_key_id").
 		Where("merchant_payment_links.merchant_api_key_id = ? AND merchant_payment_links.deleted_at IS NULL", apiKey.ID).
 		Order("merchant_payment_links.created_at DESC").
@@ -140,7 +232,7 @@ func GetPaymentLinkByToken(c *gin.Context) {
 	var paymentLink PaymentLinkDetail
 	if err := db.DB(c.Request.Context()).
 		Table("merchant_payment_links").
-		Select("merchant_payment_links.id, merchant_payment_links.token, merchant_payment_links.amount, merchant_payment_links.product_name, merchant_payment_links.remark, merchant_payment_links.created_at, merchant_api_keys.app_name, merchant_api_keys.redirect_url").
+		Select("merchant_payment_links.id, merchant_payment_links.token, merchant_payment_links.amount, merchant_payment_links.product_name, merchant_payment_links.remark, merchant_payment_links.interval, merchant_payment_links.interval_count, merchant_payment_links.grace_days, merchant_payment_links.min_amount, merchant_payment_links.max_amount, merchant_payment_links.require_remark, merchant_payment_links.remark_hint, merchant_payment_links.starts_at, merchant_payment_links.ends_at, merchant_payment_links.created_at, merchant_payment_links.auto_delivery, (SELECT COUNT(*) FROM payment_link_items WHERE payment_link_items.payment_link_id = merchant_payment_links.id AND payment_link_items.order_id IS NULL) AS stock, merchant_api_keys.app_name, merchant_api_keys.redirect_url").
 		Joins("JOIN merchant_api_keys ON merchant_api_keys.id = merchant_payment_links.merchant_api_key_id").
 		Where("merchant_payment_links.token = ? AND merchant_payment_links.deleted_at IS NULL", c.Param("token")).
 		First(&paymentLink).Error; err != nil {
@@ -199,8 +291,7 @@ func UpdatePaymentLink(c *gin.Context) {
 		return
 	}
 
-	// 验证金额
-	if err := util.ValidateAmount(req.Amount); err != nil {
+	if err := req.validate(); err != nil {
 		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
@@ -208,15 +299,26 @@ func UpdatePaymentLink(c *gin.Context) {
 	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, merchant.APIKeyObjKey)
 	linkID := c.Param("linkId")
 
+	// 已开通的订阅锁定开通时的金额与周期，修改仅对新订阅生效
 	result := db.DB(c.Request.Context()).
 		Model(&model.MerchantPaymentLink{}).
 		Where("id = ? AND merchant_api_key_id = ?", linkID, apiKey.ID).
 		Updates(map[string]interface{}{
-			"amount":       req.Amount,
-			"product_name": req.ProductName,
-			"remark":       req.Remark,
-			"total_limit":  req.TotalLimit,
-			"user_limit":   req.UserLimit,
+			"amount":         req.Amount,
+			"product_name":   req.ProductName,
+			"remark":         req.Remark,
+			"total_limit":    req.TotalLimit,
+			"user_limit":     req.UserLimit,
+			"interval":       req.Interval,
+			"interval_count": req.IntervalCount,
+			"grace_days":     req.GraceDays,
+			"min_amount":     req.MinAmount,
+			"max_amount":     req.MaxAmount,
+			"require_remark": req.RequireRemark,
+			"remark_hint":    req.RemarkHint,
+			"auto_delivery":  req.AutoDelivery,
+			"starts_at":      req.StartsAt,
+			"ends_at":        req.EndsAt,
 		})
 
 	if result.Error != nil {
@@ -251,16 +353,67 @@ func PayByLink(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusNotFound, util.Err(PaymentLinkNotFound))
 		return
 	}
+	if paymentLink.IsSubscription() {
+		c.JSON(http.StatusBadRequest, util.Err(SubscriptionLinkNotPayable))
+		return
+	}
+	if err := CheckAvailability(&paymentLink, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	if err := CheckPayerRemark(&paymentLink, req.Remark); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	amount, err := resolvePayAmount(&paymentLink, req.Amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	if paymentLink.AutoDelivery {
+		stock, err := service.CountPaymentLinkStock(db.DB(c.Request.Context()), paymentLink.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		if stock == 0 {
+			c.JSON(http.StatusBadRequest, util.Err(common.PaymentLinkOutOfStock))
+			return
+		}
+	}
 
 	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
 
-	if !currentUser.VerifyPayKey(req.PayKey) {
-		c.JSON(http.StatusBadRequest, util.Err(common.PayKeyIncorrect))
+	// 优惠券预校验，实付金额用于支付验证与余额检查
+	payAmount := amount
+	if req.CouponCode != "" {
+		quote, err := service.ApplyCoupon(db.DB(c.Request.Context()), service.ApplyCouponOptions{
+			APIKeyID:      paymentLink.MerchantAPIKeyID,
+			Code:          req.CouponCode,
+			PayerID:       currentUser.ID,
+			Amount:        amount,
+			PaymentLinkID: &paymentLink.ID,
+		})
+		if err != nil {
+			if service.IsCouponError(err.Error()) {
+				c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			} else {
+				c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			}
+			return
+		}
+		payAmount = quote.Amount
+	}
+
+	if err := service.VerifyPaymentAuth(c.Request.Context(), currentUser, req.PayKey, req.TOTPCode, payAmount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
 
 	// 检查余额是否足够
-	if currentUser.AvailableBalance.LessThan(paymentLink.Amount) {
+	if currentUser.AvailableBalance.LessThan(payAmount) {
 		c.JSON(http.StatusBadRequest, util.Err(common.InsufficientBalance))
 		return
 	}
@@ -303,6 +456,24 @@ func PayByLink(c *gin.Context) {
 
 	if err := db.DB(c.Request.Context()).Transaction(
 		func(tx *gorm.DB) error {
+			// 优惠券：锁定后复核使用次数，避免并发超用
+			var quote *service.CouponQuote
+			if req.CouponCode != "" {
+				var err error
+				quote, err = service.ApplyCoupon(tx, service.ApplyCouponOptions{
+					APIKeyID:      paymentLink.MerchantAPIKeyID,
+					Code:          req.CouponCode,
+					PayerID:       currentUser.ID,
+					Amount:        amount,
+					PaymentLinkID: &paymentLink.ID,
+					Lock:          true,
+				})
+				if err != nil {
+					return err
+				}
+				payAmount = quote.Amount
+			}
+
 			// 非测试模式
 			if !isTestMode {
 				if paymentLink.TotalLimit != nil || paymentLink.UserLimit != nil {
@@ -339,13 +510,16 @@ func PayByLink(c *gin.Context) {
 				}
 
 				// 检查每日限额
-				if err := service.CheckDailyLimit(tx, currentUser.ID, paymentLink.Amount, payerPayConfig.DailyLimit); err != nil {
+				if err := service.CheckDailyLimit(tx, currentUser.ID, payAmount, payerPayConfig.DailyLimit); err != nil {
+					return err
+				}
+				if err := service.LockAndCheckPayKeyResetCooling(tx, currentUser, payAmount); err != nil {
 					return err
 				}
 			}
 
 			// 计算手续费
-			_, merchantAmount, feePercent := service.CalculateFee(paymentLink.Amount, merchantPayConfig.FeeRate)
+			_, merchantAmount, feePercent := service.CalculateFee(payAmount, merchantPayConfig.FeeRate)
 
 			var remark string
 			var orderType model.OrderType
@@ -371,7 +545,7 @@ func PayByLink(c *gin.Context) {
 				PayerUserID:   currentUser.ID,
 				PayeeUserID:   merchantUser.ID,
 				ClientID:      merchantAPIKey.ClientID,
-				Amount:        paymentLink.Amount,
+				Amount:        amount,
 				Status:        model.OrderStatusSuccess,
 				Type:          orderType,
 				Remark:        remark,
@@ -379,24 +553,34 @@ func PayByLink(c *gin.Context) {
 				TradeTime:     time.Now(),
 				ExpiresAt:     time.Now(),
 			}
+			if quote != nil {
+				quote.ApplyTo(&order)
+			}
 			if err := tx.Create(&order).Error; err != nil {
 				return err
 			}
 
+			// 发卡链接：为订单分配一个卡密，库存不足时整体回滚
+			if !isTestMode && paymentLink.AutoDelivery {
+				if err := service.AssignPaymentLinkItem(tx, paymentLink.ID, order.ID, currentUser.ID); err != nil {
+					return err
+				}
+			}
+
 			// 非测试模式：扣减用户余额和增加商户余额
 			if !isTestMode {
 				if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
 					UserID:       currentUser.ID,
-					Amount:       paymentLink.Amount,
+					Amount:       payAmount,
 					Operation:    service.BalanceDeduct,
-					ScoreChange:  paymentLink.Amount.Round(0).IntPart(),
+					ScoreChange:  payAmount.Round(0).IntPart(),
 					TotalField:   "total_payment",
 					CheckBalance: true,
 				}); err != nil {
 					return err
 				}
 
-				merchantScoreIncrease := paymentLink.Amount.Mul(merchantPayConfig.ScoreRate).Round(0).IntPart()
+				merchantScoreIncrease := payAmount.Mul(merchantPayConfig.ScoreRate).Round(0).IntPart()
 				if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
 					UserID:       merchantUser.ID,
 					Amount:       merchantAmount,
@@ -418,10 +602,14 @@ func PayByLink(c *gin.Context) {
 	); err != nil {
 		errMsg := err.Error()
 		switch errMsg {
-		case common.InsufficientBalance, common.DailyLimitExceeded,
-			PaymentLinkTotalLimitExceeded, PaymentLinkUserLimitExceeded:
+		case common.InsufficientBalance, common.DailyLimitExceeded, common.PayKeyResetCoolingLimited,
+			PaymentLinkTotalLimitExceeded, PaymentLinkUserLimitExceeded, common.PaymentLinkOutOfStock:
 			c.JSON(http.StatusBadRequest, util.Err(errMsg))
 		default:
+			if service.IsCouponError(errMsg) {
+				c.JSON(http.StatusBadRequest, util.Err(errMsg))
+				return
+			}
 			c.JSON(http.StatusInternalServerError, util.Err(errMsg))
 		}
 		return
@@ -429,3 +617,228 @@ func PayByLink(c *gin.Context) {
 
 	c.JSON(http.StatusOK, util.OKNil())
 }
+
+// PreviewCouponRequest 优惠券试算请求
+type PreviewCouponRequest struct {
+	Token      string          `json:"token" binding:"required"`
+	Amount     decimal.Decimal `json:"amount"` // 自定金额链接由付款人填写
+	CouponCode string          `json:"coupon_code" binding:"required,max=32"`
+}
+
+// PreviewLinkCoupon 支付页输入优惠码后试算实付金额
+// @Tags merchant
+// @Accept json
+// @Produce json
+// @Param request body PreviewCouponRequest true "试算请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/payment-links/coupon [post]
+func PreviewLinkCoupon(c *gin.Context) {
+	var req PreviewCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	var paymentLink model.MerchantPaymentLink
+	if err := paymentLink.GetByToken(db.DB(c.Request.Context()), req.Token); err != nil {
+		c.JSON(http.StatusNotFound, util.Err(PaymentLinkNotFound))
+		return
+	}
+	if paymentLink.IsSubscription() {
+		c.JSON(http.StatusBadRequest, util.Err(common.CouponNotApplicable))
+		return
+	}
+
+	amount, err := resolvePayAmount(&paymentLink, req.Amount)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	quote, err := service.ApplyCoupon(db.DB(c.Request.Context()), service.ApplyCouponOptions{
+		APIKeyID:      paymentLink.MerchantAPIKeyID,
+		Code:          req.CouponCode,
+		PayerID:       currentUser.ID,
+		Amount:        amount,
+		PaymentLinkID: &paymentLink.ID,
+	})
+	if err != nil {
+		if service.IsCouponError(err.Error()) {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		} else {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(quote))
+}
+
+// UploadItemsRequest 批量上传卡密请求
+type UploadItemsRequest struct {
+	Items []string `json:"items" binding:"required,min=1,max=1000,dive,max=1000"`
+}
+
+// UploadPaymentLinkItems 批量上传发卡链接的卡密，同一链接内重复的卡密会被忽略
+// @Tags merchant
+// @Accept json
+// @Produce json
+// @Param id path uint64 true "API Key ID"
+// @Param linkId path uint64 true "Payment Link ID"
+// @Param request body UploadItemsRequest true "卡密列表"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/api-keys/{id}/payment-links/{linkId}/items [post]
+func UploadPaymentLinkItems(c *gin.Context) {
+	var req UploadItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	paymentLink, _ := util.GetFromContext[*model.MerchantPaymentLink](c, merchant.PaymentLinkObjKey)
+	if !paymentLink.AutoDelivery {
+		c.JSON(http.StatusBadRequest, util.Err(NotAutoDeliveryLink))
+		return
+	}
+
+	items := make([]model.PaymentLinkItem, 0, len(req.Items))
+	for _, raw := range req.Items {
+		content := strings.TrimSpace(raw)
+		if content == "" {
+			continue
+		}
+		encrypted, err := service.EncryptCardKey(content)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		items = append(items, model.PaymentLinkItem{
+			PaymentLinkID: paymentLink.ID,
+			Content:       encrypted,
+			ContentHash:   service.HashCardKey(content),
+		})
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, util.Err(PaymentLinkItemsEmpty))
+		return
+	}
+
+	result := db.DB(c.Request.Context()).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&items, 200)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(gin.H{
+		"added":     result.RowsAffected,
+		"duplicate": int64(len(items)) - result.RowsAffected,
+	}))
+}
+
+// ListItemsRequest 卡密列表请求
+type ListItemsRequest struct {
+	Page     int   `form:"page" binding:"min=1"`
+	PageSize int   `form:"page_size" binding:"min=1,max=100"`
+	Sold     *bool `form:"sold"`
+}
+
+// PaymentLinkItemDetail 卡密列表项，内容脱敏展示
+type PaymentLinkItemDetail struct {
+	model.PaymentLinkItem
+	MaskedContent string `json:"masked_content"`
+}
+
+// ListPaymentLinkItems 查询发卡链接的卡密库存
+// @Tags merchant
+// @Produce json
+// @Param id path uint64 true "API Key ID"
+// @Param linkId path uint64 true "Payment Link ID"
+// @Param request query ListItemsRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/api-keys/{id}/payment-links/{linkId}/items [get]
+func ListPaymentLinkItems(c *gin.Context) {
+	var req ListItemsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	paymentLink, _ := util.GetFromContext[*model.MerchantPaymentLink](c, merchant.PaymentLinkObjKey)
+
+	baseQuery := db.DB(c.Request.Context()).
+		Model(&model.PaymentLinkItem{}).
+		Where("payment_link_id = ?", paymentLink.ID)
+	if req.Sold != nil {
+		if *req.Sold {
+			baseQuery = baseQuery.Where("order_id IS NOT NULL")
+		} else {
+			baseQuery = baseQuery.Where("order_id IS NULL")
+		}
+	}
+
+	var total int64
+	if err := baseQuery.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	var items []model.PaymentLinkItem
+	if err := baseQuery.
+		Order("id ASC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	details := make([]PaymentLinkItemDetail, 0, len(items))
+	for _, item := range items {
+		content, err := service.DecryptCardKey(item.Content)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		details = append(details, PaymentLinkItemDetail{
+			PaymentLinkItem: item,
+			MaskedContent:   maskCardKey(content),
+		})
+	}
+
+	c.JSON(http.StatusOK, util.OK(gin.H{
+		"total":     total,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+		"items":     details,
+	}))
+}
+
+// DeletePaymentLinkItem 删除未售出的卡密
+// @Tags merchant
+// @Produce json
+// @Param id path uint64 true "API Key ID"
+// @Param linkId path uint64 true "Payment Link ID"
+// @Param itemId path uint64 true "Item ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/api-keys/{id}/payment-links/{linkId}/items/{itemId} [delete]
+func DeletePaymentLinkItem(c *gin.Context) {
+	paymentLink, _ := util.GetFromContext[*model.MerchantPaymentLink](c, merchant.PaymentLinkObjKey)
+
+	result := db.DB(c.Request.Context()).
+		Where("id = ? AND payment_link_id = ? AND order_id IS NULL", c.Param("itemId"), paymentLink.ID).
+		Delete(&model.PaymentLinkItem{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, util.Err(PaymentLinkItemNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package link
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+)
+
+// CheckAvailability 检查支付链接当前是否处于开放时间窗口内
+func CheckAvailability(paymentLink *model.MerchantPaymentLink, now time.Time) error {
+	if paymentLink.StartsAt != nil && now.Before(*paymentLink.StartsAt) {
+		return errors.New(PaymentLinkNotStarted)
+	}
+	if paymentLink.EndsAt != nil && !now.Before(*paymentLink.EndsAt) {
+		return errors.New(PaymentLinkEnded)
+	}
+	return nil
+}
+
+// resolvePayAmount 确定本次支付金额：固定金额链接使用链接金额，自定金额链接校验付款人输入的金额
+func resolvePayAmount(paymentLink *model.MerchantPaymentLink, amount decimal.Decimal) (decimal.Decimal, error) {
+	if !paymentLink.IsVariableAmount() {
+		return paymentLink.Amount, nil
+	}
+
+	if amount.IsZero() {
+		return decimal.Zero, errors.New(PayAmountRequired)
+	}
+	if err := util.ValidateAmount(amount); err != nil {
+		return decimal.Zero, err
+	}
+	if paymentLink.MinAmount != nil && amount.LessThan(*paymentLink.MinAmount) {
+		return decimal.Zero, errors.New(AmountOutOfRange)
+	}
+	if paymentLink.MaxAmount != nil && amount.GreaterThan(*paymentLink.MaxAmount) {
+		return decimal.Zero, errors.New(AmountOutOfRange)
+	}
+	return amount, nil
+}
+
+// CheckPayerRemark 检查链接要求的付款备注
+func CheckPayerRemark(paymentLink *model.MerchantPaymentLink, remark string) error {
+	if paymentLink.RequireRemark && strings.TrimSpace(remark) == "" {
+		return errors.New(PayerRemarkRequired)
+	}
+	return nil
+}
+
+// maskCardKey 卡密脱敏，仅保留首尾各两个字符
+func maskCardKey(content string) string {
+	runes := []rune(content)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:2]) + strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-2:])
+}
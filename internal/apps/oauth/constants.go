@@ -21,12 +21,39 @@ import (
 )
 
 const (
-	UserNameKey = "username"
-	UserIDKey   = "user_id"
-	UserObjKey  = "user_obj"
+	UserNameKey  = "username"
+	UserIDKey    = "user_id"
+	UserObjKey   = "user_obj"
+	SessionIDKey = "session_id"
+
+	AccessTokenObjKey = "access_token_obj"
 )
 
 const (
 	OAuthStateCacheKeyFormat     = "oauth:state:%s"
 	OAuthStateCacheKeyExpiration = 10 * time.Minute
 )
+
+const (
+	OAuthStepUpStateCacheKeyFormat = "oauth:step_up:state:%s"
+	OAuthStepUpTokenCacheKeyFormat = "oauth:step_up:token:%s"
+	OAuthStepUpTokenExpiration     = 5 * time.Minute
+	OAuthStepUpMaxAge              = 5 * time.Minute // 再认证要求的最长登录时长
+)
+
+// StepUpPurpose 再认证用途
+type StepUpPurpose string
+
+const (
+	StepUpPurposePayKeyReset StepUpPurpose = "pay_key_reset" // 重置支付密码
+)
+
+// Valid 是否为支持的再认证用途
+func (p StepUpPurpose) Valid() bool {
+	switch p {
+	case StepUpPurposePayKeyReset:
+		return true
+	default:
+		return false
+	}
+}
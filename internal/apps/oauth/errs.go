@@ -17,7 +17,15 @@ limitations under the License.
 package oauth
 
 const (
-	InvalidState        = "非法登录请求"
-	IDTokenVerifyFailed = "ID Token 验证失败"
-	NonceMismatch       = "nonce 不匹配，可能存在重放攻击"
+	InvalidState           = "非法登录请求"
+	IDTokenVerifyFailed    = "ID Token 验证失败"
+	NonceMismatch          = "nonce 不匹配，可能存在重放攻击"
+	InvalidStepUpPurpose   = "不支持的身份验证用途"
+	StepUpUnsupported      = "当前登录方式不支持身份验证，请联系管理员"
+	StepUpUserMismatch     = "验证账号与当前登录账号不一致"
+	ReauthRequired         = "身份验证已过期，请重新登录验证"
+	InvalidStepUpToken     = "身份验证凭证无效或已过期"
+	SessionRevoked         = "登录会话已失效，请重新登录"
+	InvalidAccessToken     = "访问令牌无效或已过期"
+	AccessTokenScopeDenied = "访问令牌无权访问该接口"
 )
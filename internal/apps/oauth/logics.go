@@ -22,7 +22,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
+	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -31,6 +33,9 @@ import (
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/otel_trace"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/codes"
 	"gorm.io/gorm"
 )
@@ -48,8 +53,58 @@ func GetUserIDFromContext(c *gin.Context) uint64 {
 	return GetUserIDFromSession(session)
 }
 
+func GetSessionIDFromSession(s sessions.Session) string {
+	sessionID, _ := s.Get(SessionIDKey).(string)
+	return sessionID
+}
+
+func GetSessionIDFromContext(c *gin.Context) string {
+	return GetSessionIDFromSession(sessions.Default(c))
+}
+
+// bindUserSession 登记新的登录会话并写入当前 Session，调用方负责 Save
+func bindUserSession(c *gin.Context, s sessions.Session, userID uint64) error {
+	userSession, err := service.CreateUserSession(c.Request.Context(), userID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		return err
+	}
+	s.Set(SessionIDKey, userSession.ID)
+	return nil
+}
+
+// accessTokenTouchInterval 访问令牌最近使用时间的更新间隔
+const accessTokenTouchInterval = time.Minute
+
+// GetAccessTokenFromContext 获取当前请求使用的个人访问令牌，会话登录时返回 false
+func GetAccessTokenFromContext(c *gin.Context) (*model.UserAccessToken, bool) {
+	return util.GetFromContext[*model.UserAccessToken](c, AccessTokenObjKey)
+}
+
+// authenticateAccessToken 校验个人访问令牌并加载令牌所属的有效用户
+func authenticateAccessToken(ctx context.Context, token string) (*model.User, *model.UserAccessToken, error) {
+	var accessToken model.UserAccessToken
+	if err := accessToken.GetByToken(db.DB(ctx), token); err != nil {
+		return nil, nil, err
+	}
+
+	var user model.User
+	if err := db.DB(ctx).Where("id = ? AND is_active = ?", accessToken.UserID, true).First(&user).Error; err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if err := db.DB(ctx).Model(&model.UserAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", accessToken.ID, now.Add(-accessTokenTouchInterval)).
+		UpdateColumn("last_used_at", now).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &user, &accessToken, nil
+}
+
 // doOAuth 执行 OAuth2/OIDC 认证流程
-func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error) {
+// maxAge 大于 0 时要求存在已验证的 ID Token 且其 auth_time 在该时长内（用于再认证），否则拒绝
+func doOAuth(ctx context.Context, code string, nonce string, maxAge time.Duration) (*model.User, error) {
 	ctx, span := otel_trace.Start(ctx, "OAuth")
 	defer span.End()
 
@@ -61,6 +116,7 @@ func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error
 	}
 
 	var userInfo model.OAuthUserInfo
+	authTimeVerified := false
 
 	if oidcVerifier != nil {
 		if rawIDToken, ok := token.Extra("id_token").(string); ok {
@@ -74,6 +130,13 @@ func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error
 				span.SetStatus(codes.Error, NonceMismatch)
 				return nil, errors.New(NonceMismatch)
 			}
+			if maxAge > 0 {
+				if authErr := checkAuthTime(idToken, maxAge); authErr != nil {
+					span.SetStatus(codes.Error, authErr.Error())
+					return nil, authErr
+				}
+				authTimeVerified = true
+			}
 			if claimsErr := idToken.Claims(&userInfo); claimsErr != nil {
 				span.SetStatus(codes.Error, claimsErr.Error())
 				return nil, claimsErr
@@ -81,6 +144,12 @@ func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error
 		}
 	}
 
+	// 再认证必须由 ID Token 证明登录时间，纯 OAuth2 模式或未返回 ID Token 时拒绝
+	if maxAge > 0 && !authTimeVerified {
+		span.SetStatus(codes.Error, ReauthRequired)
+		return nil, errors.New(ReauthRequired)
+	}
+
 	if userInfo.GetID() == 0 {
 		client := oauthConf.Client(ctx, token)
 		resp, httpErr := client.Get(config.Config.OAuth2.UserEndpoint)
@@ -150,3 +219,63 @@ func doOAuth(ctx context.Context, code string, nonce string) (*model.User, error
 
 	return &user, nil
 }
+
+// checkAuthTime 校验 ID Token 的 auth_time 是否在 maxAge 内
+func checkAuthTime(idToken *oidc.IDToken, maxAge time.Duration) error {
+	var claims struct {
+		AuthTime int64 `json:"auth_time"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return err
+	}
+	if claims.AuthTime == 0 || time.Since(time.Unix(claims.AuthTime, 0)) > maxAge+time.Minute {
+		return errors.New(ReauthRequired)
+	}
+	return nil
+}
+
+// stepUpState 再认证登录请求的缓存内容
+type stepUpState struct {
+	UserID  uint64        `json:"user_id"`
+	Purpose StepUpPurpose `json:"purpose"`
+}
+
+// issueStepUpToken 签发短时有效的再认证凭证
+func issueStepUpToken(ctx context.Context, userID uint64, purpose StepUpPurpose) (string, time.Time, error) {
+	token := util.GenerateUniqueIDSimple()
+	payload, err := json.Marshal(stepUpState{UserID: userID, Purpose: purpose})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	key := db.PrefixedKey(fmt.Sprintf(OAuthStepUpTokenCacheKeyFormat, token))
+	if err := db.Redis.Set(ctx, key, payload, OAuthStepUpTokenExpiration).Err(); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, time.Now().Add(OAuthStepUpTokenExpiration), nil
+}
+
+// ConsumeStepUpToken 校验并消费再认证凭证，凭证只能使用一次
+func ConsumeStepUpToken(ctx context.Context, token string, userID uint64, purpose StepUpPurpose) error {
+	if token == "" {
+		return errors.New(InvalidStepUpToken)
+	}
+
+	key := db.PrefixedKey(fmt.Sprintf(OAuthStepUpTokenCacheKeyFormat, token))
+	payload, err := db.Redis.GetDel(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return errors.New(InvalidStepUpToken)
+		}
+		return err
+	}
+
+	var state stepUpState
+	if err := json.Unmarshal([]byte(payload), &state); err != nil {
+		return errors.New(InvalidStepUpToken)
+	}
+	if state.UserID != userID || state.Purpose != purpose {
+		return errors.New(InvalidStepUpToken)
+	}
+	return nil
+}
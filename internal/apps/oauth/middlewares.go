@@ -17,23 +17,71 @@ limitations under the License.
 package oauth
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"github.com/linux-do/credit/internal/common"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/logger"
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/otel_trace"
+	"github.com/linux-do/credit/internal/service"
 	"github.com/linux-do/credit/internal/util"
+	"gorm.io/gorm"
 )
 
-func LoginRequired() gin.HandlerFunc {
+// GetBearerToken 从 Authorization 头中解析 Bearer 令牌
+func GetBearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(header[len("Bearer "):])
+	return token, token != ""
+}
+
+// LoginRequired 校验登录状态
+// 传入 scopes 时，同时允许持有全部对应权限的个人访问令牌（Authorization: Bearer）访问；未传入时仅允许会话登录
+func LoginRequired(scopes ...model.AccessTokenScope) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// init trace
 		ctx, span := otel_trace.Start(c.Request.Context(), "LoginRequired")
 		defer span.End()
 
+		// personal access token
+		if token, ok := GetBearerToken(c); ok {
+			user, accessToken, err := authenticateAccessToken(ctx, token)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error_msg": InvalidAccessToken, "data": nil})
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error_msg": err.Error(), "data": nil})
+				return
+			}
+
+			if len(scopes) == 0 {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error_msg": AccessTokenScopeDenied, "data": nil})
+				return
+			}
+			for _, scope := range scopes {
+				if !accessToken.HasScope(scope) {
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error_msg": AccessTokenScopeDenied, "data": nil})
+					return
+				}
+			}
+
+			logger.InfoF(ctx, "[LoginRequired] %d %s via access token %d", user.ID, user.Username, accessToken.ID)
+
+			util.SetToContext(c, UserObjKey, user)
+			util.SetToContext(c, AccessTokenObjKey, accessToken)
+			c.Next()
+			return
+		}
+
 		// load user
 		userId := GetUserIDFromContext(c)
 		if userId <= 0 {
@@ -49,6 +97,33 @@ func LoginRequired() gin.HandlerFunc {
 			return
 		}
 
+		// check session not revoked
+		session := sessions.Default(c)
+		if sessionID := GetSessionIDFromSession(session); sessionID == "" {
+			// 会话管理上线前的旧会话，补充登记
+			if err := bindUserSession(c, session, user.ID); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error_msg": err.Error(), "data": nil})
+				return
+			}
+			if err := session.Save(); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error_msg": err.Error(), "data": nil})
+				return
+			}
+		} else {
+			valid, err := service.TouchUserSession(ctx, user.ID, sessionID, c.ClientIP())
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error_msg": err.Error(), "data": nil})
+				return
+			}
+			if !valid {
+				session.Options(util.GetSessionOptions(-1))
+				session.Clear()
+				_ = session.Save()
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error_msg": SessionRevoked, "data": nil})
+				return
+			}
+		}
+
 		// log
 		logger.InfoF(ctx, "[LoginRequired] %d %s", user.ID, user.Username)
 
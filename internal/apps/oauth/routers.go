@@ -17,23 +17,29 @@ limitations under the License.
 package oauth
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/linux-do/credit/internal/common"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/service"
 	"github.com/linux-do/credit/internal/util"
 	"github.com/shopspring/decimal"
+	"golang.org/x/oauth2"
 )
 
 // GetLoginURL godoc
 // @Tags oauth
 // @Produce json
+// @Param step_up query string false "再认证用途，如 pay_key_reset（需已登录）"
 // @Success 200 {object} util.ResponseAny
 // @Router /api/v1/oauth/login [get]
 func GetLoginURL(c *gin.Context) {
@@ -41,24 +47,62 @@ func GetLoginURL(c *gin.Context) {
 
 	// 生成 state
 	state := uuid.NewString()
-	cmd := db.Redis.Set(ctx, db.PrefixedKey(fmt.Sprintf(OAuthStateCacheKeyFormat, state)), state, OAuthStateCacheKeyExpiration)
+	stateKey := fmt.Sprintf(OAuthStateCacheKeyFormat, state)
+	stateValue := state
+	var authOpts []oauth2.AuthCodeOption
+
+	// 再认证：要求当前已登录，并强制在授权端重新输入凭据
+	if purpose := StepUpPurpose(c.Query("step_up")); purpose != "" {
+		if !purpose.Valid() {
+			c.JSON(http.StatusBadRequest, util.Err(InvalidStepUpPurpose))
+			return
+		}
+		// 再认证依赖 ID Token 中的 auth_time，仅 OIDC 模式可用
+		if oidcVerifier == nil {
+			c.JSON(http.StatusBadRequest, util.Err(StepUpUnsupported))
+			return
+		}
+		userID := GetUserIDFromContext(c)
+		if userID == 0 {
+			c.JSON(http.StatusUnauthorized, util.Err(common.UnAuthorized))
+			return
+		}
+
+		payload, err := json.Marshal(stepUpState{UserID: userID, Purpose: purpose})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		stateKey = fmt.Sprintf(OAuthStepUpStateCacheKeyFormat, state)
+		stateValue = string(payload)
+		authOpts = append(authOpts,
+			oauth2.SetAuthURLParam("prompt", "login"),
+			oauth2.SetAuthURLParam("max_age", strconv.Itoa(int(OAuthStepUpMaxAge.Seconds()))),
+		)
+	}
+
+	cmd := db.Redis.Set(ctx, db.PrefixedKey(stateKey), stateValue, OAuthStateCacheKeyExpiration)
 	if cmd.Err() != nil {
 		c.JSON(http.StatusInternalServerError, util.Err(cmd.Err().Error()))
 		return
 	}
 
 	// 构造登录 URL
-	var authURL string
 	if oidcVerifier != nil {
 		// OIDC 模式：state 同时用作 nonce
-		authURL = oauthConf.AuthCodeURL(state, oidc.Nonce(state))
-	} else {
-		// 纯 OAuth2 模式
-		authURL = oauthConf.AuthCodeURL(state)
+		authOpts = append(authOpts, oidc.Nonce(state))
 	}
+	authURL := oauthConf.AuthCodeURL(state, authOpts...)
 	c.JSON(http.StatusOK, util.OK(authURL))
 }
 
+// StepUpResponse 再认证结果
+type StepUpResponse struct {
+	Purpose     StepUpPurpose `json:"purpose"`
+	StepUpToken string        `json:"step_up_token"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+}
+
 type CallbackRequest struct {
 	State string `json:"state"`
 	Code  string `json:"code"`
@@ -83,13 +127,14 @@ func Callback(c *gin.Context) {
 	// 验证 state
 	cmd := db.Redis.Get(ctx, db.PrefixedKey(fmt.Sprintf(OAuthStateCacheKeyFormat, req.State)))
 	if cmd.Val() != req.State {
-		c.JSON(http.StatusBadRequest, util.Err(InvalidState))
+		// 非普通登录 state，按再认证处理
+		stepUpCallback(c, req)
 		return
 	}
 	db.Redis.Del(ctx, db.PrefixedKey(fmt.Sprintf(OAuthStateCacheKeyFormat, req.State)))
 
 	// 执行 OAuth/OIDC 认证
-	user, err := doOAuth(ctx, req.Code, req.State)
+	user, err := doOAuth(ctx, req.Code, req.State, 0)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
 		return
@@ -98,6 +143,10 @@ func Callback(c *gin.Context) {
 	session := sessions.Default(c)
 	session.Set(UserIDKey, user.ID)
 	session.Set(UserNameKey, user.Username)
+	if err := bindUserSession(c, session, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
 	if err := session.Save(); err != nil {
 		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
 		return
@@ -106,24 +155,75 @@ func Callback(c *gin.Context) {
 	c.JSON(http.StatusOK, util.OKNil())
 }
 
+// stepUpCallback 处理再认证回调：校验为当前登录用户本人后签发再认证凭证，不改变登录会话
+func stepUpCallback(c *gin.Context, req CallbackRequest) {
+	ctx := c.Request.Context()
+
+	payload, err := db.Redis.GetDel(ctx, db.PrefixedKey(fmt.Sprintf(OAuthStepUpStateCacheKeyFormat, req.State))).Result()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(InvalidState))
+		return
+	}
+
+	var state stepUpState
+	if err := json.Unmarshal([]byte(payload), &state); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(InvalidState))
+		return
+	}
+	if GetUserIDFromContext(c) != state.UserID {
+		c.JSON(http.StatusBadRequest, util.Err(StepUpUserMismatch))
+		return
+	}
+
+	user, err := doOAuth(ctx, req.Code, req.State, OAuthStepUpMaxAge)
+	if err != nil {
+		if err.Error() == ReauthRequired {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if user.ID != state.UserID {
+		c.JSON(http.StatusBadRequest, util.Err(StepUpUserMismatch))
+		return
+	}
+
+	token, expiresAt, err := issueStepUpToken(ctx, user.ID, state.Purpose)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(StepUpResponse{
+		Purpose:     state.Purpose,
+		StepUpToken: token,
+		ExpiresAt:   expiresAt,
+	}))
+}
+
 type BasicUserInfo struct {
-	ID               uint64           `json:"id"`
-	Username         string           `json:"username"`
-	Nickname         string           `json:"nickname"`
-	TrustLevel       model.TrustLevel `json:"trust_level"`
-	AvatarUrl        string           `json:"avatar_url"`
-	TotalReceive     decimal.Decimal  `json:"total_receive"`
-	TotalPayment     decimal.Decimal  `json:"total_payment"`
-	TotalTransfer    decimal.Decimal  `json:"total_transfer"`
-	TotalCommunity   decimal.Decimal  `json:"total_community"`
-	CommunityBalance decimal.Decimal  `json:"community_balance"`
-	AvailableBalance decimal.Decimal  `json:"available_balance"`
-	PayScore         int64            `json:"pay_score"`
-	IsPayKey         bool             `json:"is_pay_key"`
-	IsAdmin          bool             `json:"is_admin"`
-	RemainQuota      decimal.Decimal  `json:"remain_quota"`
-	PayLevel         model.PayLevel   `json:"pay_level"`
-	DailyLimit       *int64           `json:"daily_limit"`
+	ID                 uint64           `json:"id"`
+	Username           string           `json:"username"`
+	Nickname           string           `json:"nickname"`
+	TrustLevel         model.TrustLevel `json:"trust_level"`
+	AvatarUrl          string           `json:"avatar_url"`
+	TotalReceive       decimal.Decimal  `json:"total_receive"`
+	TotalPayment       decimal.Decimal  `json:"total_payment"`
+	TotalTransfer      decimal.Decimal  `json:"total_transfer"`
+	TotalCommunity     decimal.Decimal  `json:"total_community"`
+	CommunityBalance   decimal.Decimal  `json:"community_balance"`
+	AvailableBalance   decimal.Decimal  `json:"available_balance"`
+	HeldBalance        decimal.Decimal  `json:"held_balance"`
+	IncomingBalance    decimal.Decimal  `json:"incoming_balance"` // 撤回窗口内尚未到账的转入金额
+	PayScore           int64            `json:"pay_score"`
+	IsPayKey           bool             `json:"is_pay_key"`
+	PayKeyLockedUntil  *time.Time       `json:"pay_key_locked_until"`
+	PayKeyCoolingUntil *time.Time       `json:"pay_key_cooling_until"`
+	IsAdmin            bool             `json:"is_admin"`
+	RemainQuota        decimal.Decimal  `json:"remain_quota"`
+	PayLevel           model.PayLevel   `json:"pay_level"`
+	DailyLimit         *int64           `json:"daily_limit"`
 }
 
 // UserInfo godoc
@@ -151,26 +251,48 @@ func UserInfo(c *gin.Context) {
 		remainQuota = decimal.NewFromInt(*payConfig.DailyLimit).Sub(todayUsed)
 	}
 
+	incomingBalance, err := service.GetIncomingDelayedAmount(db.DB(c.Request.Context()), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	payKeyLockedUntil, err := service.GetPayKeyLockedUntil(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	payKeyCoolingUntil, err := service.GetPayKeyCoolingUntil(c.Request.Context(), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
 	c.JSON(
 		http.StatusOK,
 		util.OK(BasicUserInfo{
-			ID:               user.ID,
-			Username:         user.Username,
-			Nickname:         user.Nickname,
-			TrustLevel:       user.TrustLevel,
-			AvatarUrl:        user.AvatarUrl,
-			TotalReceive:     user.TotalReceive,
-			TotalPayment:     user.TotalPayment,
-			TotalTransfer:    user.TotalTransfer,
-			TotalCommunity:   user.TotalCommunity,
-			CommunityBalance: user.CommunityBalance,
-			AvailableBalance: user.AvailableBalance,
-			PayScore:         user.PayScore,
-			IsPayKey:         user.PayKey != "",
-			IsAdmin:          user.IsAdmin,
-			RemainQuota:      remainQuota,
-			PayLevel:         payConfig.Level,
-			DailyLimit:       payConfig.DailyLimit,
+			ID:                 user.ID,
+			Username:           user.Username,
+			Nickname:           user.Nickname,
+			TrustLevel:         user.TrustLevel,
+			AvatarUrl:          user.AvatarUrl,
+			TotalReceive:       user.TotalReceive,
+			TotalPayment:       user.TotalPayment,
+			TotalTransfer:      user.TotalTransfer,
+			TotalCommunity:     user.TotalCommunity,
+			CommunityBalance:   user.CommunityBalance,
+			AvailableBalance:   user.AvailableBalance,
+			HeldBalance:        user.HeldBalance,
+			IncomingBalance:    incomingBalance,
+			PayScore:           user.PayScore,
+			IsPayKey:           user.PayKey != "",
+			PayKeyLockedUntil:  payKeyLockedUntil,
+			PayKeyCoolingUntil: payKeyCoolingUntil,
+			IsAdmin:            user.IsAdmin,
+			RemainQuota:        remainQuota,
+			PayLevel:           payConfig.Level,
+			DailyLimit:         payConfig.DailyLimit,
 		}),
 	)
 }
@@ -182,6 +304,12 @@ func UserInfo(c *gin.Context) {
 // @Router /api/v1/oauth/logout [get]
 func Logout(c *gin.Context) {
 	session := sessions.Default(c)
+	if sessionID := GetSessionIDFromSession(session); sessionID != "" {
+		if _, err := service.RevokeUserSession(c.Request.Context(), GetUserIDFromSession(session), sessionID); err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+	}
 	session.Options(util.GetSessionOptions(-1))
 	session.Clear()
 	if err := session.Save(); err != nil {
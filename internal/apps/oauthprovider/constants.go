@@ -0,0 +1,44 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauthprovider
+
+import "time"
+
+const (
+	OAuthTokenObjKey         = "oauth_provider_token_obj"
+	OAuthAuthorizationObjKey = "oauth_provider_authorization_obj"
+)
+
+const (
+	// AuthorizationCodeCacheKeyFormat 授权码缓存 Key
+	AuthorizationCodeCacheKeyFormat = "oauth2:code:%s"
+	AuthorizationCodeExpiration     = 10 * time.Minute
+)
+
+const (
+	AccessTokenExpiration  = 2 * time.Hour
+	RefreshTokenExpiration = 30 * 24 * time.Hour
+
+	accessTokenPrefix  = "ldc_at_"
+	refreshTokenPrefix = "ldc_rt_"
+)
+
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeRefreshToken      = "refresh_token"
+	codeChallengeMethodS256    = "S256"
+)
@@ -0,0 +1,44 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauthprovider
+
+const (
+	ClientNotFound                 = "应用不存在"
+	RedirectURIMismatch            = "回调地址与应用配置不一致"
+	UnsupportedResponseType        = "仅支持 response_type=code"
+	InvalidScope                   = "授权范围无效"
+	ChargeLimitRequired            = "申请扣款权限时需设置大于 0 的月度额度"
+	UnsupportedCodeChallengeMethod = "仅支持 S256 方式的 code_challenge"
+	AuthorizationNotFound          = "授权记录不存在"
+	MonthlyChargeLimitExceeded     = "已超过授权的月度扣款额度"
+	InvalidOAuthToken              = "访问令牌无效或已过期"
+	OAuthScopeDenied               = "访问令牌未获得该权限"
+	InvalidAuthorizationCode       = "授权码无效或已过期"
+	InvalidRefreshToken            = "刷新令牌无效或已过期"
+	CodeVerifierMismatch           = "code_verifier 校验失败"
+	ClientAuthFailed               = "应用认证失败"
+)
+
+// OAuth2 标准错误码（RFC 6749 第 5.2 节）
+const (
+	errInvalidRequest       = "invalid_request"
+	errInvalidClient        = "invalid_client"
+	errInvalidGrant         = "invalid_grant"
+	errUnsupportedGrantType = "unsupported_grant_type"
+	errAccessDenied         = "access_denied"
+	errServerError          = "server_error"
+)
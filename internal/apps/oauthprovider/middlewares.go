@@ -0,0 +1,70 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauthprovider
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+)
+
+// RequireOAuthToken 校验第三方应用的访问令牌及授权范围
+func RequireOAuthToken(scope model.OAuthScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessToken, ok := oauth.GetBearerToken(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, util.Err(InvalidOAuthToken))
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		var token model.OAuthToken
+		if err := token.GetByAccessToken(db.DB(ctx), accessToken); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, util.Err(InvalidOAuthToken))
+			return
+		}
+		if !token.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, util.Err(OAuthScopeDenied))
+			return
+		}
+
+		var authorization model.OAuthAuthorization
+		if err := db.DB(ctx).
+			Where("id = ? AND revoked_at IS NULL", token.AuthorizationID).
+			First(&authorization).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, util.Err(InvalidOAuthToken))
+			return
+		}
+
+		var user model.User
+		if err := db.DB(ctx).Where("id = ? AND is_active = ?", token.UserID, true).First(&user).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, util.Err(InvalidOAuthToken))
+			return
+		}
+
+		util.SetToContext(c, OAuthTokenObjKey, &token)
+		util.SetToContext(c, OAuthAuthorizationObjKey, &authorization)
+		util.SetToContext(c, oauth.UserObjKey, &user)
+
+		c.Next()
+	}
+}
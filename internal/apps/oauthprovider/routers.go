@@ -0,0 +1,664 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauthprovider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuthorizeRequest 授权请求参数
+type AuthorizeRequest struct {
+	ResponseType        string          `form:"response_type" json:"response_type" binding:"required"`
+	ClientID            string          `form:"client_id" json:"client_id" binding:"required,max=64"`
+	RedirectURI         string          `form:"redirect_uri" json:"redirect_uri" binding:"max=100"`
+	Scope               string          `form:"scope" json:"scope" binding:"required,max=255"`
+	State               string          `form:"state" json:"state" binding:"max=255"`
+	CodeChallenge       string          `form:"code_challenge" json:"code_challenge" binding:"max=128"`
+	CodeChallengeMethod string          `form:"code_challenge_method" json:"code_challenge_method"`
+	ChargeLimit         decimal.Decimal `form:"charge_limit" json:"charge_limit"` // 应用申请的月度扣款额度
+}
+
+// validatedAuthorizeRequest 校验通过的授权请求
+type validatedAuthorizeRequest struct {
+	APIKey      *model.MerchantAPIKey
+	RedirectURI string
+	Scopes      []model.OAuthScope
+}
+
+// validate 校验授权请求参数
+func (r *AuthorizeRequest) validate(c *gin.Context) (*validatedAuthorizeRequest, error) {
+	if r.ResponseType != "code" {
+		return nil, errors.New(UnsupportedResponseType)
+	}
+	if r.CodeChallenge != "" && r.CodeChallengeMethod != codeChallengeMethodS256 {
+		return nil, errors.New(UnsupportedCodeChallengeMethod)
+	}
+
+	apiKey, err := loadClient(c.Request.Context(), r.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	redirectURI, err := resolveRedirectURI(apiKey, r.RedirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes, ok := model.ParseOAuthScopes(r.Scope)
+	if !ok {
+		return nil, errors.New(InvalidScope)
+	}
+
+	return &validatedAuthorizeRequest{APIKey: apiKey, RedirectURI: redirectURI, Scopes: scopes}, nil
+}
+
+// AuthorizeInfoResponse 授权确认页信息
+type AuthorizeInfoResponse struct {
+	AppName        string             `json:"app_name"`
+	AppHomepageURL string             `json:"app_homepage_url"`
+	AppDescription string             `json:"app_description"`
+	RedirectURI    string             `json:"redirect_uri"`
+	Scopes         []model.OAuthScope `json:"scopes"`
+	ChargeLimit    decimal.Decimal    `json:"charge_limit"`
+	Authorized     bool               `json:"authorized"` // 是否已授权过相同范围
+}
+
+// GetAuthorizeInfo 获取授权确认页信息
+// @Tags oauth2
+// @Produce json
+// @Param request query AuthorizeRequest true "授权请求参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/oauth2/authorize [get]
+func GetAuthorizeInfo(c *gin.Context) {
+	var req AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	validated, err := req.validate(c)
+	if err != nil {
+		respondAuthorizeError(c, err)
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	authorized := false
+	var authorization model.OAuthAuthorization
+	if err := db.DB(c.Request.Context()).
+		Where("user_id = ? AND api_key_id = ? AND revoked_at IS NULL", currentUser.ID, validated.APIKey.ID).
+		First(&authorization).Error; err == nil {
+		authorized = true
+		for _, scope := range validated.Scopes {
+			if !authorization.HasScope(scope) {
+				authorized = false
+				break
+			}
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(AuthorizeInfoResponse{
+		AppName:        validated.APIKey.AppName,
+		AppHomepageURL: validated.APIKey.AppHomepageURL,
+		AppDescription: validated.APIKey.AppDescription,
+		RedirectURI:    validated.RedirectURI,
+		Scopes:         validated.Scopes,
+		ChargeLimit:    req.ChargeLimit,
+		Authorized:     authorized,
+	}))
+}
+
+// ConsentRequest 用户授权确认请求
+type ConsentRequest struct {
+	AuthorizeRequest
+	Approve            bool            `json:"approve"`
+	MonthlyChargeLimit decimal.Decimal `json:"monthly_charge_limit"` // 用户确认的月度扣款额度，可低于应用申请的额度
+}
+
+// ConsentResponse 授权确认结果，前端跳转至 redirect_url
+type ConsentResponse struct {
+	RedirectURL string `json:"redirect_url"`
+}
+
+// Authorize 用户确认或拒绝授权，生成授权码
+// @Tags oauth2
+// @Accept json
+// @Produce json
+// @Param request body ConsentRequest true "request body"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/oauth2/authorize [post]
+func Authorize(c *gin.Context) {
+	var req ConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	validated, err := req.validate(c)
+	if err != nil {
+		respondAuthorizeError(c, err)
+		return
+	}
+
+	params := url.Values{}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+
+	if !req.Approve {
+		params.Set("error", errAccessDenied)
+		c.JSON(http.StatusOK, util.OK(ConsentResponse{RedirectURL: buildRedirectURL(validated.RedirectURI, params)}))
+		return
+	}
+
+	monthlyChargeLimit := decimal.Zero
+	for _, scope := range validated.Scopes {
+		if scope != model.OAuthScopeCharge {
+			continue
+		}
+		if err := util.ValidateAmount(req.MonthlyChargeLimit); err != nil {
+			c.JSON(http.StatusBadRequest, util.Err(ChargeLimitRequired))
+			return
+		}
+		monthlyChargeLimit = req.MonthlyChargeLimit
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	authorization := model.OAuthAuthorization{
+		UserID:             currentUser.ID,
+		APIKeyID:           validated.APIKey.ID,
+		ClientID:           validated.APIKey.ClientID,
+		Scopes:             model.JoinOAuthScopes(validated.Scopes),
+		MonthlyChargeLimit: monthlyChargeLimit,
+	}
+	if err := db.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "api_key_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"scopes":               authorization.Scopes,
+			"monthly_charge_limit": authorization.MonthlyChargeLimit,
+			"revoked_at":           nil,
+			"updated_at":           time.Now(),
+		}),
+	}).Create(&authorization).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	// 冲突更新时 Create 不会回填已有记录的 ID，重新查询
+	if err := db.DB(ctx).
+		Where("user_id = ? AND api_key_id = ?", currentUser.ID, validated.APIKey.ID).
+		First(&authorization).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	code := util.GenerateUniqueIDSimple()
+	if err := db.SetJSON(ctx, fmt.Sprintf(AuthorizationCodeCacheKeyFormat, code), authorizationCode{
+		AuthorizationID:     authorization.ID,
+		UserID:              currentUser.ID,
+		APIKeyID:            validated.APIKey.ID,
+		Scopes:              authorization.Scopes,
+		RedirectURI:         validated.RedirectURI,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	}, AuthorizationCodeExpiration); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	params.Set("code", code)
+	c.JSON(http.StatusOK, util.OK(ConsentResponse{RedirectURL: buildRedirectURL(validated.RedirectURI, params)}))
+}
+
+// Token 授权码换取令牌 / 刷新令牌（RFC 6749）
+// @Tags oauth2
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code 或 refresh_token"
+// @Success 200 {object} TokenResponse
+// @Router /oauth2/token [post]
+func Token(c *gin.Context) {
+	apiKey, ok := authenticateClient(c)
+	if !ok {
+		oauthError(c, http.StatusUnauthorized, errInvalidClient, ClientAuthFailed)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	switch c.PostForm("grant_type") {
+	case grantTypeAuthorizationCode:
+		code := c.PostForm("code")
+		if code == "" {
+			oauthError(c, http.StatusBadRequest, errInvalidRequest, InvalidAuthorizationCode)
+			return
+		}
+
+		payload, err := db.Redis.GetDel(ctx, db.PrefixedKey(fmt.Sprintf(AuthorizationCodeCacheKeyFormat, code))).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				oauthError(c, http.StatusBadRequest, errInvalidGrant, InvalidAuthorizationCode)
+				return
+			}
+			oauthError(c, http.StatusInternalServerError, errServerError, err.Error())
+			return
+		}
+
+		var authCode authorizationCode
+		if err := json.Unmarshal([]byte(payload), &authCode); err != nil || authCode.APIKeyID != apiKey.ID {
+			oauthError(c, http.StatusBadRequest, errInvalidGrant, InvalidAuthorizationCode)
+			return
+		}
+		if redirectURI := c.PostForm("redirect_uri"); redirectURI != "" && redirectURI != authCode.RedirectURI {
+			oauthError(c, http.StatusBadRequest, errInvalidGrant, RedirectURIMismatch)
+			return
+		}
+		if !verifyPKCE(authCode.CodeChallenge, authCode.CodeChallengeMethod, c.PostForm("code_verifier")) {
+			oauthError(c, http.StatusBadRequest, errInvalidGrant, CodeVerifierMismatch)
+			return
+		}
+
+		var authorization model.OAuthAuthorization
+		if err := db.DB(ctx).
+			Where("id = ? AND revoked_at IS NULL", authCode.AuthorizationID).
+			First(&authorization).Error; err != nil {
+			oauthError(c, http.StatusBadRequest, errInvalidGrant, InvalidAuthorizationCode)
+			return
+		}
+
+		resp, err := issueTokens(db.DB(ctx), &authorization, authCode.Scopes)
+		if err != nil {
+			oauthError(c, http.StatusInternalServerError, errServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+
+	case grantTypeRefreshToken:
+		refreshToken := c.PostForm("refresh_token")
+		if refreshToken == "" {
+			oauthError(c, http.StatusBadRequest, errInvalidRequest, InvalidRefreshToken)
+			return
+		}
+
+		var resp *TokenResponse
+		if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+			var oldToken model.OAuthToken
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("refresh_token_hash = ? AND refresh_expires_at > ? AND revoked_at IS NULL AND api_key_id = ?",
+					model.HashAccessToken(refreshToken), time.Now(), apiKey.ID).
+				First(&oldToken).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New(InvalidRefreshToken)
+				}
+				return err
+			}
+
+			var authorization model.OAuthAuthorization
+			if err := tx.Where("id = ? AND revoked_at IS NULL", oldToken.AuthorizationID).
+				First(&authorization).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New(InvalidRefreshToken)
+				}
+				return err
+			}
+
+			// 刷新令牌轮换：旧令牌立即作废
+			if err := tx.Model(&oldToken).Update("revoked_at", time.Now()).Error; err != nil {
+				return err
+			}
+
+			var err error
+			resp, err = issueTokens(tx, &authorization, oldToken.Scopes)
+			return err
+		}); err != nil {
+			if err.Error() == InvalidRefreshToken {
+				oauthError(c, http.StatusBadRequest, errInvalidGrant, err.Error())
+				return
+			}
+			oauthError(c, http.StatusInternalServerError, errServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+
+	default:
+		oauthError(c, http.StatusBadRequest, errUnsupportedGrantType, "")
+	}
+}
+
+// IntrospectResponse 令牌内省响应（RFC 7662）
+type IntrospectResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+}
+
+// Introspect 令牌内省，应用仅能查询自身签发的令牌
+// @Tags oauth2
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "访问令牌或刷新令牌"
+// @Success 200 {object} IntrospectResponse
+// @Router /oauth2/introspect [post]
+func Introspect(c *gin.Context) {
+	apiKey, ok := authenticateClient(c)
+	if !ok {
+		oauthError(c, http.StatusUnauthorized, errInvalidClient, ClientAuthFailed)
+		return
+	}
+
+	rawToken := c.PostForm("token")
+	if rawToken == "" {
+		oauthError(c, http.StatusBadRequest, errInvalidRequest, InvalidOAuthToken)
+		return
+	}
+
+	ctx := c.Request.Context()
+	tokenHash := model.HashAccessToken(rawToken)
+	now := time.Now()
+
+	var token model.OAuthToken
+	if err := db.DB(ctx).
+		Where("(access_token_hash = ? OR refresh_token_hash = ?) AND revoked_at IS NULL AND api_key_id = ?", tokenHash, tokenHash, apiKey.ID).
+		First(&token).Error; err != nil {
+		c.JSON(http.StatusOK, IntrospectResponse{Active: false})
+		return
+	}
+
+	tokenType := "access_token"
+	expiresAt := token.AccessExpiresAt
+	if token.RefreshTokenHash == tokenHash {
+		tokenType = "refresh_token"
+		expiresAt = token.RefreshExpiresAt
+	}
+	if !expiresAt.After(now) {
+		c.JSON(http.StatusOK, IntrospectResponse{Active: false})
+		return
+	}
+
+	var authorization model.OAuthAuthorization
+	if err := db.DB(ctx).
+		Where("id = ? AND revoked_at IS NULL", token.AuthorizationID).
+		First(&authorization).Error; err != nil {
+		c.JSON(http.StatusOK, IntrospectResponse{Active: false})
+		return
+	}
+
+	var user model.User
+	if err := db.DB(ctx).Where("id = ? AND is_active = ?", token.UserID, true).First(&user).Error; err != nil {
+		c.JSON(http.StatusOK, IntrospectResponse{Active: false})
+		return
+	}
+
+	c.JSON(http.StatusOK, IntrospectResponse{
+		Active:    true,
+		Scope:     token.Scopes,
+		ClientID:  token.ClientID,
+		Username:  user.Username,
+		Sub:       strconv.FormatUint(user.ID, 10),
+		TokenType: tokenType,
+		Exp:       expiresAt.Unix(),
+		Iat:       token.CreatedAt.Unix(),
+	})
+}
+
+// UserInfoResponse 授权用户基础信息
+type UserInfoResponse struct {
+	ID        uint64 `json:"id"`
+	Username  string `json:"username"`
+	Nickname  string `json:"nickname"`
+	AvatarUrl string `json:"avatar_url"`
+}
+
+// UserInfo 获取授权用户基础信息
+// @Tags oauth2
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/oauth2/userinfo [get]
+func UserInfo(c *gin.Context) {
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	c.JSON(http.StatusOK, util.OK(UserInfoResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Nickname:  user.Nickname,
+		AvatarUrl: user.AvatarUrl,
+	}))
+}
+
+// BalanceResponse 授权用户余额
+type BalanceResponse struct {
+	AvailableBalance decimal.Decimal `json:"available_balance"`
+}
+
+// Balance 获取授权用户可用余额
+// @Tags oauth2
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/oauth2/balance [get]
+func Balance(c *gin.Context) {
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	c.JSON(http.StatusOK, util.OK(BalanceResponse{AvailableBalance: user.AvailableBalance}))
+}
+
+// ChargeRequest 授权扣款请求
+type ChargeRequest struct {
+	OrderName       string          `json:"order_name" binding:"required,max=64"`
+	MerchantOrderNo *string         `json:"merchant_order_no" binding:"omitempty,min=1,max=64"`
+	Amount          decimal.Decimal `json:"amount" binding:"required"`
+	Remark          string          `json:"remark" binding:"max=100"`
+}
+
+// ChargeResponse 授权扣款结果
+type ChargeResponse struct {
+	OrderNo         string            `json:"order_no"`
+	MerchantOrderNo *string           `json:"merchant_order_no"`
+	Amount          decimal.Decimal   `json:"amount"`
+	Status          model.OrderStatus `json:"status"`
+	TradeTime       time.Time         `json:"trade_time"`
+}
+
+// Charge 在用户授权的月度额度内免密扣款
+// @Tags oauth2
+// @Accept json
+// @Produce json
+// @Param request body ChargeRequest true "request body"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/oauth2/charge [post]
+func Charge(c *gin.Context) {
+	var req ChargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	if err := util.ValidateAmount(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	authorization, _ := util.GetFromContext[*model.OAuthAuthorization](c, OAuthAuthorizationObjKey)
+	ctx := c.Request.Context()
+
+	var apiKey model.MerchantAPIKey
+	if err := apiKey.GetByID(db.DB(ctx), authorization.APIKeyID); err != nil {
+		c.JSON(http.StatusNotFound, util.Err(ClientNotFound))
+		return
+	}
+
+	var order *model.Order
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = service.DirectCharge(tx, service.DirectChargeOptions{
+			PayerID:         user.ID,
+			APIKey:          &apiKey,
+			Amount:          req.Amount,
+			OrderName:       req.OrderName,
+			MerchantOrderNo: req.MerchantOrderNo,
+			Remark:          req.Remark,
+			PaymentType:     common.PayTypeOAuth,
+			BeforeCharge: func(tx *gorm.DB, payer *model.User) error {
+				return checkMonthlyChargeLimit(tx, authorization, payer.ID, req.Amount)
+			},
+		})
+		return err
+	}); err != nil {
+		switch err.Error() {
+		case common.InsufficientBalance, common.DailyLimitExceeded, MonthlyChargeLimitExceeded,
+			common.CannotPaySelf, common.TestModeCannotProcessOrder, common.PayKeyResetCoolingLimited:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		case common.MerchantUnavailable:
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(ChargeResponse{
+		OrderNo:         fmt.Sprintf("%018d", order.ID),
+		MerchantOrderNo: order.MerchantOrderNo,
+		Amount:          order.Amount,
+		Status:          order.Status,
+		TradeTime:       order.TradeTime,
+	}))
+}
+
+// checkMonthlyChargeLimit 检查授权的当月累计扣款额度
+func checkMonthlyChargeLimit(tx *gorm.DB, authorization *model.OAuthAuthorization, payerID uint64, amount decimal.Decimal) error {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	var used decimal.Decimal
+	if err := tx.Model(&model.Order{}).
+		Where("client_id = ? AND payer_user_id = ? AND payment_type = ? AND status = ? AND trade_time >= ?",
+			authorization.ClientID,
+			payerID,
+			common.PayTypeOAuth,
+			model.OrderStatusSuccess,
+			monthStart).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&used).Error; err != nil {
+		return err
+	}
+
+	if used.Add(amount).GreaterThan(authorization.MonthlyChargeLimit) {
+		return errors.New(MonthlyChargeLimitExceeded)
+	}
+	return nil
+}
+
+// AuthorizationResponse 用户已授权应用
+type AuthorizationResponse struct {
+	model.OAuthAuthorization
+	AppName        string `json:"app_name"`
+	AppHomepageURL string `json:"app_homepage_url"`
+}
+
+// ListAuthorizations 查询当前用户已授权的第三方应用
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/oauth-authorizations [get]
+func ListAuthorizations(c *gin.Context) {
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var authorizations []AuthorizationResponse
+	if err := db.DB(c.Request.Context()).
+		Table("o_auth_authorizations AS a").
+		Select("a.*, k.app_name, k.app_homepage_url").
+		Joins("LEFT JOIN merchant_api_keys k ON k.id = a.api_key_id").
+		Where("a.user_id = ? AND a.revoked_at IS NULL", user.ID).
+		Order("a.updated_at DESC").
+		Scan(&authorizations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(authorizations))
+}
+
+// RevokeAuthorization 撤销对第三方应用的授权，已签发的令牌立即失效
+// @Tags user
+// @Produce json
+// @Param id path string true "授权ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/oauth-authorizations/{id} [delete]
+func RevokeAuthorization(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.OAuthAuthorization{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, user.ID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(AuthorizationNotFound)
+		}
+
+		return tx.Model(&model.OAuthToken{}).
+			Where("authorization_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", now).Error
+	}); err != nil {
+		if err.Error() == AuthorizationNotFound {
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oauthprovider
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"gorm.io/gorm"
+)
+
+// authorizationCode 授权码缓存内容
+type authorizationCode struct {
+	AuthorizationID     uint64 `json:"authorization_id"`
+	UserID              uint64 `json:"user_id"`
+	APIKeyID            uint64 `json:"api_key_id"`
+	Scopes              string `json:"scopes"`
+	RedirectURI         string `json:"redirect_uri"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+// TokenResponse 令牌响应（RFC 6749 第 5.1 节）
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// oauthError 按 OAuth2 标准格式返回错误
+func oauthError(c *gin.Context, status int, code string, description string) {
+	c.JSON(status, gin.H{"error": code, "error_description": description})
+}
+
+// loadClient 通过 client_id 查询应用
+func loadClient(ctx context.Context, clientID string) (*model.MerchantAPIKey, error) {
+	var apiKey model.MerchantAPIKey
+	if err := apiKey.GetByClientID(db.DB(ctx), clientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(ClientNotFound)
+		}
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// resolveRedirectURI 校验回调地址，必须与应用配置的回调地址完全一致；未传入时使用应用配置
+func resolveRedirectURI(apiKey *model.MerchantAPIKey, redirectURI string) (string, error) {
+	if apiKey.RedirectURL == "" {
+		return "", errors.New(RedirectURIMismatch)
+	}
+	if redirectURI == "" {
+		return apiKey.RedirectURL, nil
+	}
+	if redirectURI != apiKey.RedirectURL {
+		return "", errors.New(RedirectURIMismatch)
+	}
+	return redirectURI, nil
+}
+
+// buildRedirectURL 在回调地址上追加查询参数
+func buildRedirectURL(redirectURI string, params url.Values) string {
+	separator := "?"
+	if strings.Contains(redirectURI, "?") {
+		separator = "&"
+	}
+	return redirectURI + separator + params.Encode()
+}
+
+// authenticateClient 通过 Basic Auth 或表单参数认证应用
+func authenticateClient(c *gin.Context) (*model.MerchantAPIKey, bool) {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID = c.PostForm("client_id")
+		clientSecret = c.PostForm("client_secret")
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil, false
+	}
+
+	apiKey, err := loadClient(c.Request.Context(), clientID)
+	if err != nil {
+		return nil, false
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.ClientSecret), []byte(clientSecret)) != 1 {
+		return nil, false
+	}
+	return apiKey, true
+}
+
+// verifyPKCE 校验 PKCE code_verifier（RFC 7636，仅支持 S256）
+func verifyPKCE(challenge string, method string, verifier string) bool {
+	if challenge == "" {
+		return true
+	}
+	if method != codeChallengeMethodS256 || verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// issueTokens 为授权签发一组访问令牌与刷新令牌
+func issueTokens(tx *gorm.DB, authorization *model.OAuthAuthorization, scopes string) (*TokenResponse, error) {
+	accessToken := accessTokenPrefix + util.GenerateUniqueIDSimple()
+	refreshToken := refreshTokenPrefix + util.GenerateUniqueIDSimple()
+	now := time.Now()
+
+	token := model.OAuthToken{
+		AuthorizationID:  authorization.ID,
+		UserID:           authorization.UserID,
+		APIKeyID:         authorization.APIKeyID,
+		ClientID:         authorization.ClientID,
+		Scopes:           scopes,
+		AccessTokenHash:  model.HashAccessToken(accessToken),
+		AccessExpiresAt:  now.Add(AccessTokenExpiration),
+		RefreshTokenHash: model.HashAccessToken(refreshToken),
+		RefreshExpiresAt: now.Add(RefreshTokenExpiration),
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(AccessTokenExpiration.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        scopes,
+	}, nil
+}
+
+// respondAuthorizeError 授权请求参数校验错误
+func respondAuthorizeError(c *gin.Context, err error) {
+	switch err.Error() {
+	case ClientNotFound:
+		c.JSON(http.StatusNotFound, util.Err(err.Error()))
+	case RedirectURIMismatch, UnsupportedResponseType, InvalidScope, ChargeLimitRequired, UnsupportedCodeChallengeMethod:
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+	}
+}
@@ -17,21 +17,26 @@ limitations under the License.
 package order
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/linux-do/credit/internal/apps/oauth"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
 	"github.com/linux-do/credit/internal/util"
+	"gorm.io/gorm"
 )
 
 type TransactionListRequest struct {
 	Page          int        `json:"page" form:"page" binding:"min=1"`
 	PageSize      int        `json:"page_size" form:"page_size" binding:"min=1,max=100"`
-	Types         []string   `json:"types" form:"types" binding:"omitempty,dive,oneof=receive payment transfer community online test distribute red_envelope_send red_envelope_receive red_envelope_refund"`
-	Statuses      []string   `json:"statuses" form:"statuses" binding:"omitempty,dive,oneof=success pending failed expired disputing refund refused"`
+	Types         []string   `json:"types" form:"types" binding:"omitempty,dive,oneof=receive payment transfer community online test distribute red_envelope_send red_envelope_receive red_envelope_refund split_bill escrow transfer_return"`
+	Statuses      []string   `json:"statuses" form:"statuses" binding:"omitempty,dive,oneof=success pending failed expired disputing refund refused held voided cancelled"`
 	ClientID      string     `json:"client_id" form:"client_id" binding:"omitempty"`
 	StartTime     *time.Time `json:"startTime" form:"startTime" binding:"omitempty"`
 	EndTime       *time.Time `json:"endTime" form:"endTime" binding:"omitempty,gtfield=StartTime"`
@@ -52,10 +57,12 @@ type TransactionListResponse struct {
 		AppDescription string  `json:"app_description"`
 		RedirectURL    string  `json:"redirect_url"`
 		DisputeID      *uint64 `json:"dispute_id,string"`
-		PayerUsername  string  `json:"payer_username"`
-		PayeeUsername  string  `json:"payee_username"`
-		PayerAvatarURL string  `json:"payer_avatar_url"`
-		PayeeAvatarURL string  `json:"payee_avatar_url"`
+		// ReversalOrderID 转账已被退回时对应的退回订单 ID，与退回订单的 reversal_of_order_id 互相关联
+		ReversalOrderID *uint64 `json:"reversal_order_id,string"`
+		PayerUsername   string  `json:"payer_username"`
+		PayeeUsername   string  `json:"payee_username"`
+		PayerAvatarURL  string  `json:"payer_avatar_url"`
+		PayeeAvatarURL  string  `json:"payee_avatar_url"`
 	} `json:"orders"`
 }
 
@@ -76,9 +83,10 @@ func ListTransactions(c *gin.Context) {
 	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
 
 	baseQuery := db.DB(c.Request.Context()).Model(&model.Order{}).
-		Select("orders.*, merchant_api_keys.app_name, merchant_api_keys.app_homepage_url, merchant_api_keys.app_description, merchant_api_keys.redirect_url, disputes.id as dispute_id, payer_user.username as payer_username, payee_user.username as payee_username, payer_user.avatar_url as payer_avatar_url, payee_user.avatar_url as payee_avatar_url").
+		Select("orders.*, merchant_api_keys.app_name, merchant_api_keys.app_homepage_url, merchant_api_keys.app_description, merchant_api_keys.redirect_url, disputes.id as dispute_id, reversal_order.id as reversal_order_id, payer_user.username as payer_username, payee_user.username as payee_username, payer_user.avatar_url as payer_avatar_url, payee_user.avatar_url as payee_avatar_url").
 		Joins("LEFT JOIN merchant_api_keys ON orders.client_id = merchant_api_keys.client_id").
 		Joins("LEFT JOIN disputes ON orders.id = disputes.order_id").
+		Joins("LEFT JOIN orders as reversal_order ON reversal_order.reversal_of_order_id = orders.id").
 		Joins("LEFT JOIN users as payer_user ON orders.payer_user_id = payer_user.id").
 		Joins("LEFT JOIN users as payee_user ON orders.payee_user_id = payee_user.id")
 
@@ -100,6 +108,10 @@ func ListTransactions(c *gin.Context) {
 				// community、red_envelope_refund、red_envelope_receive 类型：查询当前用户作为收款方的订单
 				conditions = append(conditions, "(orders.type = ? AND orders.payee_user_id = ?)")
 				args = append(args, orderType, user.ID)
+			case model.OrderTypeSplitBill, model.OrderTypeEscrow, model.OrderTypeTransferReturn:
+				// split_bill、escrow、transfer_return 类型：查询当前用户作为付款方或收款方的订单
+				conditions = append(conditions, "(orders.type = ? AND (orders.payer_user_id = ? OR orders.payee_user_id = ?))")
+				args = append(args, orderType, user.ID, user.ID)
 			case model.OrderTypeOnline:
 				// online 类型：商家可查看自己 client_id 的所有订单，普通用户只能查看与自己相关的订单
 				if req.ClientID != "" {
@@ -197,3 +209,78 @@ func ListTransactions(c *gin.Context) {
 
 	c.JSON(http.StatusOK, util.OK(response))
 }
+
+// DeliveredItem 订单发放的卡密
+type DeliveredItem struct {
+	ID         uint64     `json:"id,string"`
+	Content    string     `json:"content"`
+	AssignedAt *time.Time `json:"assigned_at"`
+}
+
+// OrderDetailResponse 订单详情
+type OrderDetailResponse struct {
+	model.Order
+	AppName        string          `json:"app_name"`
+	AppHomepageURL string          `json:"app_homepage_url"`
+	DeliveredItems []DeliveredItem `json:"delivered_items"` // 仅付款方可见
+}
+
+// GetOrderDetail 获取订单详情，付款方可查看发卡链接自动发放的卡密
+// @Tags order
+// @Produce json
+// @Param id path string true "订单ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/order/{id} [get]
+func GetOrderDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var detail OrderDetailResponse
+	if err := db.DB(ctx).Model(&model.Order{}).
+		Select("orders.*, merchant_api_keys.app_name, merchant_api_keys.app_homepage_url, payer_user.username as payer_username, payee_user.username as payee_username").
+		Joins("LEFT JOIN merchant_api_keys ON orders.client_id = merchant_api_keys.client_id").
+		Joins("LEFT JOIN users as payer_user ON orders.payer_user_id = payer_user.id").
+		Joins("LEFT JOIN users as payee_user ON orders.payee_user_id = payee_user.id").
+		Where("orders.id = ? AND (orders.payer_user_id = ? OR orders.payee_user_id = ?)", id, user.ID, user.ID).
+		First(&detail).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(OrderNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	detail.OrderNo = fmt.Sprintf("%018d", detail.ID)
+
+	detail.DeliveredItems = []DeliveredItem{}
+	if detail.PayerUserID == user.ID && detail.PaymentLinkID != nil {
+		var items []model.PaymentLinkItem
+		if err := db.DB(ctx).
+			Where("order_id = ? AND buyer_user_id = ?", detail.ID, user.ID).
+			Order("id ASC").
+			Find(&items).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		for _, item := range items {
+			content, err := service.DecryptCardKey(item.Content)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+				return
+			}
+			detail.DeliveredItems = append(detail.DeliveredItems, DeliveredItem{
+				ID:         item.ID,
+				Content:    content,
+				AssignedAt: item.AssignedAt,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, util.OK(detail))
+}
@@ -17,12 +17,16 @@ limitations under the License.
 package payment
 
 const (
-	OrderNotFound        = "订单不存在或已完成"
-	OrderStatusInvalid   = "订单状态不允许支付"
-	OrderExpired         = "订单已过期"
-	MerchantInfoNotFound = "商户信息不存在"
-	RecipientNotFound    = "收款人不存在"
-	OrderNoFormatError   = "订单号格式错误"
-	CannotTransferToSelf = "不能转账给自己"
-	PayConfigNotFound    = "支付配置不存在"
+	OrderNotFound         = "订单不存在或已完成"
+	OrderStatusInvalid    = "订单状态不允许支付"
+	OrderExpired          = "订单已过期"
+	MerchantInfoNotFound  = "商户信息不存在"
+	RecipientNotFound     = "收款人不存在"
+	OrderNoFormatError    = "订单号格式错误"
+	CannotTransferToSelf  = "不能转账给自己"
+	PayConfigNotFound     = "支付配置不存在"
+	AuthorizationNotFound = "预授权订单不存在或已结算"
+	AuthorizationExpired  = "预授权已过期"
+	CaptureAmountExceeded = "扣款金额不能超过预授权金额"
+	UndoWindowDisabled    = "可撤回转账未开启"
 )
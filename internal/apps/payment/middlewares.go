@@ -36,6 +36,8 @@ type CreateOrderRequest struct {
 	Amount          decimal.Decimal `json:"amount" binding:"required"`
 	Remark          string          `json:"remark" binding:"max=100"`
 	PaymentType     string          `json:"payment_type"`
+	// CaptureMode 为 manual 时支付仅冻结资金，需商户调用扣款接口确认
+	CaptureMode model.CaptureMode `json:"capture_mode" binding:"omitempty,oneof=auto manual"`
 }
 
 // EPayRequest 易支付请求
@@ -50,6 +52,7 @@ type EPayRequest struct {
 	Sign            string          `form:"sign" binding:"required"`
 	PayType         string          `form:"type" binding:"required"`
 	SignType        string          `form:"sign_type"`
+	CaptureMode     string          `form:"capture_mode" binding:"omitempty,oneof=auto manual"`
 }
 
 // ToCreateOrderRequest 转换为通用创建订单请求
@@ -59,6 +62,7 @@ func (r *EPayRequest) ToCreateOrderRequest() *CreateOrderRequest {
 		MerchantOrderNo: r.MerchantOrderNo,
 		Amount:          r.Amount,
 		PaymentType:     r.PayType,
+		CaptureMode:     model.CaptureMode(r.CaptureMode),
 	}
 }
 
@@ -41,8 +41,16 @@ import (
 
 // PayOrderRequest 用户支付订单请求
 type PayOrderRequest struct {
-	OrderNo string `json:"order_no" binding:"required"`
-	PayKey  string `json:"pay_key" binding:"required,max=6"`
+	OrderNo    string `json:"order_no" binding:"required"`
+	PayKey     string `json:"pay_key" binding:"required,max=6"`
+	TOTPCode   string `json:"totp_code" binding:"max=16"`
+	CouponCode string `json:"coupon_code" binding:"max=32"` // 为空表示不使用优惠券
+}
+
+// PreviewOrderCouponRequest 商户订单优惠券试算请求
+type PreviewOrderCouponRequest struct {
+	OrderNo    string `json:"order_no" binding:"required"`
+	CouponCode string `json:"coupon_code" binding:"required,max=32"`
 }
 
 // GetOrderRequest 查询订单请求
@@ -69,7 +77,17 @@ type TransferRequest struct {
 	RecipientUsername string          `json:"recipient_username" binding:"required"`
 	Amount            decimal.Decimal `json:"amount" binding:"required"`
 	PayKey            string          `json:"pay_key" binding:"required,max=6"`
+	TOTPCode          string          `json:"totp_code" binding:"max=16"`
 	Remark            string          `json:"remark" binding:"max=100"`
+	// Delayed 可撤回转账，资金冻结至撤回窗口结束后到账，期间付款人可撤回
+	Delayed bool `json:"delayed"`
+}
+
+// ReturnTransferRequest 退回转账请求
+type ReturnTransferRequest struct {
+	PayKey   string `json:"pay_key" binding:"required,max=6"`
+	TOTPCode string `json:"totp_code" binding:"max=16"`
+	Remark   string `json:"remark" binding:"max=100"`
 }
 
 // QueryOrderRequest 商户查询订单请求
@@ -89,6 +107,18 @@ type RefundOrderRequest struct {
 	Amount          decimal.Decimal `form:"money" json:"money" binding:"required"`
 }
 
+// CaptureOrderRequest 商户预授权扣款请求
+type CaptureOrderRequest struct {
+	TradeNo uint64 `json:"trade_no" binding:"required"`
+	// Amount 扣款金额，为空时按预授权金额全额扣款
+	Amount decimal.Decimal `json:"money"`
+}
+
+// VoidOrderRequest 商户撤销预授权请求
+type VoidOrderRequest struct {
+	TradeNo uint64 `json:"trade_no" binding:"required"`
+}
+
 // CreateMerchantOrder 商户创建订单接口
 // @Tags payment
 // @Accept x-www-form-urlencoded
@@ -130,6 +160,7 @@ func CreateMerchantOrder(c *gin.Context) {
 				Type:            model.OrderTypePayment,
 				Remark:          req.Remark,
 				PaymentType:     req.PaymentType,
+				CaptureMode:     req.CaptureMode,
 				ExpiresAt:       time.Now().Add(time.Duration(expireMinutes) * time.Minute),
 			}
 			if err := tx.Create(&order).Error; err != nil {
@@ -212,7 +243,7 @@ func QueryMerchantOrder(c *gin.Context) {
 		statusInt = 1
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"code":         1,
 		"msg":          "查询订单号成功！",
 		"trade_no":     strconv.FormatUint(order.ID, 10),
@@ -224,7 +255,23 @@ func QueryMerchantOrder(c *gin.Context) {
 		"name":         order.OrderName,
 		"money":        order.Amount.Truncate(2).StringFixed(2),
 		"status":       statusInt,
-	})
+	}
+	if order.CouponID != nil && order.OriginalAmount != nil {
+		resp["original_money"] = order.OriginalAmount.Truncate(2).StringFixed(2)
+		resp["discount"] = order.DiscountAmount.Truncate(2).StringFixed(2)
+	}
+	if order.CaptureMode == model.CaptureModeManual {
+		resp["capture_mode"] = order.CaptureMode
+		resp["trade_status"] = order.Status
+		if order.AuthorizedAmount != nil {
+			resp["authorized_money"] = order.AuthorizedAmount.Truncate(2).StringFixed(2)
+		}
+		if order.AuthExpiresAt != nil {
+			resp["auth_expires_at"] = order.AuthExpiresAt.Format("2006-01-02 15:04:05")
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 // RefundMerchantOrderResponse 退款响应
@@ -518,8 +565,52 @@ func PayMerchantOrder(c *gin.Context) {
 		return
 	}
 
-	if !orderCtx.CurrentUser.VerifyPayKey(req.PayKey) {
-		c.JSON(http.StatusBadRequest, util.Err(common.PayKeyIncorrect))
+	var pendingOrder model.Order
+	if err := db.DB(c.Request.Context()).
+		Select("id, amount, capture_mode").
+		Where("id = ? AND status = ?", orderCtx.OrderID, model.OrderStatusPending).
+		First(&pendingOrder).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(OrderNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	// 优惠券预校验，实付金额用于支付验证
+	payAmount := pendingOrder.Amount
+	if req.CouponCode != "" {
+		quote, err := service.ApplyCoupon(db.DB(c.Request.Context()), service.ApplyCouponOptions{
+			APIKeyID: orderCtx.MerchantAPIKey.ID,
+			Code:     req.CouponCode,
+			PayerID:  orderCtx.CurrentUser.ID,
+			Amount:   pendingOrder.Amount,
+		})
+		if err != nil {
+			if service.IsCouponError(err.Error()) {
+				c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			} else {
+				c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			}
+			return
+		}
+		payAmount = quote.Amount
+	}
+
+	// 预授权订单：读取冻结时长
+	manualCapture := pendingOrder.CaptureMode == model.CaptureModeManual
+	var authHoldHours int
+	if manualCapture {
+		var errGet error
+		if authHoldHours, errGet = model.GetIntByKey(c.Request.Context(), model.ConfigKeyMerchantAuthHoldHours); errGet != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(errGet.Error()))
+			return
+		}
+	}
+
+	if err := service.VerifyPaymentAuth(c.Request.Context(), orderCtx.CurrentUser, req.PayKey, req.TOTPCode, payAmount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
 
@@ -540,6 +631,21 @@ func PayMerchantOrder(c *gin.Context) {
 				return errors.New(OrderExpired)
 			}
 
+			// 优惠券：锁定后复核使用次数，并按实付金额结算
+			if req.CouponCode != "" {
+				quote, err := service.ApplyCoupon(tx, service.ApplyCouponOptions{
+					APIKeyID: orderCtx.MerchantAPIKey.ID,
+					Code:     req.CouponCode,
+					PayerID:  orderCtx.CurrentUser.ID,
+					Amount:   order.Amount,
+					Lock:     true,
+				})
+				if err != nil {
+					return err
+				}
+				quote.ApplyTo(&order)
+			}
+
 			isTestMode := orderCtx.MerchantAPIKey.TestMode
 
 			// 非测试模式：检查每日限额
@@ -547,20 +653,30 @@ func PayMerchantOrder(c *gin.Context) {
 				if err := service.CheckDailyLimit(tx, orderCtx.CurrentUser.ID, order.Amount, orderCtx.PayerPayConfig.DailyLimit); err != nil {
 					return err
 				}
+				if err := service.LockAndCheckPayKeyResetCooling(tx, orderCtx.CurrentUser, order.Amount); err != nil {
+					return err
+				}
 			}
 
 			// 计算手续费
 			_, merchantAmount, feePercent := service.CalculateFee(order.Amount, orderCtx.MerchantPayConfig.FeeRate)
 
-			// 更新订单状态
+			// 更新订单状态，预授权订单冻结资金，待商户扣款时再收取手续费
 			order.Status = model.OrderStatusSuccess
 			order.PayerUserID = orderCtx.CurrentUser.ID
 			order.TradeTime = time.Now()
+			if manualCapture {
+				authorizedAmount := order.Amount
+				authExpiresAt := order.TradeTime.Add(time.Duration(authHoldHours) * time.Hour)
+				order.Status = model.OrderStatusHeld
+				order.AuthorizedAmount = &authorizedAmount
+				order.AuthExpiresAt = &authExpiresAt
+			}
 
 			if isTestMode {
 				order.Type = model.OrderTypeTest
 				order.Remark = common.TestModeOrderRemark
-			} else {
+			} else if !manualCapture {
 				feeRemark := fmt.Sprintf("[系统]: 收取商家%d%%手续费", feePercent)
 				if order.Remark != "" {
 					order.Remark = order.Remark + " " + feeRemark
@@ -573,8 +689,12 @@ func PayMerchantOrder(c *gin.Context) {
 				return err
 			}
 
-			// 非测试模式：扣减用户余额和增加商户余额
-			if !isTestMode {
+			// 非测试模式：预授权冻结用户余额，否则扣减用户余额和增加商户余额
+			if !isTestMode && manualCapture {
+				if _, err := service.HoldBalance(tx, orderCtx.CurrentUser.ID, order.Amount); err != nil {
+					return err
+				}
+			} else if !isTestMode {
 				if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
 					UserID:       orderCtx.CurrentUser.ID,
 					Amount:       order.Amount,
@@ -609,11 +729,15 @@ func PayMerchantOrder(c *gin.Context) {
 	); err != nil {
 		errMsg := err.Error()
 		switch errMsg {
-		case common.InsufficientBalance, OrderExpired, common.DailyLimitExceeded:
+		case common.InsufficientBalance, OrderExpired, common.DailyLimitExceeded, common.PayKeyResetCoolingLimited:
 			c.JSON(http.StatusBadRequest, util.Err(errMsg))
 		case OrderNotFound:
 			c.JSON(http.StatusNotFound, util.Err(errMsg))
 		default:
+			if service.IsCouponError(errMsg) {
+				c.JSON(http.StatusBadRequest, util.Err(errMsg))
+				return
+			}
 			c.JSON(http.StatusInternalServerError, util.Err(errMsg))
 		}
 		return
@@ -622,6 +746,55 @@ func PayMerchantOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, util.OKNil())
 }
 
+// PreviewOrderCoupon 商户订单支付页输入优惠码后试算实付金额
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Param request body PreviewOrderCouponRequest true "试算请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/payment/coupon [post]
+func PreviewOrderCoupon(c *gin.Context) {
+	var req PreviewOrderCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	orderCtx, errCtx := ParseOrderNo(c, req.OrderNo)
+	if HandleParseOrderNoError(c, errCtx) {
+		return
+	}
+
+	var pendingOrder model.Order
+	if err := db.DB(c.Request.Context()).
+		Select("id, amount, capture_mode").
+		Where("id = ? AND status = ?", orderCtx.OrderID, model.OrderStatusPending).
+		First(&pendingOrder).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(OrderNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	quote, err := service.ApplyCoupon(db.DB(c.Request.Context()), service.ApplyCouponOptions{
+		APIKeyID: orderCtx.MerchantAPIKey.ID,
+		Code:     req.CouponCode,
+		PayerID:  orderCtx.CurrentUser.ID,
+		Amount:   pendingOrder.Amount,
+	})
+	if err != nil {
+		if service.IsCouponError(err.Error()) {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		} else {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(quote))
+}
+
 // Transfer 用户转账接口
 // @Tags payment
 // @Accept json
@@ -643,8 +816,24 @@ func Transfer(c *gin.Context) {
 
 	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
 
-	if !currentUser.VerifyPayKey(req.PayKey) {
-		c.JSON(http.StatusBadRequest, util.Err(common.PayKeyIncorrect))
+	// 可撤回转账：读取撤回窗口
+	var settlesAt *time.Time
+	if req.Delayed {
+		undoWindowMinutes, errGet := model.GetIntByKey(c.Request.Context(), model.ConfigKeyTransferUndoWindowMinutes)
+		if errGet != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(errGet.Error()))
+			return
+		}
+		if undoWindowMinutes <= 0 {
+			c.JSON(http.StatusBadRequest, util.Err(UndoWindowDisabled))
+			return
+		}
+		settleTime := time.Now().Add(time.Duration(undoWindowMinutes) * time.Minute).Truncate(time.Second)
+		settlesAt = &settleTime
+	}
+
+	if err := service.VerifyPaymentAuth(c.Request.Context(), currentUser, req.PayKey, req.TOTPCode, req.Amount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
 
@@ -653,6 +842,7 @@ func Transfer(c *gin.Context) {
 		return
 	}
 
+	var order *model.Order
 	if err := db.DB(c.Request.Context()).Transaction(
 		func(tx *gorm.DB) error {
 			// 验证收款人是否存在且用户名匹配
@@ -664,54 +854,30 @@ func Transfer(c *gin.Context) {
 				return err
 			}
 
-			var payer model.User
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
-				Where("id = ?", currentUser.ID).
-				First(&payer).Error; err != nil {
-				return err
-			}
-
-			if payer.AvailableBalance.LessThan(req.Amount) {
-				return errors.New(common.InsufficientBalance)
-			}
-
-			// 创建转账订单
-			order := model.Order{
-				OrderName:   "转账",
-				PayerUserID: payer.ID,
-				PayeeUserID: recipient.ID,
-				Amount:      req.Amount,
-				Status:      model.OrderStatusSuccess,
-				Type:        model.OrderTypeTransfer,
-				Remark:      req.Remark,
-				TradeTime:   time.Now(),
-				ExpiresAt:   time.Now().Add(24 * time.Hour),
+			opts := service.TransferOptions{
+				PayerID:   currentUser.ID,
+				PayeeID:   recipient.ID,
+				Amount:    req.Amount,
+				OrderName: "转账",
+				Remark:    req.Remark,
+				SettlesAt: settlesAt,
 			}
-
-			if err := tx.Create(&order).Error; err != nil {
-				return err
+			if accessToken, viaAccessToken := oauth.GetAccessTokenFromContext(c); viaAccessToken {
+				opts.AccessTokenID = &accessToken.ID
+				opts.BeforeTransfer = func(tx *gorm.DB, payer *model.User) error {
+					return service.CheckAccessTokenTransferLimit(tx, accessToken, req.Amount)
+				}
 			}
 
-			// 扣减付款人余额
-			if err := tx.Model(&model.User{}).
-				Where("id = ?", payer.ID).
-				UpdateColumns(map[string]interface{}{
-					"available_balance": gorm.Expr("available_balance - ?", req.Amount),
-					"total_transfer":    gorm.Expr("total_transfer + ?", req.Amount),
-				}).Error; err != nil {
+			var err error
+			if order, err = service.Transfer(tx, opts); err != nil {
 				return err
 			}
 
-			// 增加收款人余额
-			if err := tx.Model(&model.User{}).
-				Where("id = ?", recipient.ID).
-				UpdateColumns(map[string]interface{}{
-					"available_balance": gorm.Expr("available_balance + ?", req.Amount),
-					"total_receive":     gorm.Expr("total_receive + ?", req.Amount),
-				}).Error; err != nil {
-				return err
+			// 到账任务在事务内下发，下发失败时转账回滚，避免资金长期冻结
+			if settlesAt != nil {
+				return enqueueSettleDelayedTransfer(order)
 			}
-
 			return nil
 		},
 	); err != nil {
@@ -719,5 +885,200 @@ func Transfer(c *gin.Context) {
 		return
 	}
 
+	if settlesAt != nil {
+		c.JSON(http.StatusOK, util.OK(order))
+		return
+	}
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// CancelDelayedTransfer 在撤回窗口内撤回可撤回转账，冻结资金退回可用余额
+// @Tags payment
+// @Produce json
+// @Param id path string true "订单ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/payment/transfer/{id}/cancel [post]
+func CancelDelayedTransfer(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(OrderNoFormatError))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var order *model.Order
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var errCancel error
+		order, errCancel = service.CancelDelayedTransfer(tx, orderID, currentUser.ID)
+		return errCancel
+	}); err != nil {
+		switch err.Error() {
+		case common.DelayedTransferNotFound, common.DelayedTransferUndoWindowClosed:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(order))
+}
+
+// ReturnTransfer 收款人将误收的转账原路退回付款人，仅限登录会话调用，个人访问令牌不可用
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Param id path string true "原转账订单ID"
+// @Param request body ReturnTransferRequest true "退回请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/payment/transfer/{id}/return [post]
+func ReturnTransfer(c *gin.Context) {
+	var req ReturnTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(OrderNoFormatError))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var original model.Order
+	if err := db.DB(ctx).
+		Where("id = ? AND payee_user_id = ? AND type = ? AND status = ?", orderID, currentUser.ID, model.OrderTypeTransfer, model.OrderStatusSuccess).
+		First(&original).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, util.Err(common.TransferNotReturnable))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := service.VerifyPaymentAuth(ctx, currentUser, req.PayKey, req.TOTPCode, original.Amount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	var reversal *model.Order
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var errReturn error
+		reversal, errReturn = service.ReturnTransfer(tx, original.ID, currentUser.ID, req.Remark)
+		return errReturn
+	}); err != nil {
+		switch err.Error() {
+		case common.TransferNotReturnable, common.InsufficientBalance:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(reversal))
+}
+
+// CaptureMerchantOrder 商户对预授权订单全额或部分扣款，未扣款部分退回买家
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Param request body CaptureOrderRequest true "扣款请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/capture [post]
+func CaptureMerchantOrder(c *gin.Context) {
+	var req CaptureOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	if !req.Amount.IsZero() {
+		if err := util.ValidateAmount(req.Amount); err != nil {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, APIKeyObjKey)
+
+	var captured *model.Order
+	var captureAmount decimal.Decimal
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		order, err := lockAuthorization(tx, "id = ? AND client_id = ?", req.TradeNo, apiKey.ClientID)
+		if err != nil {
+			return err
+		}
+
+		if order.AuthExpiresAt != nil && order.AuthExpiresAt.Before(time.Now()) {
+			return errors.New(AuthorizationExpired)
+		}
+
+		captureAmount = req.Amount
+		if captureAmount.IsZero() {
+			captureAmount = order.Amount
+		}
+		if captureAmount.GreaterThan(order.Amount) {
+			return errors.New(CaptureAmountExceeded)
+		}
+
+		captured = order
+		return settleAuthorization(tx, order, captureAmount, model.OrderStatusSuccess)
+	}); err != nil {
+		switch err.Error() {
+		case AuthorizationExpired, CaptureAmountExceeded:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		case AuthorizationNotFound:
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(gin.H{
+		"trade_no":         strconv.FormatUint(captured.ID, 10),
+		"out_trade_no":     captured.MerchantOrderNo,
+		"authorized_money": captured.AuthorizedAmount.Truncate(2).StringFixed(2),
+		"money":            captureAmount.Truncate(2).StringFixed(2),
+	}))
+}
+
+// VoidMerchantOrder 商户撤销预授权，冻结资金全部退回买家
+// @Tags payment
+// @Accept json
+// @Produce json
+// @Param request body VoidOrderRequest true "撤销请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/void [post]
+func VoidMerchantOrder(c *gin.Context) {
+	var req VoidOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, APIKeyObjKey)
+
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		order, err := lockAuthorization(tx, "id = ? AND client_id = ?", req.TradeNo, apiKey.ClientID)
+		if err != nil {
+			return err
+		}
+		return settleAuthorization(tx, order, decimal.Zero, model.OrderStatusVoided)
+	}); err != nil {
+		if err.Error() == AuthorizationNotFound {
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
 	c.JSON(http.StatusOK, util.OKNil())
 }
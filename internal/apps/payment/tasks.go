@@ -26,13 +26,16 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/linux-do/credit/internal/common"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/logger"
 	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
 	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -50,7 +53,7 @@ func HandleMerchantPaymentNotify(ctx context.Context, t *asynq.Task) error {
 
 	// 查询订单信息
 	var order model.Order
-	if err := db.DB(ctx).Where("id = ? AND status = ?", payload.OrderID, model.OrderStatusSuccess).First(&order).Error; err != nil {
+	if err := db.DB(ctx).Where("id = ? AND status IN ?", payload.OrderID, []model.OrderStatus{model.OrderStatusSuccess, model.OrderStatusHeld}).First(&order).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logger.ErrorF(ctx, "订单[ID:%d]不存在，跳过回调", payload.OrderID)
 			return nil
@@ -77,9 +80,21 @@ func HandleMerchantPaymentNotify(ctx context.Context, t *asynq.Task) error {
 		"sign_type":    "MD5",
 	}
 
+	// 预授权订单通知商户资金已冻结，商户需调用扣款接口完成收款
+	if order.Status == model.OrderStatusHeld {
+		callbackParams["trade_status"] = "TRADE_AUTHORIZED"
+		callbackParams["auth_expires_at"] = order.AuthExpiresAt.Format("2006-01-02 15:04:05")
+	}
+
+	// 使用优惠券的订单附带优惠前金额与减免金额，money 为实付金额
+	if order.CouponID != nil && order.OriginalAmount != nil {
+		callbackParams["original_money"] = order.OriginalAmount.Truncate(2).StringFixed(2)
+		callbackParams["discount"] = order.DiscountAmount.Truncate(2).StringFixed(2)
+	}
+
 	callbackParams["sign"] = GenerateSignature(callbackParams, apiKey.ClientSecret)
 
-	if err := sendCallbackRequest(ctx, apiKey.NotifyURL, callbackParams); err != nil {
+	if err := SendCallbackRequest(ctx, apiKey.NotifyURL, callbackParams); err != nil {
 		retried, _ := asynq.GetRetryCount(ctx)
 		logger.ErrorF(ctx, "商户回调失败: 订单[ID:%d] 重试次数[%d] 错误: %v",
 			payload.OrderID, retried+1, err)
@@ -90,8 +105,8 @@ func HandleMerchantPaymentNotify(ctx context.Context, t *asynq.Task) error {
 	return nil
 }
 
-// sendCallbackRequest 发送HTTP回调请求
-func sendCallbackRequest(ctx context.Context, callbackURL string, params map[string]string) error {
+// SendCallbackRequest 发送HTTP回调请求，商户需响应 success
+func SendCallbackRequest(ctx context.Context, callbackURL string, params map[string]string) error {
 	vals := url.Values{}
 	for k, v := range params {
 		vals.Add(k, v)
@@ -131,3 +146,86 @@ func sendCallbackRequest(ctx context.Context, callbackURL string, params map[str
 	logger.InfoF(ctx, "商户回调请求成功: URL[%s] 响应[%s]", callbackURL, string(respBody))
 	return nil
 }
+
+// HandleExpireAuthorizations 撤销已到期仍未扣款的预授权订单，冻结资金退回买家
+func HandleExpireAuthorizations(ctx context.Context, t *asynq.Task) error {
+	var orderIDs []uint64
+	if err := db.DB(ctx).Model(&model.Order{}).
+		Where("status = ? AND capture_mode = ? AND auth_expires_at <= ?", model.OrderStatusHeld, model.CaptureModeManual, time.Now()).
+		Order("id ASC").
+		Pluck("id", &orderIDs).Error; err != nil {
+		logger.ErrorF(ctx, "查询到期预授权订单失败: %v", err)
+		return err
+	}
+
+	expired := 0
+	for _, orderID := range orderIDs {
+		if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+			order, err := lockAuthorization(tx, "id = ?", orderID)
+			if err != nil {
+				return err
+			}
+			return settleAuthorization(tx, order, decimal.Zero, model.OrderStatusExpired)
+		}); err != nil {
+			logger.ErrorF(ctx, "预授权订单[ID:%d]到期撤销失败: %v", orderID, err)
+			continue
+		}
+		expired++
+	}
+
+	logger.InfoF(ctx, "预授权到期撤销完成: 共[%d] 成功[%d]", len(orderIDs), expired)
+	return nil
+}
+
+// HandleSettleDelayedTransfer 可撤回转账撤回窗口结束后到账
+func HandleSettleDelayedTransfer(ctx context.Context, t *asynq.Task) error {
+	var payload struct {
+		OrderID uint64 `json:"order_id"`
+	}
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("解析任务参数失败: %w", err)
+	}
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := service.SettleDelayedTransfer(tx, payload.OrderID)
+		return err
+	}); err != nil {
+		// 已撤回或已到账
+		if err.Error() == common.DelayedTransferNotFound {
+			return nil
+		}
+		logger.ErrorF(ctx, "转账订单[ID:%d]到账失败: %v", payload.OrderID, err)
+		return err
+	}
+
+	logger.InfoF(ctx, "转账订单[ID:%d]撤回窗口结束，已到账", payload.OrderID)
+	return nil
+}
+
+// HandleSettleDueDelayedTransfers 扫描已过到账时间仍处于冻结状态的可撤回转账并完成到账
+// 正常情况下由下单时下发的延时任务到账，此处用于任务重试耗尽后的兜底
+func HandleSettleDueDelayedTransfers(ctx context.Context, t *asynq.Task) error {
+	var orderIDs []uint64
+	if err := db.DB(ctx).Model(&model.Order{}).
+		Where("status = ? AND type = ? AND settles_at <= ?", model.OrderStatusHeld, model.OrderTypeTransfer, time.Now()).
+		Order("id ASC").
+		Pluck("id", &orderIDs).Error; err != nil {
+		logger.ErrorF(ctx, "查询到期可撤回转账失败: %v", err)
+		return err
+	}
+
+	settled := 0
+	for _, orderID := range orderIDs {
+		if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+			_, err := service.SettleDelayedTransfer(tx, orderID)
+			return err
+		}); err != nil {
+			logger.ErrorF(ctx, "转账订单[ID:%d]到账失败: %v", orderID, err)
+			continue
+		}
+		settled++
+	}
+
+	logger.InfoF(ctx, "可撤回转账到账完成: 共[%d] 成功[%d]", len(orderIDs), settled)
+	return nil
+}
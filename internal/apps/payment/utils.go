@@ -19,22 +19,29 @@ package payment
 import (
 	"crypto/md5"
 	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
 	"github.com/linux-do/credit/internal/apps/oauth"
 	"github.com/linux-do/credit/internal/common"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/task"
+	"github.com/linux-do/credit/internal/task/scheduler"
 	"github.com/linux-do/credit/internal/util"
 	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // HandleParseOrderNoError 处理 ParseOrderNo 返回的错误，返回对应的 HTTP 响应
@@ -210,6 +217,7 @@ func VerifySignature(c *gin.Context, apiKey *model.MerchantAPIKey) (*CreateOrder
 		"return_url":   req.ReturnURL,
 		"name":         req.OrderName,
 		"device":       req.Device,
+		"capture_mode": req.CaptureMode,
 	}
 
 	params["money"] = req.Amount.Truncate(2).StringFixed(2)
@@ -227,3 +235,89 @@ func VerifySignature(c *gin.Context, apiKey *model.MerchantAPIKey) (*CreateOrder
 
 	return req.ToCreateOrderRequest(), nil
 }
+
+// lockAuthorization 锁定待结算的预授权订单
+func lockAuthorization(tx *gorm.DB, query string, args ...interface{}) (*model.Order, error) {
+	var order model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+		Where(query, args...).
+		Where("status = ? AND capture_mode = ?", model.OrderStatusHeld, model.CaptureModeManual).
+		First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(AuthorizationNotFound)
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// settleAuthorization 结算预授权订单
+// 按 captureAmount 从冻结资金中扣款并入账商户，剩余冻结金额退回买家；captureAmount 为 0 时全部退回
+func settleAuthorization(tx *gorm.DB, order *model.Order, captureAmount decimal.Decimal, status model.OrderStatus) error {
+	authorizedAmount := order.Amount
+	if order.AuthorizedAmount != nil {
+		authorizedAmount = *order.AuthorizedAmount
+	}
+
+	updates := map[string]interface{}{"status": status}
+	isTestMode := order.Type == model.OrderTypeTest
+
+	if releaseAmount := authorizedAmount.Sub(captureAmount); releaseAmount.IsPositive() && !isTestMode {
+		if err := service.ReturnHeldBalance(tx, order.PayerUserID, releaseAmount); err != nil {
+			return err
+		}
+	}
+
+	if captureAmount.IsPositive() {
+		updates["amount"] = captureAmount
+		updates["trade_time"] = time.Now()
+
+		if !isTestMode {
+			var merchantUser model.User
+			if err := merchantUser.GetByID(tx, order.PayeeUserID); err != nil {
+				return err
+			}
+			var merchantPayConfig model.UserPayConfig
+			if err := merchantPayConfig.GetByPayScore(tx, merchantUser.PayScore); err != nil {
+				return err
+			}
+
+			_, merchantAmount, feePercent := service.CalculateFee(captureAmount, merchantPayConfig.FeeRate)
+			if err := service.SettleHeldBalance(tx, service.SettleHeldOptions{
+				PayerID:          order.PayerUserID,
+				PayeeID:          merchantUser.ID,
+				Amount:           captureAmount,
+				PayerTotalField:  "total_payment",
+				PayerScoreChange: captureAmount.Round(0).IntPart(),
+				PayeeAmount:      &merchantAmount,
+				PayeeScoreChange: captureAmount.Mul(merchantPayConfig.ScoreRate).Round(0).IntPart(),
+			}); err != nil {
+				return err
+			}
+
+			feeRemark := fmt.Sprintf("[系统]: 收取商家%d%%手续费", feePercent)
+			if order.Remark != "" {
+				feeRemark = order.Remark + " " + feeRemark
+			}
+			updates["remark"] = feeRemark
+		}
+	}
+
+	return tx.Model(order).Updates(updates).Error
+}
+
+// enqueueSettleDelayedTransfer 按到账时间下发可撤回转账的到账任务
+func enqueueSettleDelayedTransfer(order *model.Order) error {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"order_id": order.ID,
+	})
+	if _, err := scheduler.AsynqClient.Enqueue(
+		asynq.NewTask(task.SettleDelayedTransferTask, payload),
+		asynq.TaskID(fmt.Sprintf("payment:settle_delayed_transfer:%d", order.ID)),
+		asynq.ProcessAt(*order.SettlesAt),
+		asynq.MaxRetry(5),
+	); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("下发转账到账任务失败: %w", err)
+	}
+	return nil
+}
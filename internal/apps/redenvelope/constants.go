@@ -0,0 +1,44 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import "time"
+
+const (
+	// redEnvelopeLifetime 红包可领取时长，定时红包从开启时间起算
+	redEnvelopeLifetime = 24 * time.Hour
+	// maxOpenDelay 定时红包开启时间距创建时间的上限
+	maxOpenDelay = 30 * 24 * time.Hour
+	// sharesRetention 预拆分份额在红包过期后的保留时长，供退款前补录领取记录
+	sharesRetention = 24 * time.Hour
+	// persistBatchSize 每批补录领取记录的红包数量
+	persistBatchSize = 100
+)
+
+// Redis key 格式，同一红包的 key 使用 hash tag 保证在集群模式下位于同一 slot
+const (
+	// sharesMetaKeyFormat 红包已预拆分的标记，不存在时回退到数据库行锁领取
+	sharesMetaKeyFormat = "redenvelope:{%d}:meta"
+	// sharesListKeyFormat 预拆分的待领取金额列表
+	sharesListKeyFormat = "redenvelope:{%d}:shares"
+	// claimsHashKeyFormat 已领取记录，field 为用户 ID，value 为 "金额|领取时间戳"
+	claimsHashKeyFormat = "redenvelope:{%d}:claims"
+	// dirtySetKey 有待落库领取记录的红包 ID 集合
+	dirtySetKey = "redenvelope:claims:dirty"
+	// leaderboardCacheKeyPrefix 发红包排行榜缓存
+	leaderboardCacheKeyPrefix = "redenvelope:leaderboard:"
+)
@@ -30,4 +30,16 @@ const (
 	InvalidRedEnvelopeID      = "红包ID格式错误"
 	InvalidCoverImage         = "无效的封面图片"
 	InvalidHeterotypicImage   = "无效的装饰图片"
+	RecipientNotFound         = "领取名单中存在不存在的用户"
+	CannotSendToSelf          = "领取名单不能包含自己"
+	CountExceedsRecipients    = "专属红包个数不能超过领取名单人数"
+	NotInRecipientList        = "这是专属红包，您不在领取名单中"
+	TrustLevelTooLow          = "您的信任等级未达到领取要求"
+	AccountTooNew             = "您的账号注册时间未达到领取要求"
+	PayScoreRequired          = "支付积分为 0 的用户无法领取该红包"
+	PassphraseRequired        = "请输入红包口令"
+	PassphraseIncorrect       = "口令错误"
+	RedEnvelopeNotOpened      = "红包尚未开启，请在开启时间后再来领取"
+	OpensAtMustBeFuture       = "开启时间必须晚于当前时间"
+	OpensAtTooFar             = "开启时间不能晚于30天后"
 )
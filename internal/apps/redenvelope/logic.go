@@ -0,0 +1,220 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redenvelope
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// buildHighlights 计算已领完红包的手气最佳与领完耗时，claims 需按领取时间倒序
+func buildHighlights(redEnvelope *model.RedEnvelope, claims []model.RedEnvelopeClaim) *Highlights {
+	if redEnvelope.Status != model.RedEnvelopeStatusFinished || len(claims) == 0 {
+		return nil
+	}
+
+	startedAt := redEnvelope.CreatedAt
+	if redEnvelope.OpensAt != nil {
+		startedAt = *redEnvelope.OpensAt
+	}
+	highlights := &Highlights{
+		FinishedInSeconds: int64(claims[0].ClaimedAt.Sub(startedAt).Seconds()),
+	}
+	if highlights.FinishedInSeconds < 0 {
+		highlights.FinishedInSeconds = 0
+	}
+
+	// 手气最佳仅对多人拼手气红包有意义，金额相同时先领取者胜出
+	if redEnvelope.Type == model.RedEnvelopeTypeRandom && len(claims) > 1 {
+		luckiest := &claims[0]
+		for i := range claims {
+			if claims[i].Amount.GreaterThan(luckiest.Amount) ||
+				(claims[i].Amount.Equal(luckiest.Amount) && claims[i].ClaimedAt.Before(luckiest.ClaimedAt)) {
+				luckiest = &claims[i]
+			}
+		}
+		highlights.Luckiest = luckiest
+	}
+	return highlights
+}
+
+// getYearRange 返回指定年份的时间范围 [start, end)
+func getYearRange(year int) (time.Time, time.Time) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Now().Location())
+	return start, start.AddDate(1, 0, 0)
+}
+
+// getPeriodRange 返回当前自然日、周、月、年的时间范围 [start, end)
+func getPeriodRange(period string) (time.Time, time.Time) {
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case "week":
+		// 以周一为一周的开始
+		offset := (int(todayStart.Weekday()) + 6) % 7
+		start := todayStart.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	case "month":
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0)
+	case "year":
+		return getYearRange(now.Year())
+	default:
+		return todayStart, todayStart.AddDate(0, 0, 1)
+	}
+}
+
+// getUserStats 统计用户年度红包数据，发出金额按已被领取的金额计算，不含过期退款
+func getUserStats(ctx context.Context, userID uint64, year int) (*StatsResponse, error) {
+	start, end := getYearRange(year)
+	response := &StatsResponse{Year: year}
+
+	if err := db.DB(ctx).Model(&model.RedEnvelope{}).
+		Where("creator_id = ? AND created_at >= ? AND created_at < ?", userID, start, end).
+		Count(&response.SentCount).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.DB(ctx).Model(&model.RedEnvelopeClaim{}).
+		Select("COALESCE(SUM(red_envelope_claims.amount), 0)").
+		Joins("INNER JOIN red_envelopes ON red_envelope_claims.red_envelope_id = red_envelopes.id").
+		Where("red_envelopes.creator_id = ? AND red_envelopes.created_at >= ? AND red_envelopes.created_at < ?", userID, start, end).
+		Scan(&response.SentAmount).Error; err != nil {
+		return nil, err
+	}
+
+	var received struct {
+		Count  int64
+		Amount decimal.Decimal
+	}
+	if err := db.DB(ctx).Model(&model.RedEnvelopeClaim{}).
+		Select("COUNT(*) as count, COALESCE(SUM(amount), 0) as amount").
+		Where("user_id = ? AND claimed_at >= ? AND claimed_at < ?", userID, start, end).
+		Scan(&received).Error; err != nil {
+		return nil, err
+	}
+	response.ReceivedCount = received.Count
+	response.ReceivedAmount = received.Amount
+
+	// 手气最佳次数：用户领取过的已领完多人拼手气红包中，金额最高（相同时最早领取）的次数
+	if err := db.DB(ctx).Raw(`
+		SELECT COUNT(*) FROM (
+			SELECT red_envelope_claims.user_id,
+				ROW_NUMBER() OVER (
+					PARTITION BY red_envelope_claims.red_envelope_id
+					ORDER BY red_envelope_claims.amount DESC, red_envelope_claims.claimed_at ASC
+				) AS rn
+			FROM red_envelope_claims
+			INNER JOIN red_envelopes ON red_envelope_claims.red_envelope_id = red_envelopes.id
+			WHERE red_envelopes.type = ? AND red_envelopes.status = ? AND red_envelopes.total_count > 1
+				AND red_envelopes.id IN (
+					SELECT red_envelope_id FROM red_envelope_claims
+					WHERE user_id = ? AND claimed_at >= ? AND claimed_at < ?
+				)
+		) ranked
+		WHERE ranked.rn = 1 AND ranked.user_id = ?`,
+		model.RedEnvelopeTypeRandom, model.RedEnvelopeStatusFinished, userID, start, end, userID).
+		Scan(&response.LuckiestCount).Error; err != nil {
+		return nil, err
+	}
+
+	var bestLuck []BestLuck
+	if err := db.DB(ctx).Model(&model.RedEnvelopeClaim{}).
+		Select("red_envelope_claims.red_envelope_id, red_envelope_claims.amount, red_envelope_claims.claimed_at, "+
+			"red_envelopes.creator_id, users.username as creator_username").
+		Joins("INNER JOIN red_envelopes ON red_envelope_claims.red_envelope_id = red_envelopes.id").
+		Joins("LEFT JOIN users ON red_envelopes.creator_id = users.id").
+		Where("red_envelope_claims.user_id = ? AND red_envelope_claims.claimed_at >= ? AND red_envelope_claims.claimed_at < ?", userID, start, end).
+		Order("red_envelope_claims.amount DESC, red_envelope_claims.claimed_at ASC").
+		Limit(1).
+		Scan(&bestLuck).Error; err != nil {
+		return nil, err
+	}
+	if len(bestLuck) > 0 {
+		response.BestLuck = &bestLuck[0]
+	}
+
+	return response, nil
+}
+
+// getSenderLeaderboard 统计周期内发出红包被领取金额最多的用户，结果短暂缓存
+func getSenderLeaderboard(ctx context.Context, req *LeaderboardRequest) (*LeaderboardResponse, error) {
+	start, end := getPeriodRange(req.Period)
+
+	cacheKey := fmt.Sprintf("%s%s:%d:p:%d:s:%d", leaderboardCacheKeyPrefix, req.Period, start.Unix(), req.Page, req.PageSize)
+	if data, err := db.Redis.Get(ctx, db.PrefixedKey(cacheKey)).Bytes(); err == nil {
+		var cached LeaderboardResponse
+		if err := json.Unmarshal(data, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	baseQuery := db.DB(ctx).Model(&model.RedEnvelopeClaim{}).
+		Joins("INNER JOIN red_envelopes ON red_envelope_claims.red_envelope_id = red_envelopes.id").
+		Where("red_envelopes.created_at >= ? AND red_envelopes.created_at < ?", start, end)
+
+	var total int64
+	if err := baseQuery.Session(&gorm.Session{}).
+		Distinct("red_envelopes.creator_id").
+		Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]SenderEntry, 0, req.PageSize)
+	if err := baseQuery.Session(&gorm.Session{}).
+		Select("red_envelopes.creator_id as user_id, users.username, users.avatar_url, " +
+			"SUM(red_envelope_claims.amount) as sent_amount, COUNT(DISTINCT red_envelopes.id) as sent_count").
+		Joins("LEFT JOIN users ON red_envelopes.creator_id = users.id").
+		Group("red_envelopes.creator_id, users.username, users.avatar_url").
+		Order("sent_amount DESC, red_envelopes.creator_id ASC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Scan(&items).Error; err != nil {
+		return nil, err
+	}
+
+	response := &LeaderboardResponse{
+		Period:    req.Period,
+		StartTime: start,
+		EndTime:   end,
+		Page:      req.Page,
+		PageSize:  req.PageSize,
+		Total:     total,
+		Items:     items,
+	}
+
+	if data, err := json.Marshal(response); err == nil {
+		_ = db.Redis.Set(ctx, db.PrefixedKey(cacheKey), data, getLeaderboardCacheTTL(ctx)).Err()
+	}
+	return response, nil
+}
+
+// getLeaderboardCacheTTL 复用排行榜缓存过期时间配置
+func getLeaderboardCacheTTL(ctx context.Context) time.Duration {
+	ttl, err := model.GetIntByKey(ctx, model.ConfigKeyLeaderboardCacheTTLSeconds)
+	if err != nil || ttl <= 0 {
+		ttl = 30
+	}
+	return time.Duration(ttl) * time.Second
+}
@@ -19,8 +19,11 @@ package redenvelope
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -28,6 +31,7 @@ import (
 	"github.com/linux-do/credit/internal/common"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/linux-do/credit/internal/logger"
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/service"
 	"github.com/linux-do/credit/internal/util"
@@ -43,8 +47,19 @@ type CreateRequest struct {
 	TotalCount          int                   `json:"total_count" binding:"required,min=1"`
 	Greeting            string                `json:"greeting" binding:"max=100"`
 	PayKey              string                `json:"pay_key" binding:"required,max=10"`
+	TOTPCode            string                `json:"totp_code" binding:"max=16"`
 	CoverUploadID       *uint64               `json:"cover_upload_id,string" binding:"omitempty"`
 	HeterotypicUploadID *uint64               `json:"heterotypic_upload_id,string" binding:"omitempty"`
+	// RecipientIDs、RecipientUsernames 专属红包的领取名单，填写任一项即为专属红包，仅一人时可作为礼物赠送
+	RecipientIDs       []uint64 `json:"recipient_ids" binding:"omitempty,dive,required"`
+	RecipientUsernames []string `json:"recipient_usernames" binding:"omitempty,dive,required,max=64"`
+	// 领取条件：最低信任等级、最短注册天数、排除支付积分为 0 的用户、口令
+	MinTrustLevel     model.TrustLevel `json:"min_trust_level" binding:"max=4"`
+	MinAccountAgeDays int              `json:"min_account_age_days" binding:"min=0,max=3650"`
+	RequirePayScore   bool             `json:"require_pay_score"`
+	Passphrase        string           `json:"passphrase" binding:"max=32"`
+	// OpensAt 定时开启时间，创建时即扣款，开启前不可领取
+	OpensAt *time.Time `json:"opens_at"`
 }
 
 // CreateResponse 创建红包响应
@@ -55,6 +70,8 @@ type CreateResponse struct {
 // ClaimRequest 领取红包请求
 type ClaimRequest struct {
 	ID uint64 `json:"id,string" binding:"required"`
+	// Passphrase 口令红包需提交的口令
+	Passphrase string `json:"passphrase" binding:"max=32"`
 }
 
 // ClaimResponse 领取红包响应
@@ -68,13 +85,29 @@ type DetailResponse struct {
 	RedEnvelope model.RedEnvelope        `json:"red_envelope"`
 	Claims      []model.RedEnvelopeClaim `json:"claims"`
 	UserClaimed *model.RedEnvelopeClaim  `json:"user_claimed,omitempty"`
+	// OpensInSeconds 距离定时红包开启的剩余秒数，已开启时为 0
+	OpensInSeconds int64 `json:"opens_in_seconds"`
+	// IsRecipient 当前用户是否在专属红包的领取名单中
+	IsRecipient bool `json:"is_recipient"`
+	// Recipients 专属红包的领取名单，仅发送者可见
+	Recipients []model.RedEnvelopeRecipient `json:"recipients,omitempty"`
+	// Highlights 红包领完后的手气最佳与领完耗时
+	Highlights *Highlights `json:"highlights,omitempty"`
+}
+
+// Highlights 红包亮点
+type Highlights struct {
+	// Luckiest 手气最佳，仅多人拼手气红包返回
+	Luckiest *model.RedEnvelopeClaim `json:"luckiest,omitempty"`
+	// FinishedInSeconds 从开启到被领完的耗时（秒）
+	FinishedInSeconds int64 `json:"finished_in_seconds"`
 }
 
 // ListRequest 红包列表请求
 type ListRequest struct {
 	Page     int    `json:"page" binding:"required,min=1"`
 	PageSize int    `json:"page_size" binding:"required,min=1,max=100"`
-	Type     string `json:"type" binding:"omitempty,oneof=sent received"`
+	Type     string `json:"type" binding:"omitempty,oneof=sent received gifts"`
 }
 
 // ListResponse 红包列表响应
@@ -85,6 +118,62 @@ type ListResponse struct {
 	RedEnvelopes []model.RedEnvelope `json:"red_envelopes"`
 }
 
+// StatsRequest 年度红包统计请求
+type StatsRequest struct {
+	Year int `form:"year" binding:"omitempty,min=2000,max=9999"`
+}
+
+// StatsResponse 年度红包统计响应
+type StatsResponse struct {
+	Year int `json:"year"`
+	// SentCount 发出的红包个数，SentAmount 发出红包中被领取的金额，不含过期退款
+	SentCount  int64           `json:"sent_count"`
+	SentAmount decimal.Decimal `json:"sent_amount"`
+	// ReceivedCount 领取的红包个数，ReceivedAmount 领取的总金额
+	ReceivedCount  int64           `json:"received_count"`
+	ReceivedAmount decimal.Decimal `json:"received_amount"`
+	// LuckiestCount 获得手气最佳的次数
+	LuckiestCount int64 `json:"luckiest_count"`
+	// BestLuck 单次领取金额最高的记录
+	BestLuck *BestLuck `json:"best_luck,omitempty"`
+}
+
+// BestLuck 单次领取金额最高的记录
+type BestLuck struct {
+	RedEnvelopeID   uint64          `json:"red_envelope_id,string"`
+	CreatorID       uint64          `json:"creator_id,string"`
+	CreatorUsername string          `json:"creator_username"`
+	Amount          decimal.Decimal `json:"amount"`
+	ClaimedAt       time.Time       `json:"claimed_at"`
+}
+
+// LeaderboardRequest 发红包排行榜请求
+type LeaderboardRequest struct {
+	Period   string `form:"period" binding:"required,oneof=day week month year"`
+	Page     int    `form:"page" binding:"required,min=1"`
+	PageSize int    `form:"page_size" binding:"required,min=1,max=50"`
+}
+
+// SenderEntry 发红包排行榜条目（rank 由前端根据 offset + index + 1 计算）
+type SenderEntry struct {
+	UserID     uint64          `json:"user_id,string"`
+	Username   string          `json:"username"`
+	AvatarURL  string          `json:"avatar_url"`
+	SentAmount decimal.Decimal `json:"sent_amount"`
+	SentCount  int64           `json:"sent_count"`
+}
+
+// LeaderboardResponse 发红包排行榜响应
+type LeaderboardResponse struct {
+	Period    string        `json:"period"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Page      int           `json:"page"`
+	PageSize  int           `json:"page_size"`
+	Total     int64         `json:"total"`
+	Items     []SenderEntry `json:"items"`
+}
+
 // Create 创建红包
 // @Tags redenvelope
 // @Accept json
@@ -139,8 +228,50 @@ func Create(c *gin.Context) {
 		return
 	}
 
+	// 定时红包：过期时间从开启时间起算
+	opensAt := time.Now()
+	if req.OpensAt != nil {
+		opensAt = req.OpensAt.Truncate(time.Second)
+		if !opensAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, util.Err(OpensAtMustBeFuture))
+			return
+		}
+		if opensAt.After(time.Now().Add(maxOpenDelay)) {
+			c.JSON(http.StatusBadRequest, util.Err(OpensAtTooFar))
+			return
+		}
+		req.OpensAt = &opensAt
+	}
+
 	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
 
+	// 专属红包：解析领取名单
+	exclusive := len(req.RecipientIDs) > 0 || len(req.RecipientUsernames) > 0
+	var recipientIDs []uint64
+	if exclusive {
+		recipientIDs, err = resolveRecipients(db.DB(c.Request.Context()), req.RecipientIDs, req.RecipientUsernames)
+		if err != nil {
+			if err.Error() == RecipientNotFound {
+				c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			} else {
+				c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			}
+			return
+		}
+		if slices.Contains(recipientIDs, currentUser.ID) {
+			c.JSON(http.StatusBadRequest, util.Err(CannotSendToSelf))
+			return
+		}
+		if len(recipientIDs) > maxRecipients {
+			c.JSON(http.StatusBadRequest, util.Err(common.RedEnvelopeRecipientsExceeded))
+			return
+		}
+		if req.TotalCount > len(recipientIDs) {
+			c.JSON(http.StatusBadRequest, util.Err(CountExceedsRecipients))
+			return
+		}
+	}
+
 	// 检查每日红包发送数量限制
 	dailyLimit, err := model.GetIntByKey(c.Request.Context(), model.ConfigKeyRedEnvelopeDailyLimit)
 	if err != nil {
@@ -163,8 +294,8 @@ func Create(c *gin.Context) {
 		return
 	}
 
-	if !currentUser.VerifyPayKey(req.PayKey) {
-		c.JSON(http.StatusBadRequest, util.Err(common.PayKeyIncorrect))
+	if err := service.VerifyPaymentAuth(c.Request.Context(), currentUser, req.PayKey, req.TOTPCode, req.TotalAmount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		return
 	}
 
@@ -238,6 +369,10 @@ func Create(c *gin.Context) {
 			return err
 		}
 
+		if err := service.CheckPayKeyResetCooling(tx, currentUser, totalDeduction); err != nil {
+			return err
+		}
+
 		// 创建红包
 		redEnvelope = model.RedEnvelope{
 			ID:                  idgen.NextUint64ID(),
@@ -248,16 +383,35 @@ func Create(c *gin.Context) {
 			TotalCount:          req.TotalCount,
 			RemainingCount:      req.TotalCount,
 			Greeting:            req.Greeting,
+			Exclusive:           exclusive,
+			MinTrustLevel:       req.MinTrustLevel,
+			MinAccountAgeDays:   req.MinAccountAgeDays,
+			RequirePayScore:     req.RequirePayScore,
+			Passphrase:          strings.TrimSpace(req.Passphrase),
 			Status:              model.RedEnvelopeStatusActive,
 			CoverUploadID:       coverUploadID,
 			HeterotypicUploadID: heterotypicUploadID,
-			ExpiresAt:           time.Now().Add(24 * time.Hour),
+			OpensAt:             req.OpensAt,
+			ExpiresAt:           opensAt.Add(redEnvelopeLifetime),
 		}
 
 		if err := tx.Create(&redEnvelope).Error; err != nil {
 			return err
 		}
 
+		if exclusive {
+			recipients := make([]model.RedEnvelopeRecipient, 0, len(recipientIDs))
+			for _, userID := range recipientIDs {
+				recipients = append(recipients, model.RedEnvelopeRecipient{
+					RedEnvelopeID: redEnvelope.ID,
+					UserID:        userID,
+				})
+			}
+			if err := tx.Create(&recipients).Error; err != nil {
+				return err
+			}
+		}
+
 		// 创建订单记录（红包支出）
 		remarkMsg := fmt.Sprintf("创建红包，共%d个", req.TotalCount)
 		if feeAmount.GreaterThan(decimal.Zero) {
@@ -281,8 +435,8 @@ func Create(c *gin.Context) {
 
 		return tx.Create(&order).Error
 	}); err != nil {
-		if err.Error() == common.InsufficientBalance {
-			c.JSON(http.StatusBadRequest, util.Err(common.InsufficientBalance))
+		if err.Error() == common.InsufficientBalance || err.Error() == common.PayKeyResetCoolingLimited {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		} else if err.Error() == InvalidCoverImage || err.Error() == InvalidHeterotypicImage {
 			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
 		} else {
@@ -291,12 +445,18 @@ func Create(c *gin.Context) {
 		return
 	}
 
+	// 预拆分失败时领取回退到数据库行锁
+	if err := presplitShares(c.Request.Context(), &redEnvelope); err != nil {
+		logger.ErrorF(c.Request.Context(), "红包ID:%d 预拆分金额失败: %v", redEnvelope.ID, err)
+	}
+
 	c.JSON(http.StatusOK, util.OK(CreateResponse{
 		ID: redEnvelope.ID,
 	}))
 }
 
 // Claim 领取红包
+// 已预拆分的红包通过 Redis 脚本原子弹出一份金额，领取记录异步批量落库；未预拆分的红包使用数据库行锁领取
 // @Tags redenvelope
 // @Accept json
 // @Produce json
@@ -311,35 +471,107 @@ func Claim(c *gin.Context) {
 	}
 
 	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
 
 	var claimedAmount decimal.Decimal
-	var redEnvelope model.RedEnvelope
+	var remainingCount *int
 
-	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
-		// 使用 FOR UPDATE 锁定红包记录，防止并发领取
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
-			Where("id = ?", req.ID).First(&redEnvelope).Error; err != nil {
+	err := func() error {
+		var redEnvelope model.RedEnvelope
+		if err := db.DB(ctx).Where("id = ?", req.ID).First(&redEnvelope).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return errors.New(RedEnvelopeNotFound)
 			}
-			// 捕获锁等待超时错误，返回友好提示
-			return errors.New(RedEnvelopeTooPopular)
+			return err
 		}
 
-		// 检查红包状态
-		if redEnvelope.Status == model.RedEnvelopeStatusExpired || redEnvelope.ExpiresAt.Before(time.Now()) {
-			return errors.New(RedEnvelopeExpired)
+		if err := checkClaimable(db.DB(ctx), &redEnvelope, currentUser, req.Passphrase); err != nil {
+			return err
 		}
 
-		if redEnvelope.Status == model.RedEnvelopeStatusFinished || redEnvelope.RemainingCount <= 0 {
-			return errors.New(RedEnvelopeFinished)
+		status, amount, remaining, err := claimShare(ctx, redEnvelope.ID, currentUser.ID, time.Now())
+		if err != nil {
+			return err
 		}
 
-		// 检查是否已领取
-		var existingClaim model.RedEnvelopeClaim
-		if err := tx.Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, currentUser.ID).
-			First(&existingClaim).Error; err == nil {
+		switch status {
+		case claimShareOK:
+			claimedAmount = amount
+			remainingCount = &remaining
+			if err := markClaimsDirty(ctx, redEnvelope.ID); err != nil {
+				// 落库任务下发失败由对账任务兜底
+				logger.ErrorF(ctx, "红包ID:%d 下发领取落库任务失败: %v", redEnvelope.ID, err)
+			}
+			return nil
+		case claimShareClaimed:
 			return errors.New(RedEnvelopeAlreadyClaimed)
+		case claimShareFinished:
+			return errors.New(RedEnvelopeFinished)
+		default:
+			// 未预拆分或预拆分标记丢失：先补录 Redis 中尚未落库的领取记录，使数据库中的剩余数量与去重准确
+			if _, err := persistClaims(ctx, redEnvelope.ID); err != nil {
+				logger.ErrorF(ctx, "红包ID:%d 回退领取前补录领取记录失败: %v", redEnvelope.ID, err)
+				return errors.New(RedEnvelopeTooPopular)
+			}
+			claimedAmount, err = claimWithLock(db.DB(ctx), req, currentUser)
+			return err
+		}
+	}()
+	if err != nil {
+		errMsg := err.Error()
+		switch errMsg {
+		case RedEnvelopeNotFound:
+			c.JSON(http.StatusNotFound, util.Err(errMsg))
+		case RedEnvelopeExpired, RedEnvelopeFinished, RedEnvelopeAlreadyClaimed, CannotClaimOwnRedEnvelope, NotInRecipientList,
+			TrustLevelTooLow, AccountTooNew, PayScoreRequired, PassphraseRequired, PassphraseIncorrect, RedEnvelopeNotOpened:
+			c.JSON(http.StatusBadRequest, util.Err(errMsg))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(errMsg))
+		}
+		return
+	}
+
+	var redEnvelopeView model.RedEnvelope
+	if err := db.DB(ctx).
+		Model(&model.RedEnvelope{}).
+		Select("red_envelopes.*, users.username as creator_username, users.avatar_url as creator_avatar_url").
+		Joins("LEFT JOIN users ON red_envelopes.creator_id = users.id").
+		Where("red_envelopes.id = ?", req.ID).First(&redEnvelopeView).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	// 异步落库时数据库中的剩余数量尚未扣减，以 Redis 中的剩余份数为准
+	if remainingCount != nil {
+		redEnvelopeView.RemainingCount = *remainingCount
+		if *remainingCount == 0 {
+			redEnvelopeView.Status = model.RedEnvelopeStatusFinished
+		}
+	}
+
+	c.JSON(http.StatusOK, util.OK(ClaimResponse{
+		Amount:      claimedAmount,
+		RedEnvelope: redEnvelopeView,
+	}))
+}
+
+// claimWithLock 锁定红包记录后在事务内计算金额并完成领取，用于未预拆分的红包
+func claimWithLock(tx *gorm.DB, req ClaimRequest, currentUser *model.User) (decimal.Decimal, error) {
+	var claimedAmount decimal.Decimal
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		// 使用 FOR UPDATE 锁定红包记录，防止并发领取
+		var redEnvelope model.RedEnvelope
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+			Where("id = ?", req.ID).First(&redEnvelope).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(RedEnvelopeNotFound)
+			}
+			// 捕获锁等待超时错误，返回友好提示
+			return errors.New(RedEnvelopeTooPopular)
+		}
+
+		if err := checkClaimable(tx, &redEnvelope, currentUser, req.Passphrase); err != nil {
+			return err
 		}
 
 		// 计算领取金额
@@ -383,11 +615,6 @@ func Claim(c *gin.Context) {
 			return err
 		}
 
-		// 更新红包对象用于返回
-		redEnvelope.RemainingCount = newRemainingCount
-		redEnvelope.RemainingAmount = newRemainingAmount
-		redEnvelope.Status = newStatus
-
 		// 增加领取者余额并更新total_receive
 		if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
 			UserID:     currentUser.ID,
@@ -412,33 +639,8 @@ func Claim(c *gin.Context) {
 		}
 
 		return tx.Create(&order).Error
-	}); err != nil {
-		errMsg := err.Error()
-		switch errMsg {
-		case RedEnvelopeNotFound:
-			c.JSON(http.StatusNotFound, util.Err(errMsg))
-		case RedEnvelopeExpired, RedEnvelopeFinished, RedEnvelopeAlreadyClaimed, CannotClaimOwnRedEnvelope:
-			c.JSON(http.StatusBadRequest, util.Err(errMsg))
-		default:
-			c.JSON(http.StatusInternalServerError, util.Err(errMsg))
-		}
-		return
-	}
-
-	var redEnvelopeView model.RedEnvelope
-	if err := db.DB(c.Request.Context()).
-		Model(&model.RedEnvelope{}).
-		Select("red_envelopes.*, users.username as creator_username, users.avatar_url as creator_avatar_url").
-		Joins("LEFT JOIN users ON red_envelopes.creator_id = users.id").
-		Where("red_envelopes.id = ?", redEnvelope.ID).First(&redEnvelopeView).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
-		return
-	}
-
-	c.JSON(http.StatusOK, util.OK(ClaimResponse{
-		Amount:      claimedAmount,
-		RedEnvelope: redEnvelopeView,
-	}))
+	})
+	return claimedAmount, err
 }
 
 // GetDetail 获取红包详情
@@ -487,13 +689,58 @@ func GetDetail(c *gin.Context) {
 				break
 			}
 		}
+
+		// 领取记录异步落库，刚领取时从 Redis 读取
+		if userClaimed == nil {
+			pendingClaim, err := getPendingClaim(c.Request.Context(), redEnvelope.ID, currentUser.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+				return
+			}
+			if pendingClaim != nil {
+				pendingClaim.Username = currentUser.Username
+				pendingClaim.AvatarURL = currentUser.AvatarUrl
+				userClaimed = pendingClaim
+			}
+		}
 	}
 
-	c.JSON(http.StatusOK, util.OK(DetailResponse{
+	response := DetailResponse{
 		RedEnvelope: redEnvelope,
 		Claims:      claims,
 		UserClaimed: userClaimed,
-	}))
+		Highlights:  buildHighlights(&redEnvelope, claims),
+	}
+	if redEnvelope.OpensAt != nil {
+		if remaining := time.Until(*redEnvelope.OpensAt); remaining > 0 {
+			response.OpensInSeconds = int64(math.Ceil(remaining.Seconds()))
+		}
+	}
+
+	if redEnvelope.Exclusive && currentUser != nil {
+		if redEnvelope.CreatorID == currentUser.ID {
+			if err := db.DB(c.Request.Context()).
+				Select("red_envelope_recipients.*, users.username, users.avatar_url").
+				Joins("LEFT JOIN users ON red_envelope_recipients.user_id = users.id").
+				Where("red_envelope_recipients.red_envelope_id = ?", redEnvelope.ID).
+				Order("users.username ASC").
+				Find(&response.Recipients).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+				return
+			}
+		} else {
+			var recipientCount int64
+			if err := db.DB(c.Request.Context()).Model(&model.RedEnvelopeRecipient{}).
+				Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, currentUser.ID).
+				Count(&recipientCount).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+				return
+			}
+			response.IsRecipient = recipientCount > 0
+		}
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
 }
 
 // List 获取红包列表
@@ -521,6 +768,10 @@ func List(c *gin.Context) {
 	case "received":
 		query = query.Joins("INNER JOIN red_envelope_claims ON red_envelopes.id = red_envelope_claims.red_envelope_id").
 			Where("red_envelope_claims.user_id = ?", currentUser.ID)
+	case "gifts":
+		// 收到的专属红包，无论是否已领取
+		query = query.Joins("INNER JOIN red_envelope_recipients ON red_envelopes.id = red_envelope_recipients.red_envelope_id").
+			Where("red_envelope_recipients.user_id = ?", currentUser.ID)
 	default:
 		query = query.Where("red_envelopes.creator_id = ?", currentUser.ID)
 	}
@@ -541,3 +792,54 @@ func List(c *gin.Context) {
 		RedEnvelopes: redEnvelopes,
 	}))
 }
+
+// GetStats 获取当前用户的年度红包统计
+// @Tags redenvelope
+// @Produce json
+// @Param year query int false "年份，默认今年"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/stats [get]
+func GetStats(c *gin.Context) {
+	var req StatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	if req.Year == 0 {
+		req.Year = time.Now().Year()
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	response, err := getUserStats(c.Request.Context(), currentUser.ID, req.Year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
+}
+
+// GetLeaderboard 获取发红包排行榜
+// @Tags redenvelope
+// @Produce json
+// @Param period query string true "统计周期：day、week、month、year"
+// @Param page query int true "页码"
+// @Param page_size query int true "每页数量"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/redenvelope/leaderboard [get]
+func GetLeaderboard(c *gin.Context) {
+	var req LeaderboardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	response, err := getSenderLeaderboard(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
+}
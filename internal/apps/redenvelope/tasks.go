@@ -18,7 +18,9 @@ package redenvelope
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -27,6 +29,7 @@ import (
 	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/service"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // HandleRefundExpiredRedEnvelopes 处理过期红包退款的定时任务
@@ -64,7 +67,28 @@ func refundExpiredRedEnvelopes(ctx context.Context) {
 
 		// 处理每个过期红包
 		for _, envelope := range expiredEnvelopes {
+			// 先删除剩余的预拆分金额阻止继续领取，再补录已领取的记录，剩余金额以补录后为准
+			if err := drainShares(ctx, envelope.ID); err != nil {
+				logger.ErrorF(ctx, "红包ID:%d 清理预拆分金额失败: %v", envelope.ID, err)
+				lastID = envelope.ID
+				continue
+			}
+			if _, err := persistClaims(ctx, envelope.ID); err != nil {
+				logger.ErrorF(ctx, "红包ID:%d 补录领取记录失败: %v", envelope.ID, err)
+				lastID = envelope.ID
+				continue
+			}
+
 			if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+					Where("id = ? AND status = ?", envelope.ID, model.RedEnvelopeStatusActive).
+					First(&envelope).Error; err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return nil
+					}
+					return err
+				}
+
 				// 更新红包状态为已过期
 				if err := tx.Model(&model.RedEnvelope{}).
 					Where("id = ? AND status = ?", envelope.ID, model.RedEnvelopeStatusActive).
@@ -118,6 +142,10 @@ func refundExpiredRedEnvelopes(ctx context.Context) {
 				logger.ErrorF(ctx, "红包ID:%d 退款失败: %v", envelope.ID, err)
 			} else {
 				totalProcessed++
+				// 领取记录均已落库，清理 Redis 中的领取记录
+				if err := db.Redis.Del(ctx, sharesKeys(envelope.ID)[2]).Err(); err != nil {
+					logger.ErrorF(ctx, "红包ID:%d 清理领取记录缓存失败: %v", envelope.ID, err)
+				}
 			}
 
 			// 更新游标
@@ -131,3 +159,80 @@ func refundExpiredRedEnvelopes(ctx context.Context) {
 		logger.InfoF(ctx, "没有需要退款的过期红包")
 	}
 }
+
+// HandlePersistRedEnvelopeClaims 将 Redis 中新增的红包领取记录批量落库
+func HandlePersistRedEnvelopeClaims(ctx context.Context, t *asynq.Task) error {
+	dirtyKey := db.PrefixedKey(dirtySetKey)
+	var failedIDs []interface{}
+
+	for {
+		members, err := db.Redis.SPopN(ctx, dirtyKey, persistBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			break
+		}
+
+		for _, member := range members {
+			redEnvelopeID, err := strconv.ParseUint(member, 10, 64)
+			if err != nil {
+				continue
+			}
+			persisted, err := persistClaims(ctx, redEnvelopeID)
+			if err != nil {
+				logger.ErrorF(ctx, "红包ID:%d 领取记录落库失败: %v", redEnvelopeID, err)
+				failedIDs = append(failedIDs, redEnvelopeID)
+				continue
+			}
+			if persisted > 0 {
+				logger.InfoF(ctx, "红包ID:%d 落库领取记录 %d 条", redEnvelopeID, persisted)
+			}
+		}
+	}
+
+	// 落库失败的红包放回集合，由任务重试或对账任务处理
+	if len(failedIDs) > 0 {
+		if err := db.Redis.SAdd(ctx, dirtyKey, failedIDs...).Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("%d 个红包的领取记录落库失败", len(failedIDs))
+	}
+	return nil
+}
+
+// HandleReconcileRedEnvelopeClaims 对账进行中的红包，补录落库任务遗漏的领取记录
+func HandleReconcileRedEnvelopeClaims(ctx context.Context, t *asynq.Task) error {
+	var lastID uint64
+	total := 0
+
+	for {
+		var redEnvelopeIDs []uint64
+		if err := db.DB(ctx).Model(&model.RedEnvelope{}).
+			Where("id > ? AND status = ?", lastID, model.RedEnvelopeStatusActive).
+			Order("id ASC").
+			Limit(persistBatchSize).
+			Pluck("id", &redEnvelopeIDs).Error; err != nil {
+			logger.ErrorF(ctx, "查询进行中的红包失败: %v", err)
+			return err
+		}
+		if len(redEnvelopeIDs) == 0 {
+			break
+		}
+
+		for _, redEnvelopeID := range redEnvelopeIDs {
+			persisted, err := persistClaims(ctx, redEnvelopeID)
+			if err != nil {
+				logger.ErrorF(ctx, "红包ID:%d 领取记录对账失败: %v", redEnvelopeID, err)
+				continue
+			}
+			total += persisted
+		}
+		lastID = redEnvelopeIDs[len(redEnvelopeIDs)-1]
+	}
+
+	if total > 0 {
+		logger.InfoF(ctx, "红包领取对账完成，补录领取记录 %d 条", total)
+	}
+	return nil
+}
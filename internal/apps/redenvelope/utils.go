@@ -17,9 +17,25 @@ limitations under the License.
 package redenvelope
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math/rand"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hibiken/asynq"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/task"
+	"github.com/linux-do/credit/internal/task/scheduler"
+	"github.com/redis/go-redis/v9"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // calculateRandomAmount 二倍均值算法计算随机红包金额
@@ -71,3 +87,404 @@ func calculateRandomAmount(remaining decimal.Decimal, count int) decimal.Decimal
 
 	return amount.Round(2)
 }
+
+// resolveRecipients 将专属红包领取名单中的用户 ID 与用户名解析为去重后的用户 ID 列表
+func resolveRecipients(tx *gorm.DB, ids []uint64, usernames []string) ([]uint64, error) {
+	var users []model.User
+	query := tx.Model(&model.User{}).Select("id, username")
+	switch {
+	case len(ids) > 0 && len(usernames) > 0:
+		query = query.Where("id IN ? OR username IN ?", ids, usernames)
+	case len(ids) > 0:
+		query = query.Where("id IN ?", ids)
+	default:
+		query = query.Where("username IN ?", usernames)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	foundIDs := make(map[uint64]bool, len(users))
+	foundUsernames := make(map[string]bool, len(users))
+	for _, user := range users {
+		foundIDs[user.ID] = true
+		foundUsernames[user.Username] = true
+	}
+
+	for _, id := range ids {
+		if !foundIDs[id] {
+			return nil, errors.New(RecipientNotFound)
+		}
+	}
+	for _, username := range usernames {
+		if !foundUsernames[username] {
+			return nil, errors.New(RecipientNotFound)
+		}
+	}
+
+	recipientIDs := make([]uint64, 0, len(users))
+	for _, user := range users {
+		recipientIDs = append(recipientIDs, user.ID)
+	}
+	return recipientIDs, nil
+}
+
+// checkClaimable 校验红包当前是否可被该用户领取：开启时间、状态、专属名单、领取条件以及是否已领取（含尚未落库的领取记录）
+func checkClaimable(tx *gorm.DB, redEnvelope *model.RedEnvelope, user *model.User, passphrase string) error {
+	if redEnvelope.OpensAt != nil && redEnvelope.OpensAt.After(time.Now()) {
+		return errors.New(RedEnvelopeNotOpened)
+	}
+
+	// 检查红包状态
+	if redEnvelope.Status == model.RedEnvelopeStatusExpired || redEnvelope.ExpiresAt.Before(time.Now()) {
+		return errors.New(RedEnvelopeExpired)
+	}
+
+	if redEnvelope.Status == model.RedEnvelopeStatusFinished || redEnvelope.RemainingCount <= 0 {
+		return errors.New(RedEnvelopeFinished)
+	}
+
+	// 专属红包仅领取名单内的用户可领取
+	if redEnvelope.Exclusive {
+		var recipientCount int64
+		if err := tx.Model(&model.RedEnvelopeRecipient{}).
+			Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, user.ID).
+			Count(&recipientCount).Error; err != nil {
+			return err
+		}
+		if recipientCount == 0 {
+			return errors.New(NotInRecipientList)
+		}
+	}
+
+	if err := checkClaimConditions(redEnvelope, user, passphrase); err != nil {
+		return err
+	}
+
+	// 检查是否已领取
+	var claimedCount int64
+	if err := tx.Model(&model.RedEnvelopeClaim{}).
+		Where("red_envelope_id = ? AND user_id = ?", redEnvelope.ID, user.ID).
+		Count(&claimedCount).Error; err != nil {
+		return err
+	}
+	if claimedCount > 0 {
+		return errors.New(RedEnvelopeAlreadyClaimed)
+	}
+
+	// 领取记录异步落库，还需检查 Redis 中尚未落库的领取记录
+	pendingClaim, err := getPendingClaim(tx.Statement.Context, redEnvelope.ID, user.ID)
+	if err != nil {
+		return err
+	}
+	if pendingClaim != nil {
+		return errors.New(RedEnvelopeAlreadyClaimed)
+	}
+	return nil
+}
+
+// checkClaimConditions 校验领取人是否满足红包的领取条件
+func checkClaimConditions(redEnvelope *model.RedEnvelope, user *model.User, passphrase string) error {
+	if user.TrustLevel < redEnvelope.MinTrustLevel {
+		return errors.New(TrustLevelTooLow)
+	}
+
+	if redEnvelope.MinAccountAgeDays > 0 &&
+		time.Since(user.CreatedAt) < time.Duration(redEnvelope.MinAccountAgeDays)*24*time.Hour {
+		return errors.New(AccountTooNew)
+	}
+
+	if redEnvelope.RequirePayScore && user.PayScore <= 0 {
+		return errors.New(PayScoreRequired)
+	}
+
+	if redEnvelope.Passphrase != "" {
+		passphrase = strings.TrimSpace(passphrase)
+		if passphrase == "" {
+			return errors.New(PassphraseRequired)
+		}
+		if passphrase != redEnvelope.Passphrase {
+			return errors.New(PassphraseIncorrect)
+		}
+	}
+	return nil
+}
+
+// claimShareScript 原子地为用户弹出一份预拆分金额并记录领取
+// 返回 {状态, 金额, 剩余份数}，状态：1 成功，-1 未预拆分，-2 已领取，-3 已领完
+var claimShareScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return {-1, '', 0}
+end
+if redis.call('HEXISTS', KEYS[3], ARGV[1]) == 1 then
+	return {-2, '', 0}
+end
+local amount = redis.call('LPOP', KEYS[2])
+if not amount then
+	return {-3, '', 0}
+end
+redis.call('HSET', KEYS[3], ARGV[1], amount .. '|' .. ARGV[2])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[3], ttl)
+end
+return {1, amount, redis.call('LLEN', KEYS[2])}
+`)
+
+// claimShare 脚本返回状态
+const (
+	claimShareOK          = 1
+	claimShareNotPresplit = -1
+	claimShareClaimed     = -2
+	claimShareFinished    = -3
+)
+
+// sharesKeys 返回红包预拆分相关的 Redis key：标记、金额列表、领取记录
+func sharesKeys(redEnvelopeID uint64) []string {
+	return []string{
+		db.PrefixedKey(fmt.Sprintf(sharesMetaKeyFormat, redEnvelopeID)),
+		db.PrefixedKey(fmt.Sprintf(sharesListKeyFormat, redEnvelopeID)),
+		db.PrefixedKey(fmt.Sprintf(claimsHashKeyFormat, redEnvelopeID)),
+	}
+}
+
+// splitShares 按红包类型预先拆分全部金额
+func splitShares(redEnvelope *model.RedEnvelope) []decimal.Decimal {
+	shares := make([]decimal.Decimal, 0, redEnvelope.TotalCount)
+	remaining := redEnvelope.TotalAmount
+	for count := redEnvelope.TotalCount; count > 0; count-- {
+		var amount decimal.Decimal
+		if redEnvelope.Type == model.RedEnvelopeTypeFixed {
+			// 固定金额：最后一个给全部剩余金额（避免舍入误差）
+			if count == 1 {
+				amount = remaining
+			} else {
+				amount = redEnvelope.TotalAmount.Div(decimal.NewFromInt(int64(redEnvelope.TotalCount))).Round(2)
+			}
+		} else {
+			amount = calculateRandomAmount(remaining, count)
+		}
+		shares = append(shares, amount)
+		remaining = remaining.Sub(amount)
+	}
+	return shares
+}
+
+// presplitShares 创建红包后将预拆分金额写入 Redis，领取时由脚本逐份弹出
+func presplitShares(ctx context.Context, redEnvelope *model.RedEnvelope) error {
+	shares := splitShares(redEnvelope)
+	values := make([]interface{}, 0, len(shares))
+	for _, share := range shares {
+		values = append(values, share.StringFixed(2))
+	}
+
+	keys := sharesKeys(redEnvelope.ID)
+	expireAt := redEnvelope.ExpiresAt.Add(sharesRetention)
+	_, err := db.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, keys[1], values...)
+		pipe.Set(ctx, keys[0], redEnvelope.TotalCount, 0)
+		// 领取记录在首次领取时由脚本沿用标记的过期时间
+		pipe.ExpireAt(ctx, keys[0], expireAt)
+		pipe.ExpireAt(ctx, keys[1], expireAt)
+		return nil
+	})
+	return err
+}
+
+// claimShare 从 Redis 弹出一份预拆分金额
+func claimShare(ctx context.Context, redEnvelopeID, userID uint64, claimedAt time.Time) (int64, decimal.Decimal, int, error) {
+	result, err := claimShareScript.Run(ctx, db.Redis, sharesKeys(redEnvelopeID),
+		strconv.FormatUint(userID, 10), claimedAt.Unix()).Slice()
+	if err != nil {
+		return 0, decimal.Zero, 0, err
+	}
+
+	status, _ := result[0].(int64)
+	if status != claimShareOK {
+		return status, decimal.Zero, 0, nil
+	}
+
+	amount, err := decimal.NewFromString(result[1].(string))
+	if err != nil {
+		return 0, decimal.Zero, 0, err
+	}
+	remaining, _ := result[2].(int64)
+	return status, amount, int(remaining), nil
+}
+
+// markClaimsDirty 标记红包有待落库的领取记录，并下发下一秒执行的落库任务，同一秒内的领取合并为一次落库
+func markClaimsDirty(ctx context.Context, redEnvelopeID uint64) error {
+	if err := db.Redis.SAdd(ctx, db.PrefixedKey(dirtySetKey), redEnvelopeID).Err(); err != nil {
+		return err
+	}
+
+	processAt := time.Now().Truncate(time.Second).Add(time.Second)
+	if _, err := scheduler.AsynqClient.Enqueue(
+		asynq.NewTask(task.PersistRedEnvelopeClaimsTask, nil),
+		asynq.TaskID(fmt.Sprintf("redenvelope:persist_claims:%d", processAt.Unix())),
+		asynq.ProcessAt(processAt),
+		asynq.MaxRetry(3),
+	); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+	return nil
+}
+
+// parseClaimValue 解析 Redis 中的领取记录 "金额|领取时间戳"
+func parseClaimValue(value string) (decimal.Decimal, time.Time, error) {
+	amountStr, claimedAtStr, found := strings.Cut(value, "|")
+	if !found {
+		return decimal.Zero, time.Time{}, fmt.Errorf("invalid claim value: %s", value)
+	}
+	amount, err := decimal.NewFromString(amountStr)
+	if err != nil {
+		return decimal.Zero, time.Time{}, err
+	}
+	claimedAt, err := strconv.ParseInt(claimedAtStr, 10, 64)
+	if err != nil {
+		return decimal.Zero, time.Time{}, err
+	}
+	return amount, time.Unix(claimedAt, 0), nil
+}
+
+// getPendingClaim 查询已在 Redis 领取但尚未落库的领取记录
+func getPendingClaim(ctx context.Context, redEnvelopeID, userID uint64) (*model.RedEnvelopeClaim, error) {
+	value, err := db.Redis.HGet(ctx, sharesKeys(redEnvelopeID)[2], strconv.FormatUint(userID, 10)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	amount, claimedAt, err := parseClaimValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RedEnvelopeClaim{
+		RedEnvelopeID: redEnvelopeID,
+		UserID:        userID,
+		Amount:        amount,
+		ClaimedAt:     claimedAt,
+	}, nil
+}
+
+// persistClaims 将红包在 Redis 中的领取记录补录到数据库，已落库的记录跳过
+// 在同一事务内写入领取记录与订单、增加领取者余额并扣减红包剩余数量
+func persistClaims(ctx context.Context, redEnvelopeID uint64) (int, error) {
+	values, err := db.Redis.HGetAll(ctx, sharesKeys(redEnvelopeID)[2]).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	persisted := 0
+	err = db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var redEnvelope model.RedEnvelope
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", redEnvelopeID).
+			First(&redEnvelope).Error; err != nil {
+			return err
+		}
+
+		var existingUserIDs []uint64
+		if err := tx.Model(&model.RedEnvelopeClaim{}).
+			Where("red_envelope_id = ?", redEnvelopeID).
+			Pluck("user_id", &existingUserIDs).Error; err != nil {
+			return err
+		}
+		existing := make(map[uint64]bool, len(existingUserIDs))
+		for _, userID := range existingUserIDs {
+			existing[userID] = true
+		}
+
+		var claims []model.RedEnvelopeClaim
+		var orders []model.Order
+		total := decimal.Zero
+		for field, value := range values {
+			userID, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return err
+			}
+			if existing[userID] {
+				continue
+			}
+
+			amount, claimedAt, err := parseClaimValue(value)
+			if err != nil {
+				return err
+			}
+
+			claims = append(claims, model.RedEnvelopeClaim{
+				ID:            idgen.NextUint64ID(),
+				RedEnvelopeID: redEnvelopeID,
+				UserID:        userID,
+				Amount:        amount,
+				ClaimedAt:     claimedAt,
+			})
+			orders = append(orders, model.Order{
+				OrderName:   "红包收入",
+				PayerUserID: redEnvelope.CreatorID,
+				PayeeUserID: userID,
+				Amount:      amount,
+				Status:      model.OrderStatusSuccess,
+				Type:        model.OrderTypeRedEnvelopeReceive,
+				Remark:      fmt.Sprintf("祝福语: %s", redEnvelope.Greeting),
+				TradeTime:   claimedAt,
+				ExpiresAt:   claimedAt.Add(24 * time.Hour),
+			})
+			total = total.Add(amount)
+		}
+
+		if len(claims) == 0 {
+			return nil
+		}
+
+		// 红包已退款时剩余金额已退回发送者，不能再入账
+		if redEnvelope.Status == model.RedEnvelopeStatusExpired {
+			return fmt.Errorf("红包ID:%d 已过期退款，存在 %d 条未落库的领取记录", redEnvelopeID, len(claims))
+		}
+
+		if err := tx.Create(&claims).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&orders).Error; err != nil {
+			return err
+		}
+
+		for i := range claims {
+			if err := service.UpdateBalance(tx, service.BalanceUpdateOptions{
+				UserID:     claims[i].UserID,
+				Amount:     claims[i].Amount,
+				Operation:  service.BalanceAdd,
+				TotalField: "total_receive",
+			}); err != nil {
+				return err
+			}
+		}
+
+		remainingCount := redEnvelope.RemainingCount - len(claims)
+		updates := map[string]interface{}{
+			"remaining_count":  remainingCount,
+			"remaining_amount": redEnvelope.RemainingAmount.Sub(total),
+		}
+		if remainingCount <= 0 {
+			updates["status"] = model.RedEnvelopeStatusFinished
+		}
+		if err := tx.Model(&redEnvelope).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		persisted = len(claims)
+		return nil
+	})
+	return persisted, err
+}
+
+// drainShares 删除红包剩余的预拆分金额，此后的领取回退到数据库校验
+func drainShares(ctx context.Context, redEnvelopeID uint64) error {
+	keys := sharesKeys(redEnvelopeID)
+	return db.Redis.Del(ctx, keys[0], keys[1]).Err()
+}
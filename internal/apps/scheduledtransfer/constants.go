@@ -0,0 +1,26 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledtransfer
+
+const (
+	// maxLiveSchedules 每个用户进行中（等待执行或已暂停）的定时转账数量上限
+	maxLiveSchedules = 50
+	// maxConsecutiveFailures 周期转账连续失败达到该次数后自动暂停
+	maxConsecutiveFailures = 3
+	// recentOrdersLimit 详情中展示的最近执行记录数
+	recentOrdersLimit = 20
+)
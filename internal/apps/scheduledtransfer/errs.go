@@ -0,0 +1,30 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledtransfer
+
+const (
+	ScheduledTransferNotFound = "定时转账不存在"
+	RecipientNotFound         = "收款人不存在"
+	CannotTransferToSelf      = "不能转账给自己"
+	StartAtMustBeFuture       = "首次执行时间必须晚于当前时间"
+	EndsAtBeforeStartAt       = "截止时间不能早于首次执行时间"
+	TooManySchedules          = "进行中的定时转账数量已达上限"
+	StatusNotPausable         = "只有等待执行的定时转账可以暂停"
+	StatusNotResumable        = "只有已暂停的定时转账可以恢复"
+	StatusNotCancellable      = "定时转账已结束，无法取消"
+	ScheduleNoLongerRunnable  = "定时转账已到截止时间，无法恢复"
+)
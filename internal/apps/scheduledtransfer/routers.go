@@ -0,0 +1,397 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledtransfer
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateScheduledTransferRequest 创建定时转账请求
+type CreateScheduledTransferRequest struct {
+	RecipientID       uint64                           `json:"recipient_id,string" binding:"required"`
+	RecipientUsername string                           `json:"recipient_username" binding:"required"`
+	Amount            decimal.Decimal                  `json:"amount" binding:"required"`
+	Remark            string                           `json:"remark" binding:"max=100"`
+	Frequency         model.ScheduledTransferFrequency `json:"frequency" binding:"required,oneof=once weekly monthly"`
+	StartAt           time.Time                        `json:"start_at" binding:"required"`
+	EndsAt            *time.Time                       `json:"ends_at"` // 仅周期转账有效，为空表示不限
+	PayKey            string                           `json:"pay_key" binding:"required,max=6"`
+	TOTPCode          string                           `json:"totp_code" binding:"max=16"`
+}
+
+// ListScheduledTransfersRequest 定时转账列表查询参数
+type ListScheduledTransfersRequest struct {
+	Page     int                           `form:"page" binding:"min=1"`
+	PageSize int                           `form:"page_size" binding:"min=1,max=100"`
+	Status   model.ScheduledTransferStatus `form:"status" binding:"omitempty,oneof=active paused completed failed cancelled"`
+}
+
+// ListScheduledTransfersResponse 定时转账列表响应
+type ListScheduledTransfersResponse struct {
+	Total     int64                     `json:"total"`
+	Page      int                       `json:"page"`
+	PageSize  int                       `json:"page_size"`
+	Schedules []model.ScheduledTransfer `json:"schedules"`
+}
+
+// ScheduledTransferSummary 定时转账概览
+type ScheduledTransferSummary struct {
+	Active         int64 `json:"active"`
+	Paused         int64 `json:"paused"`
+	UnreadFailures int64 `json:"unread_failures"`
+}
+
+// ScheduledTransferDetail 定时转账详情及最近执行记录
+type ScheduledTransferDetail struct {
+	model.ScheduledTransfer
+	RecentOrders []model.Order `json:"recent_orders"`
+}
+
+// scheduleQuery 查询定时转账及收款人用户名
+func scheduleQuery(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&model.ScheduledTransfer{}).
+		Select("scheduled_transfers.*, users.username AS recipient_username").
+		Joins("LEFT JOIN users ON users.id = scheduled_transfers.recipient_user_id")
+}
+
+// CreateScheduledTransfer 创建单次定时转账或每周、每月的周期转账
+// @Tags scheduled-transfer
+// @Accept json
+// @Produce json
+// @Param request body CreateScheduledTransferRequest true "创建请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/scheduled-transfers [post]
+func CreateScheduledTransfer(c *gin.Context) {
+	var req CreateScheduledTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	if err := util.ValidateAmount(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	startAt := req.StartAt.Truncate(time.Second)
+	if !startAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, util.Err(StartAtMustBeFuture))
+		return
+	}
+	if req.Frequency == model.ScheduledTransferOnce {
+		req.EndsAt = nil
+	} else if req.EndsAt != nil && req.EndsAt.Before(startAt) {
+		c.JSON(http.StatusBadRequest, util.Err(EndsAtBeforeStartAt))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	if currentUser.ID == req.RecipientID {
+		c.JSON(http.StatusBadRequest, util.Err(CannotTransferToSelf))
+		return
+	}
+
+	if err := service.VerifyPaymentAuth(ctx, currentUser, req.PayKey, req.TOTPCode, req.Amount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	schedule := model.ScheduledTransfer{
+		UserID:            currentUser.ID,
+		RecipientUserID:   req.RecipientID,
+		Amount:            req.Amount,
+		Remark:            req.Remark,
+		Frequency:         req.Frequency,
+		StartAt:           startAt,
+		EndsAt:            req.EndsAt,
+		NextRunAt:         startAt,
+		Status:            model.ScheduledTransferStatusActive,
+		RecipientUsername: req.RecipientUsername,
+	}
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var recipient model.User
+		if err := tx.Where("id = ? AND username = ?", req.RecipientID, req.RecipientUsername).First(&recipient).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(RecipientNotFound)
+			}
+			return err
+		}
+
+		var liveCount int64
+		if err := tx.Model(&model.ScheduledTransfer{}).
+			Where("user_id = ? AND status IN ?", currentUser.ID, model.ScheduledTransferLiveStatuses).
+			Count(&liveCount).Error; err != nil {
+			return err
+		}
+		if liveCount >= maxLiveSchedules {
+			return errors.New(TooManySchedules)
+		}
+
+		return tx.Create(&schedule).Error
+	}); err != nil {
+		switch err.Error() {
+		case RecipientNotFound, TooManySchedules:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	if err := enqueueExecution(&schedule); err != nil {
+		// 下发失败由定时扫描兜底
+		logger.ErrorF(ctx, "下发定时转账[ID:%d]执行任务失败: %v", schedule.ID, err)
+	}
+
+	c.JSON(http.StatusOK, util.OK(schedule))
+}
+
+// ListScheduledTransfers 我的定时转账列表
+// @Tags scheduled-transfer
+// @Produce json
+// @Param request query ListScheduledTransfersRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/scheduled-transfers [get]
+func ListScheduledTransfers(c *gin.Context) {
+	var req ListScheduledTransfersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	baseQuery := db.DB(c.Request.Context()).Model(&model.ScheduledTransfer{}).
+		Where("scheduled_transfers.user_id = ?", currentUser.ID)
+	if req.Status != "" {
+		baseQuery = baseQuery.Where("scheduled_transfers.status = ?", req.Status)
+	}
+
+	response := ListScheduledTransfersResponse{
+		Page:      req.Page,
+		PageSize:  req.PageSize,
+		Schedules: []model.ScheduledTransfer{},
+	}
+	if err := baseQuery.Count(&response.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := scheduleQuery(baseQuery).
+		Order("scheduled_transfers.created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&response.Schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
+}
+
+// GetScheduledTransferSummary 定时转账概览，包含未查看的执行失败数量
+// @Tags scheduled-transfer
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/scheduled-transfers/summary [get]
+func GetScheduledTransferSummary(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var summary ScheduledTransferSummary
+	if err := db.DB(c.Request.Context()).
+		Model(&model.ScheduledTransfer{}).
+		Select("COUNT(*) FILTER (WHERE status = ?) AS active, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS paused, "+
+			"COUNT(*) FILTER (WHERE last_failed_at IS NOT NULL AND (failure_read_at IS NULL OR failure_read_at < last_failed_at)) AS unread_failures",
+			model.ScheduledTransferStatusActive, model.ScheduledTransferStatusPaused).
+		Where("user_id = ?", currentUser.ID).
+		Scan(&summary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(summary))
+}
+
+// GetScheduledTransfer 查询定时转账详情及最近执行记录，查看后执行失败通知标记为已读
+// @Tags scheduled-transfer
+// @Produce json
+// @Param id path string true "定时转账ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/scheduled-transfers/{id} [get]
+func GetScheduledTransfer(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var detail ScheduledTransferDetail
+	if err := scheduleQuery(db.DB(ctx)).
+		Where("scheduled_transfers.id = ? AND scheduled_transfers.user_id = ?", c.Param("id"), currentUser.ID).
+		First(&detail.ScheduledTransfer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(ScheduledTransferNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := db.DB(ctx).
+		Where("scheduled_transfer_id = ?", detail.ID).
+		Order("created_at DESC").
+		Limit(recentOrdersLimit).
+		Find(&detail.RecentOrders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if detail.HasUnreadFailure() {
+		now := time.Now()
+		if err := db.DB(ctx).Model(&model.ScheduledTransfer{}).
+			Where("id = ?", detail.ID).
+			UpdateColumn("failure_read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+		detail.FailureReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, util.OK(detail))
+}
+
+// PauseScheduledTransfer 暂停等待执行的定时转账
+// @Tags scheduled-transfer
+// @Produce json
+// @Param id path string true "定时转账ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/scheduled-transfers/{id}/pause [post]
+func PauseScheduledTransfer(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	result := db.DB(c.Request.Context()).Model(&model.ScheduledTransfer{}).
+		Where("id = ? AND user_id = ? AND status = ?", c.Param("id"), currentUser.ID, model.ScheduledTransferStatusActive).
+		Update("status", model.ScheduledTransferStatusPaused)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, util.Err(StatusNotPausable))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// ResumeScheduledTransfer 恢复已暂停的定时转账
+// 周期转账跳过暂停期间错过的周期，单次转账若已过执行时间则立即执行
+// @Tags scheduled-transfer
+// @Produce json
+// @Param id path string true "定时转账ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/scheduled-transfers/{id}/resume [post]
+func ResumeScheduledTransfer(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var schedule model.ScheduledTransfer
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ? AND status = ?", c.Param("id"), currentUser.ID, model.ScheduledTransferStatusPaused).
+			First(&schedule).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(StatusNotResumable)
+			}
+			return err
+		}
+
+		now := time.Now()
+		if schedule.NextRunAt.Before(now) {
+			if schedule.Frequency == model.ScheduledTransferOnce {
+				schedule.NextRunAt = now.Truncate(time.Second)
+			} else {
+				seq, runAt, ok := schedule.NextRunAfter(now)
+				if !ok {
+					return errors.New(ScheduleNoLongerRunnable)
+				}
+				schedule.Sequence = seq
+				schedule.NextRunAt = runAt
+			}
+		}
+
+		schedule.Status = model.ScheduledTransferStatusActive
+		return tx.Model(&schedule).Updates(map[string]interface{}{
+			"status":        schedule.Status,
+			"sequence":      schedule.Sequence,
+			"next_run_at":   schedule.NextRunAt,
+			"failure_count": 0,
+		}).Error
+	}); err != nil {
+		switch err.Error() {
+		case StatusNotResumable, ScheduleNoLongerRunnable:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	if err := enqueueExecution(&schedule); err != nil {
+		logger.ErrorF(ctx, "下发定时转账[ID:%d]执行任务失败: %v", schedule.ID, err)
+	}
+
+	c.JSON(http.StatusOK, util.OK(schedule))
+}
+
+// CancelScheduledTransfer 取消尚未结束的定时转账
+// @Tags scheduled-transfer
+// @Produce json
+// @Param id path string true "定时转账ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/scheduled-transfers/{id}/cancel [post]
+func CancelScheduledTransfer(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	result := db.DB(c.Request.Context()).Model(&model.ScheduledTransfer{}).
+		Where("id = ? AND user_id = ? AND status IN ?", c.Param("id"), currentUser.ID, model.ScheduledTransferLiveStatuses).
+		Update("status", model.ScheduledTransferStatusCancelled)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, util.Err(StatusNotCancellable))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledtransfer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errExecuteSkipped 定时转账已被处理或状态已变化，跳过本次执行
+var errExecuteSkipped = errors.New("scheduled transfer execute skipped")
+
+// HandleDispatchDueScheduledTransfers 扫描已到执行时间的定时转账并下发执行任务
+// 正常情况下执行任务在创建或上次执行后已按时间延时下发，此处用于兜底
+func HandleDispatchDueScheduledTransfers(ctx context.Context, t *asynq.Task) error {
+	pageSize := 1000
+	lastID := uint64(0)
+	now := time.Now()
+
+	for {
+		var schedules []model.ScheduledTransfer
+		if err := db.DB(ctx).
+			Select("id, next_run_at").
+			Where("id > ? AND status = ? AND next_run_at <= ?", lastID, model.ScheduledTransferStatusActive, now).
+			Order("id ASC").
+			Limit(pageSize).
+			Find(&schedules).Error; err != nil {
+			logger.ErrorF(ctx, "查询到期定时转账失败: %v", err)
+			return err
+		}
+
+		if len(schedules) == 0 {
+			break
+		}
+
+		for i := range schedules {
+			if err := enqueueExecution(&schedules[i]); err != nil {
+				logger.ErrorF(ctx, "下发定时转账[ID:%d]执行任务失败: %v", schedules[i].ID, err)
+				return err
+			}
+		}
+
+		lastID = schedules[len(schedules)-1].ID
+	}
+	return nil
+}
+
+// HandleExecuteScheduledTransfer 执行单个定时转账，执行时校验余额，失败时记录原因供用户查看
+func HandleExecuteScheduledTransfer(ctx context.Context, t *asynq.Task) error {
+	var payload struct {
+		ScheduledTransferID uint64 `json:"scheduled_transfer_id"`
+	}
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("解析任务参数失败: %w", err)
+	}
+
+	now := time.Now()
+
+	var schedule model.ScheduledTransfer
+	var order *model.Order
+	err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+			Where("id = ? AND status = ? AND next_run_at <= ?", payload.ScheduledTransferID, model.ScheduledTransferStatusActive, now).
+			First(&schedule).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errExecuteSkipped
+			}
+			return err
+		}
+
+		var payer model.User
+		if err := payer.GetByID(tx, schedule.UserID); err != nil {
+			return err
+		}
+		if !payer.IsActive {
+			return errors.New(common.BannedAccount)
+		}
+
+		var err error
+		order, err = service.Transfer(tx, service.TransferOptions{
+			PayerID:             schedule.UserID,
+			PayeeID:             schedule.RecipientUserID,
+			Amount:              schedule.Amount,
+			OrderName:           "定时转账",
+			Remark:              schedule.Remark,
+			ScheduledTransferID: &schedule.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		updates := advanceUpdates(&schedule, now, model.ScheduledTransferStatusCompleted)
+		updates["run_count"] = gorm.Expr("run_count + 1")
+		updates["last_run_at"] = now
+		updates["last_order_id"] = order.ID
+		updates["failure_count"] = 0
+		updates["last_error"] = ""
+		return tx.Model(&schedule).Updates(updates).Error
+	})
+
+	switch {
+	case err == nil:
+		logger.InfoF(ctx, "定时转账[ID:%d]执行成功: 订单[ID:%d]", schedule.ID, order.ID)
+	case errors.Is(err, errExecuteSkipped):
+		return nil
+	case isTransferFailure(err.Error()):
+		if err := markFailed(ctx, payload.ScheduledTransferID, err.Error()); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	return enqueueNext(ctx, payload.ScheduledTransferID)
+}
+
+// markFailed 记录执行失败并推进到下一周期
+// 单次转账直接标记失败，周期转账连续失败达到上限后自动暂停
+func markFailed(ctx context.Context, scheduleID uint64, reason string) error {
+	return db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var schedule model.ScheduledTransfer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", scheduleID, model.ScheduledTransferStatusActive).
+			First(&schedule).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		now := time.Now()
+		updates := advanceUpdates(&schedule, now, model.ScheduledTransferStatusFailed)
+		if schedule.Frequency != model.ScheduledTransferOnce && schedule.FailureCount+1 >= maxConsecutiveFailures {
+			updates["status"] = model.ScheduledTransferStatusPaused
+		}
+		updates["failure_count"] = gorm.Expr("failure_count + 1")
+		updates["last_error"] = reason
+		updates["last_failed_at"] = now
+
+		logger.InfoF(ctx, "定时转账[ID:%d]执行失败: %s", schedule.ID, reason)
+		return tx.Model(&schedule).Updates(updates).Error
+	})
+}
+
+// enqueueNext 定时转账仍在进行中时下发下一次执行任务
+func enqueueNext(ctx context.Context, scheduleID uint64) error {
+	var schedule model.ScheduledTransfer
+	if err := db.DB(ctx).
+		Where("id = ? AND status = ?", scheduleID, model.ScheduledTransferStatusActive).
+		First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := enqueueExecution(&schedule); err != nil {
+		// 下发失败由定时扫描兜底，不影响本次执行结果
+		logger.ErrorF(ctx, "下发定时转账[ID:%d]下一次执行任务失败: %v", schedule.ID, err)
+	}
+	return nil
+}
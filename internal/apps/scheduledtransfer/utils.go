@@ -0,0 +1,75 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledtransfer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/task"
+	"github.com/linux-do/credit/internal/task/scheduler"
+)
+
+// enqueueExecution 按下一次执行时间下发延时任务，同一执行时间点只下发一次
+func enqueueExecution(schedule *model.ScheduledTransfer) error {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"scheduled_transfer_id": schedule.ID,
+	})
+
+	taskID := fmt.Sprintf("scheduled_transfer:execute:%d:%d", schedule.ID, schedule.NextRunAt.Unix())
+	if _, err := scheduler.AsynqClient.Enqueue(
+		asynq.NewTask(task.ExecuteScheduledTransferTask, payload),
+		asynq.TaskID(taskID),
+		asynq.ProcessAt(schedule.NextRunAt),
+		asynq.MaxRetry(3),
+	); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+	return nil
+}
+
+// advanceUpdates 本次执行（成功或失败）后推进到下一周期的字段更新
+// 单次转账或超过截止时间的周期转账将以 finalStatus 结束
+func advanceUpdates(schedule *model.ScheduledTransfer, now time.Time, finalStatus model.ScheduledTransferStatus) map[string]interface{} {
+	seq, runAt, ok := schedule.NextRunAfter(now)
+	if !ok {
+		return map[string]interface{}{
+			"status": finalStatus,
+		}
+	}
+
+	schedule.Sequence = seq
+	schedule.NextRunAt = runAt
+	return map[string]interface{}{
+		"sequence":    seq,
+		"next_run_at": runAt,
+	}
+}
+
+// isTransferFailure 是否为执行时余额、额度等原因导致的转账失败，此类失败不重试而是记录并通知用户
+func isTransferFailure(msg string) bool {
+	switch msg {
+	case common.InsufficientBalance, common.PayKeyResetCoolingLimited, common.BannedAccount:
+		return true
+	}
+	return false
+}
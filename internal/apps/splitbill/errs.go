@@ -0,0 +1,32 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splitbill
+
+const (
+	SplitBillNotFound      = "AA 收款不存在"
+	SplitBillShareNotFound = "应付份额不存在"
+	SplitBillClosed        = "AA 收款已结束"
+	InvalidSplitBillMode   = "不支持的分摊方式"
+	ParticipantNotFound    = "参与人不存在或用户名不匹配"
+	DuplicateParticipant   = "参与人不能重复"
+	CannotIncludeOrganizer = "参与人不能包含发起人"
+	ShareAmountTooSmall    = "每人分摊金额不能低于 0.01"
+	TotalLessThanShares    = "账单总金额不能低于参与人份额之和"
+	ShareNotPayable        = "该份额已支付或已取消"
+	ShareNotCancellable    = "该份额已支付或已取消，无法取消"
+	ShareRemindTooFrequent = "提醒过于频繁，请稍后再试"
+)
@@ -0,0 +1,508 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splitbill
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ListSplitBillsRequest 发起的 AA 收款列表查询参数
+type ListSplitBillsRequest struct {
+	Page     int                   `form:"page" binding:"min=1"`
+	PageSize int                   `form:"page_size" binding:"min=1,max=100"`
+	Status   model.SplitBillStatus `form:"status" binding:"omitempty,oneof=open completed cancelled"`
+}
+
+// SplitBillSummary 发起人视角的收款进度
+type SplitBillSummary struct {
+	model.SplitBill
+	ShareCount int64           `json:"share_count"`
+	PaidCount  int64           `json:"paid_count"`
+	PaidAmount decimal.Decimal `json:"paid_amount"`
+}
+
+// ListSplitBillsResponse 发起的 AA 收款列表响应
+type ListSplitBillsResponse struct {
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	Bills    []SplitBillSummary `json:"bills"`
+}
+
+// ListMySharesRequest 我的应付份额列表查询参数
+type ListMySharesRequest struct {
+	Page     int                        `form:"page" binding:"min=1"`
+	PageSize int                        `form:"page_size" binding:"min=1,max=100"`
+	Status   model.SplitBillShareStatus `form:"status" binding:"omitempty,oneof=pending paid cancelled"`
+}
+
+// MyShare 参与人视角的应付份额
+type MyShare struct {
+	model.SplitBillShare
+	Title             string `json:"title"`
+	OrganizerUserID   uint64 `json:"organizer_user_id,string"`
+	OrganizerUsername string `json:"organizer_username"`
+}
+
+// ListMySharesResponse 我的应付份额列表响应
+type ListMySharesResponse struct {
+	Total    int64     `json:"total"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"page_size"`
+	Shares   []MyShare `json:"shares"`
+}
+
+// PayShareRequest 支付份额请求
+type PayShareRequest struct {
+	PayKey   string `json:"pay_key" binding:"required,max=6"`
+	TOTPCode string `json:"totp_code" binding:"max=16"`
+}
+
+// CreateSplitBill 发起 AA 收款
+// @Tags splitbill
+// @Accept json
+// @Produce json
+// @Param request body CreateSplitBillRequest true "创建请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/split-bills [post]
+func CreateSplitBill(c *gin.Context) {
+	var req CreateSplitBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	bill, shares, err := req.buildShares(currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := checkParticipants(tx, shares); err != nil {
+			return err
+		}
+		if err := tx.Create(bill).Error; err != nil {
+			return err
+		}
+		for i := range shares {
+			shares[i].BillID = bill.ID
+		}
+		return tx.Create(&shares).Error
+	}); err != nil {
+		if err.Error() == ParticipantNotFound {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	bill.OrganizerUsername = currentUser.Username
+	bill.Shares = shares
+	c.JSON(http.StatusOK, util.OK(bill))
+}
+
+// ListSplitBills 我发起的 AA 收款及收款进度
+// @Tags splitbill
+// @Produce json
+// @Param request query ListSplitBillsRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/split-bills [get]
+func ListSplitBills(c *gin.Context) {
+	var req ListSplitBillsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	baseQuery := db.DB(c.Request.Context()).
+		Table("split_bills AS b").
+		Where("b.organizer_user_id = ?", currentUser.ID)
+	if req.Status != "" {
+		baseQuery = baseQuery.Where("b.status = ?", req.Status)
+	}
+
+	response := ListSplitBillsResponse{
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Bills:    []SplitBillSummary{},
+	}
+	if err := baseQuery.Count(&response.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := baseQuery.
+		Select("b.*, "+
+			"(SELECT COUNT(*) FROM split_bill_shares s WHERE s.bill_id = b.id) AS share_count, "+
+			"(SELECT COUNT(*) FROM split_bill_shares s WHERE s.bill_id = b.id AND s.status = ?) AS paid_count, "+
+			"(SELECT COALESCE(SUM(s.amount), 0) FROM split_bill_shares s WHERE s.bill_id = b.id AND s.status = ?) AS paid_amount",
+			model.SplitBillShareStatusPaid, model.SplitBillShareStatusPaid).
+		Order("b.created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Scan(&response.Bills).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
+}
+
+// ListMyShares 我参与的 AA 收款中需要支付的份额
+// @Tags splitbill
+// @Produce json
+// @Param request query ListMySharesRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/split-bills/shares [get]
+func ListMyShares(c *gin.Context) {
+	var req ListMySharesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	baseQuery := db.DB(c.Request.Context()).
+		Table("split_bill_shares AS s").
+		Where("s.user_id = ?", currentUser.ID)
+	if req.Status != "" {
+		baseQuery = baseQuery.Where("s.status = ?", req.Status)
+	}
+
+	response := ListMySharesResponse{
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Shares:   []MyShare{},
+	}
+	if err := baseQuery.Count(&response.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := baseQuery.
+		Select("s.*, b.title, b.organizer_user_id, u.username AS organizer_username").
+		Joins("JOIN split_bills b ON b.id = s.bill_id").
+		Joins("LEFT JOIN users u ON u.id = b.organizer_user_id").
+		Order("s.created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Scan(&response.Shares).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
+}
+
+// GetSplitBill 查询 AA 收款详情及每位参与人的支付状态，发起人与参与人可见
+// @Tags splitbill
+// @Produce json
+// @Param id path string true "AA 收款ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/split-bills/{id} [get]
+func GetSplitBill(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var bill model.SplitBill
+	if err := db.DB(ctx).Model(&model.SplitBill{}).
+		Select("split_bills.*, users.username AS organizer_username").
+		Joins("LEFT JOIN users ON users.id = split_bills.organizer_user_id").
+		Where("split_bills.id = ?", c.Param("id")).
+		Where("split_bills.organizer_user_id = ? OR EXISTS (SELECT 1 FROM split_bill_shares s WHERE s.bill_id = split_bills.id AND s.user_id = ?)",
+			currentUser.ID, currentUser.ID).
+		First(&bill).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(SplitBillNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := db.DB(ctx).Model(&model.SplitBillShare{}).
+		Select("split_bill_shares.*, users.username").
+		Joins("LEFT JOIN users ON users.id = split_bill_shares.user_id").
+		Where("split_bill_shares.bill_id = ?", bill.ID).
+		Order("split_bill_shares.id ASC").
+		Find(&bill.Shares).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(bill))
+}
+
+// PaySplitBillShare 参与人支付自己的份额
+// @Tags splitbill
+// @Accept json
+// @Produce json
+// @Param id path string true "AA 收款ID"
+// @Param request body PayShareRequest true "支付请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/split-bills/{id}/pay [post]
+func PaySplitBillShare(c *gin.Context) {
+	var req PayShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	billID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var share model.SplitBillShare
+	if err := db.DB(ctx).
+		Where("bill_id = ? AND user_id = ?", billID, currentUser.ID).
+		First(&share).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(SplitBillShareNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if share.Status != model.SplitBillShareStatusPending {
+		c.JSON(http.StatusBadRequest, util.Err(ShareNotPayable))
+		return
+	}
+
+	if err := service.VerifyPaymentAuth(ctx, currentUser, req.PayKey, req.TOTPCode, share.Amount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	var order *model.Order
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先锁定收款记录再锁定份额，与取消操作的加锁顺序一致，并使完成检查串行执行
+		var bill model.SplitBill
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", billID).
+			First(&bill).Error; err != nil {
+			return err
+		}
+		if bill.Status != model.SplitBillStatusOpen {
+			return errors.New(SplitBillClosed)
+		}
+
+		var locked model.SplitBillShare
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+			Where("id = ? AND status = ?", share.ID, model.SplitBillShareStatusPending).
+			First(&locked).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(ShareNotPayable)
+			}
+			return err
+		}
+
+		var err error
+		order, err = service.Transfer(tx, service.TransferOptions{
+			PayerID:     currentUser.ID,
+			PayeeID:     bill.OrganizerUserID,
+			Amount:      locked.Amount,
+			OrderName:   bill.Title,
+			OrderType:   model.OrderTypeSplitBill,
+			Remark:      bill.Note,
+			SplitBillID: &bill.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&locked).Updates(map[string]interface{}{
+			"status":   model.SplitBillShareStatusPaid,
+			"order_id": order.ID,
+			"paid_at":  time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return settleIfDone(tx, bill.ID)
+	}); err != nil {
+		switch err.Error() {
+		case SplitBillClosed, ShareNotPayable, common.InsufficientBalance, common.PayKeyResetCoolingLimited:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(gin.H{
+		"order_id": strconv.FormatUint(order.ID, 10),
+	}))
+}
+
+// RemindSplitBillShare 发起人提醒参与人支付，同一份额每 24 小时可提醒一次
+// @Tags splitbill
+// @Produce json
+// @Param id path string true "AA 收款ID"
+// @Param shareId path string true "份额ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/split-bills/{id}/shares/{shareId}/remind [post]
+func RemindSplitBillShare(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	now := time.Now()
+
+	result := db.DB(c.Request.Context()).Model(&model.SplitBillShare{}).
+		Where("id = ? AND bill_id = ? AND status = ?", c.Param("shareId"), c.Param("id"), model.SplitBillShareStatusPending).
+		Where("bill_id IN (SELECT id FROM split_bills WHERE organizer_user_id = ? AND status = ?)", currentUser.ID, model.SplitBillStatusOpen).
+		Where("last_reminded_at IS NULL OR last_reminded_at <= ?", now.Add(-remindInterval)).
+		Updates(map[string]interface{}{
+			"remind_count":     gorm.Expr("remind_count + 1"),
+			"last_reminded_at": now,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, util.Err(ShareRemindTooFrequent))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// CancelSplitBillShare 发起人取消某位参与人未支付的份额
+// @Tags splitbill
+// @Produce json
+// @Param id path string true "AA 收款ID"
+// @Param shareId path string true "份额ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/split-bills/{id}/shares/{shareId}/cancel [post]
+func CancelSplitBillShare(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var bill model.SplitBill
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND organizer_user_id = ?", c.Param("id"), currentUser.ID).
+			First(&bill).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(SplitBillNotFound)
+			}
+			return err
+		}
+
+		result := tx.Model(&model.SplitBillShare{}).
+			Where("id = ? AND bill_id = ? AND status = ?", c.Param("shareId"), bill.ID, model.SplitBillShareStatusPending).
+			Updates(map[string]interface{}{
+				"status":       model.SplitBillShareStatusCancelled,
+				"cancelled_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(ShareNotCancellable)
+		}
+
+		return settleIfDone(tx, bill.ID)
+	}); err != nil {
+		switch err.Error() {
+		case SplitBillNotFound:
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+		case ShareNotCancellable:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// CancelSplitBill 发起人关闭 AA 收款，取消所有未支付的份额，已支付的份额不受影响
+// @Tags splitbill
+// @Produce json
+// @Param id path string true "AA 收款ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/split-bills/{id}/cancel [post]
+func CancelSplitBill(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var bill model.SplitBill
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND organizer_user_id = ?", c.Param("id"), currentUser.ID).
+			First(&bill).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(SplitBillNotFound)
+			}
+			return err
+		}
+		if bill.Status != model.SplitBillStatusOpen {
+			return errors.New(SplitBillClosed)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.SplitBillShare{}).
+			Where("bill_id = ? AND status = ?", bill.ID, model.SplitBillShareStatusPending).
+			Updates(map[string]interface{}{
+				"status":       model.SplitBillShareStatusCancelled,
+				"cancelled_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&bill).Updates(map[string]interface{}{
+			"status":    model.SplitBillStatusCancelled,
+			"closed_at": now,
+		}).Error
+	}); err != nil {
+		switch err.Error() {
+		case SplitBillNotFound:
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+		case SplitBillClosed:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
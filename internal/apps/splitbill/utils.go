@@ -0,0 +1,191 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splitbill
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// minShareAmount 最小分摊金额
+var minShareAmount = decimal.New(1, -2)
+
+// ParticipantRequest 参与人，需同时提供 ID 与用户名
+type ParticipantRequest struct {
+	UserID   string          `json:"user_id" binding:"required"`
+	Username string          `json:"username" binding:"required"`
+	Amount   decimal.Decimal `json:"amount"` // 自定义分摊时必填
+}
+
+// CreateSplitBillRequest 创建 AA 收款请求
+type CreateSplitBillRequest struct {
+	Title string              `json:"title" binding:"required,max=64"`
+	Note  string              `json:"note" binding:"max=255"`
+	Mode  model.SplitBillMode `json:"mode" binding:"required"`
+	// TotalAmount 平均分摊时必填；自定义分摊时为空则取参与人份额之和，大于份额之和的部分由发起人自付
+	TotalAmount decimal.Decimal `json:"total_amount"`
+	// IncludeOrganizer 平均分摊时发起人计入人数，其份额不向他人收取
+	IncludeOrganizer bool                 `json:"include_organizer"`
+	Participants     []ParticipantRequest `json:"participants" binding:"required,min=1,max=100,dive"`
+}
+
+// buildShares 校验参与人并计算每人份额
+func (r *CreateSplitBillRequest) buildShares(organizerID uint64) (*model.SplitBill, []model.SplitBillShare, error) {
+	userIDs := make([]uint64, 0, len(r.Participants))
+	seen := make(map[uint64]struct{}, len(r.Participants))
+	for _, p := range r.Participants {
+		id, err := strconv.ParseUint(p.UserID, 10, 64)
+		if err != nil {
+			return nil, nil, errors.New(ParticipantNotFound)
+		}
+		if id == organizerID {
+			return nil, nil, errors.New(CannotIncludeOrganizer)
+		}
+		if _, ok := seen[id]; ok {
+			return nil, nil, errors.New(DuplicateParticipant)
+		}
+		seen[id] = struct{}{}
+		userIDs = append(userIDs, id)
+	}
+
+	bill := &model.SplitBill{
+		OrganizerUserID: organizerID,
+		Title:           r.Title,
+		Note:            r.Note,
+		Mode:            r.Mode,
+		Status:          model.SplitBillStatusOpen,
+	}
+	shares := make([]model.SplitBillShare, len(userIDs))
+	for i, id := range userIDs {
+		shares[i] = model.SplitBillShare{
+			UserID:   id,
+			Username: r.Participants[i].Username,
+			Status:   model.SplitBillShareStatusPending,
+		}
+	}
+
+	switch r.Mode {
+	case model.SplitBillModeEqual:
+		if err := util.ValidateAmount(r.TotalAmount); err != nil {
+			return nil, nil, err
+		}
+
+		count := int64(len(shares))
+		if r.IncludeOrganizer {
+			count++
+		}
+		perShare := r.TotalAmount.Div(decimal.NewFromInt(count)).Truncate(2)
+		if perShare.LessThan(minShareAmount) {
+			return nil, nil, errors.New(ShareAmountTooSmall)
+		}
+
+		// 除不尽的零头：发起人计入人数时由发起人承担，否则逐个分给前几位参与人
+		remainder := r.TotalAmount.Sub(perShare.Mul(decimal.NewFromInt(count)))
+		collect := decimal.Zero
+		for i := range shares {
+			shares[i].Amount = perShare
+			if !r.IncludeOrganizer && remainder.IsPositive() {
+				shares[i].Amount = shares[i].Amount.Add(minShareAmount)
+				remainder = remainder.Sub(minShareAmount)
+			}
+			collect = collect.Add(shares[i].Amount)
+		}
+		bill.TotalAmount = r.TotalAmount
+		bill.CollectAmount = collect
+		bill.IncludeOrganizer = r.IncludeOrganizer
+	case model.SplitBillModeCustom:
+		collect := decimal.Zero
+		for i, p := range r.Participants {
+			if err := util.ValidateAmount(p.Amount); err != nil {
+				return nil, nil, err
+			}
+			shares[i].Amount = p.Amount
+			collect = collect.Add(p.Amount)
+		}
+
+		if r.TotalAmount.IsZero() {
+			r.TotalAmount = collect
+		} else {
+			if err := util.ValidateAmount(r.TotalAmount); err != nil {
+				return nil, nil, err
+			}
+			if r.TotalAmount.LessThan(collect) {
+				return nil, nil, errors.New(TotalLessThanShares)
+			}
+		}
+		bill.TotalAmount = r.TotalAmount
+		bill.CollectAmount = collect
+		bill.IncludeOrganizer = r.TotalAmount.GreaterThan(collect)
+	default:
+		return nil, nil, errors.New(InvalidSplitBillMode)
+	}
+
+	return bill, shares, nil
+}
+
+// checkParticipants 校验参与人存在且用户名匹配
+func checkParticipants(tx *gorm.DB, shares []model.SplitBillShare) error {
+	userIDs := make([]uint64, 0, len(shares))
+	for _, share := range shares {
+		userIDs = append(userIDs, share.UserID)
+	}
+
+	var users []model.User
+	if err := tx.Select("id, username").
+		Where("id IN ? AND is_active = ?", userIDs, true).
+		Find(&users).Error; err != nil {
+		return err
+	}
+
+	usernames := make(map[uint64]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	for _, share := range shares {
+		if usernames[share.UserID] != share.Username {
+			return errors.New(ParticipantNotFound)
+		}
+	}
+	return nil
+}
+
+// settleIfDone 所有份额均已支付或取消时结束收款
+// 需在已锁定收款记录的事务中调用，避免并发支付最后几笔份额时均看到对方未支付
+func settleIfDone(tx *gorm.DB, billID uint64) error {
+	var pending int64
+	if err := tx.Model(&model.SplitBillShare{}).
+		Where("bill_id = ? AND status = ?", billID, model.SplitBillShareStatusPending).
+		Count(&pending).Error; err != nil {
+		return err
+	}
+	if pending > 0 {
+		return nil
+	}
+
+	return tx.Model(&model.SplitBill{}).
+		Where("id = ? AND status = ?", billID, model.SplitBillStatusOpen).
+		Updates(map[string]interface{}{
+			"status":    model.SplitBillStatusCompleted,
+			"closed_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscription
+
+import "time"
+
+// 订阅 Webhook 事件
+const (
+	EventCreated  = "subscription.created"
+	EventRenewed  = "subscription.renewed"
+	EventPastDue  = "subscription.past_due"
+	EventCanceled = "subscription.canceled"
+	EventExpired  = "subscription.expired"
+)
+
+const (
+	// pastDueRetryInterval 宽限期内续费失败后的重试间隔
+	pastDueRetryInterval = 24 * time.Hour
+	// renewRemark 续费订单备注
+	renewRemark = "[系统]: 订阅自动续费"
+)
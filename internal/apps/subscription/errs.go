@@ -0,0 +1,25 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscription
+
+const (
+	SubscriptionNotFound           = "订阅不存在"
+	NotSubscriptionLink            = "该链接不是订阅链接"
+	AlreadySubscribed              = "您已订阅该服务"
+	SubscriptionNotCancelable      = "订阅当前状态不可取消"
+	SubscriptionTotalLimitExceeded = "该订阅已达到订阅人数上限"
+)
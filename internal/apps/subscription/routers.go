@@ -0,0 +1,404 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscription
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/apps/merchant"
+	"github.com/linux-do/credit/internal/apps/merchant/link"
+	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/apps/payment"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubscribeRequest 通过订阅链接开通订阅请求
+type SubscribeRequest struct {
+	Token    string `json:"token" binding:"required"`
+	PayKey   string `json:"pay_key" binding:"required,max=6"`
+	TOTPCode string `json:"totp_code" binding:"max=16"`
+	Remark   string `json:"remark" binding:"max=100"`
+}
+
+// Subscribe 通过订阅链接开通订阅，立即扣除首期费用
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param request body SubscribeRequest true "订阅请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/payment-links/subscribe [post]
+func Subscribe(c *gin.Context) {
+	var req SubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	var paymentLink model.MerchantPaymentLink
+	if err := paymentLink.GetByToken(db.DB(ctx), req.Token); err != nil {
+		c.JSON(http.StatusNotFound, util.Err(link.PaymentLinkNotFound))
+		return
+	}
+	if !paymentLink.IsSubscription() {
+		c.JSON(http.StatusBadRequest, util.Err(NotSubscriptionLink))
+		return
+	}
+	if err := link.CheckAvailability(&paymentLink, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	if err := link.CheckPayerRemark(&paymentLink, req.Remark); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	if err := service.VerifyPaymentAuth(ctx, currentUser, req.PayKey, req.TOTPCode, paymentLink.Amount, c.ClientIP()); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	var apiKey model.MerchantAPIKey
+	if err := apiKey.GetByID(db.DB(ctx), paymentLink.MerchantAPIKeyID); err != nil {
+		c.JSON(http.StatusNotFound, util.Err(common.MerchantUnavailable))
+		return
+	}
+
+	subscription := model.Subscription{
+		ID:             idgen.NextUint64ID(),
+		PaymentLinkID:  paymentLink.ID,
+		APIKeyID:       apiKey.ID,
+		ClientID:       apiKey.ClientID,
+		MerchantUserID: apiKey.UserID,
+		UserID:         currentUser.ID,
+		ProductName:    paymentLink.ProductName,
+		Amount:         paymentLink.Amount,
+		Interval:       *paymentLink.Interval,
+		IntervalCount:  paymentLink.IntervalCount,
+		GraceDays:      paymentLink.GraceDays,
+		Status:         model.SubscriptionStatusActive,
+	}
+
+	var order *model.Order
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = service.DirectCharge(tx, service.DirectChargeOptions{
+			PayerID:        currentUser.ID,
+			APIKey:         &apiKey,
+			Amount:         paymentLink.Amount,
+			OrderName:      paymentLink.ProductName,
+			Remark:         req.Remark,
+			OrderType:      model.OrderTypeOnline,
+			PaymentLinkID:  &paymentLink.ID,
+			SubscriptionID: &subscription.ID,
+			BeforeCharge: func(tx *gorm.DB, payer *model.User) error {
+				return checkSubscribeLimits(tx, &paymentLink, payer.ID)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		subscription.CurrentPeriodStart = order.TradeTime
+		subscription.CurrentPeriodEnd = subscription.Interval.Add(order.TradeTime, subscription.IntervalCount)
+		subscription.NextChargeAt = subscription.CurrentPeriodEnd
+		if err := tx.Create(&subscription).Error; err != nil {
+			return err
+		}
+
+		return enqueueSubscriptionNotify(subscription.ID, EventCreated, order.ID)
+	}); err != nil {
+		switch err.Error() {
+		case common.InsufficientBalance, common.DailyLimitExceeded, common.CannotPaySelf,
+			common.TestModeCannotProcessOrder, common.PayKeyResetCoolingLimited, AlreadySubscribed, SubscriptionTotalLimitExceeded:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		case common.MerchantUnavailable:
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(subscription))
+}
+
+// UserSubscription 用户订阅详情
+type UserSubscription struct {
+	model.Subscription
+	AppName        string `json:"app_name"`
+	AppHomepageURL string `json:"app_homepage_url"`
+}
+
+// ListMySubscriptions 查询当前用户的订阅
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/subscriptions [get]
+func ListMySubscriptions(c *gin.Context) {
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var subscriptions []UserSubscription
+	if err := db.DB(c.Request.Context()).
+		Table("subscriptions AS s").
+		Select("s.*, k.app_name, k.app_homepage_url").
+		Joins("LEFT JOIN merchant_api_keys k ON k.id = s.api_key_id").
+		Where("s.user_id = ?", currentUser.ID).
+		Order("s.created_at DESC").
+		Scan(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(subscriptions))
+}
+
+// CancelMySubscription 用户取消订阅：正常订阅在当期结束后终止，宽限期内的订阅立即终止
+// @Tags user
+// @Produce json
+// @Param id path string true "订阅ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/subscriptions/{id}/cancel [post]
+func CancelMySubscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	currentUser, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	endedNow := false
+	if err := db.DB(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var subscription model.Subscription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", id, currentUser.ID).
+			First(&subscription).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(SubscriptionNotFound)
+			}
+			return err
+		}
+
+		switch subscription.Status {
+		case model.SubscriptionStatusPastDue:
+			endedNow = true
+			_, err := endSubscription(tx, subscription.ID, model.SubscriptionStatusCanceled, "")
+			return err
+		case model.SubscriptionStatusActive:
+			if subscription.CancelAtPeriodEnd {
+				return nil
+			}
+			return tx.Model(&subscription).Updates(map[string]interface{}{
+				"cancel_at_period_end": true,
+				"canceled_at":          time.Now(),
+			}).Error
+		default:
+			return errors.New(SubscriptionNotCancelable)
+		}
+	}); err != nil {
+		switch err.Error() {
+		case SubscriptionNotFound:
+			c.JSON(http.StatusNotFound, util.Err(err.Error()))
+		case SubscriptionNotCancelable:
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		}
+		return
+	}
+
+	if endedNow {
+		if err := enqueueSubscriptionNotify(id, EventCanceled, 0); err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// ListMerchantSubscriptionsRequest 商户订阅列表请求
+type ListMerchantSubscriptionsRequest struct {
+	Page     int                      `form:"page" binding:"min=1"`
+	PageSize int                      `form:"page_size" binding:"min=1,max=100"`
+	Status   model.SubscriptionStatus `form:"status" binding:"omitempty,oneof=active past_due canceled expired"`
+}
+
+// MerchantSubscription 商户视角的订阅详情
+type MerchantSubscription struct {
+	model.Subscription
+	Username string `json:"username"`
+}
+
+// ListMerchantSubscriptionsResponse 商户订阅列表响应
+type ListMerchantSubscriptionsResponse struct {
+	Total         int64                  `json:"total"`
+	Page          int                    `json:"page"`
+	PageSize      int                    `json:"page_size"`
+	Subscriptions []MerchantSubscription `json:"subscriptions"`
+}
+
+// ListMerchantSubscriptions 查询商户应用下的订阅
+// @Tags merchant
+// @Produce json
+// @Param id path uint64 true "API Key ID"
+// @Param request query ListMerchantSubscriptionsRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/merchant/api-keys/{id}/subscriptions [get]
+func ListMerchantSubscriptions(c *gin.Context) {
+	var req ListMerchantSubscriptionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, merchant.APIKeyObjKey)
+
+	baseQuery := db.DB(c.Request.Context()).
+		Table("subscriptions AS s").
+		Where("s.api_key_id = ?", apiKey.ID)
+	if req.Status != "" {
+		baseQuery = baseQuery.Where("s.status = ?", req.Status)
+	}
+
+	response := ListMerchantSubscriptionsResponse{
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	if err := baseQuery.Count(&response.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := baseQuery.
+		Select("s.*, u.username").
+		Joins("LEFT JOIN users u ON u.id = s.user_id").
+		Order("s.created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Scan(&response.Subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(response))
+}
+
+// QuerySubscriptionRequest 商户查询订阅请求
+type QuerySubscriptionRequest struct {
+	SubscriptionID uint64 `form:"subscription_id" binding:"required"`
+}
+
+// QuerySubscription 商户查询订阅状态（服务端调用）
+// @Tags subscription
+// @Produce json
+// @Param Authorization header string true "Basic Auth (base64(client_id:client_secret))"
+// @Param request query QuerySubscriptionRequest true "查询参数"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/subscription [get]
+func QuerySubscription(c *gin.Context) {
+	var req QuerySubscriptionRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, payment.APIKeyObjKey)
+
+	var subscription model.Subscription
+	if err := db.DB(c.Request.Context()).
+		Where("id = ? AND api_key_id = ?", req.SubscriptionID, apiKey.ID).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(SubscriptionNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(subscription))
+}
+
+// MerchantCancelSubscriptionRequest 商户取消订阅请求
+type MerchantCancelSubscriptionRequest struct {
+	SubscriptionID uint64 `json:"subscription_id,string" binding:"required"`
+}
+
+// MerchantCancelSubscription 商户立即终止订阅（服务端调用）
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Basic Auth (base64(client_id:client_secret))"
+// @Param request body MerchantCancelSubscriptionRequest true "取消请求"
+// @Success 200 {object} util.ResponseAny
+// @Router /pay/subscription/cancel [post]
+func MerchantCancelSubscription(c *gin.Context) {
+	var req MerchantCancelSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	apiKey, _ := util.GetFromContext[*model.MerchantAPIKey](c, payment.APIKeyObjKey)
+	ctx := c.Request.Context()
+
+	var subscription model.Subscription
+	if err := db.DB(ctx).
+		Select("id").
+		Where("id = ? AND api_key_id = ?", req.SubscriptionID, apiKey.ID).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, util.Err(SubscriptionNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	ended, err := endSubscription(db.DB(ctx), subscription.ID, model.SubscriptionStatusCanceled, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+	if !ended {
+		c.JSON(http.StatusBadRequest, util.Err(SubscriptionNotCancelable))
+		return
+	}
+
+	if err := enqueueSubscriptionNotify(subscription.ID, EventCanceled, 0); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/linux-do/credit/internal/apps/payment"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/task"
+	"github.com/linux-do/credit/internal/task/scheduler"
+	"github.com/linux-do/credit/internal/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errRenewSkipped 订阅已被处理，跳过本次续费
+var errRenewSkipped = errors.New("subscription renew skipped")
+
+// HandleRenewDueSubscriptions 扫描到期订阅并逐个下发续费任务
+func HandleRenewDueSubscriptions(ctx context.Context, t *asynq.Task) error {
+	pageSize := 1000
+	lastID := uint64(0)
+	now := time.Now()
+
+	for {
+		var subscriptions []model.Subscription
+		if err := db.DB(ctx).
+			Select("id, next_charge_at").
+			Where("id > ? AND status IN ? AND next_charge_at <= ?", lastID, model.SubscriptionLiveStatuses, now).
+			Order("id ASC").
+			Limit(pageSize).
+			Find(&subscriptions).Error; err != nil {
+			logger.ErrorF(ctx, "查询到期订阅失败: %v", err)
+			return err
+		}
+
+		if len(subscriptions) == 0 {
+			break
+		}
+
+		for _, subscription := range subscriptions {
+			payload, _ := json.Marshal(map[string]interface{}{
+				"subscription_id": subscription.ID,
+			})
+
+			// 同一计费时间点只下发一次
+			taskID := fmt.Sprintf("subscription:renew:%d:%d", subscription.ID, subscription.NextChargeAt.Unix())
+			if _, err := scheduler.AsynqClient.Enqueue(
+				asynq.NewTask(task.RenewSingleSubscriptionTask, payload),
+				asynq.TaskID(taskID),
+				asynq.MaxRetry(3),
+			); err != nil {
+				if errors.Is(err, asynq.ErrTaskIDConflict) {
+					continue
+				}
+				logger.ErrorF(ctx, "下发订阅[ID:%d]续费任务失败: %v", subscription.ID, err)
+				return err
+			}
+		}
+
+		lastID = subscriptions[len(subscriptions)-1].ID
+	}
+	return nil
+}
+
+// HandleRenewSingleSubscription 处理单个订阅的续费扣款
+func HandleRenewSingleSubscription(ctx context.Context, t *asynq.Task) error {
+	var payload struct {
+		SubscriptionID uint64 `json:"subscription_id"`
+	}
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("解析任务参数失败: %w", err)
+	}
+
+	now := time.Now()
+
+	var subscription model.Subscription
+	if err := db.DB(ctx).
+		Where("id = ? AND status IN ? AND next_charge_at <= ?", payload.SubscriptionID, model.SubscriptionLiveStatuses, now).
+		First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.InfoF(ctx, "订阅[ID:%d]未到期或已终止，跳过", payload.SubscriptionID)
+			return nil
+		}
+		return err
+	}
+
+	// 用户已申请到期取消
+	if subscription.CancelAtPeriodEnd {
+		ended, err := endSubscription(db.DB(ctx), subscription.ID, model.SubscriptionStatusCanceled, "")
+		if err != nil {
+			return err
+		}
+		if ended {
+			return enqueueSubscriptionNotify(subscription.ID, EventCanceled, 0)
+		}
+		return nil
+	}
+
+	var apiKey model.MerchantAPIKey
+	if err := apiKey.GetByID(db.DB(ctx), subscription.APIKeyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_, err = endSubscription(db.DB(ctx), subscription.ID, model.SubscriptionStatusCanceled, common.MerchantUnavailable)
+			return err
+		}
+		return err
+	}
+
+	var order *model.Order
+	err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked model.Subscription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+			Where("id = ? AND status IN ? AND next_charge_at <= ?", subscription.ID, model.SubscriptionLiveStatuses, now).
+			First(&locked).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errRenewSkipped
+			}
+			return err
+		}
+
+		var err error
+		order, err = service.DirectCharge(tx, service.DirectChargeOptions{
+			PayerID:        locked.UserID,
+			APIKey:         &apiKey,
+			Amount:         locked.Amount,
+			OrderName:      locked.ProductName,
+			Remark:         renewRemark,
+			OrderType:      model.OrderTypeOnline,
+			PaymentLinkID:  &locked.PaymentLinkID,
+			SubscriptionID: &locked.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 正常续费沿用原计费周期，宽限期内补缴则从补缴时刻重新计算
+		periodStart := locked.CurrentPeriodEnd
+		if locked.Status == model.SubscriptionStatusPastDue {
+			periodStart = now
+		}
+		periodEnd := locked.Interval.Add(periodStart, locked.IntervalCount)
+
+		return tx.Model(&locked).Updates(map[string]interface{}{
+			"status":               model.SubscriptionStatusActive,
+			"current_period_start": periodStart,
+			"current_period_end":   periodEnd,
+			"next_charge_at":       periodEnd,
+			"past_due_since":       nil,
+			"failed_attempts":      0,
+			"last_failure_reason":  "",
+		}).Error
+	})
+
+	switch {
+	case err == nil:
+		logger.InfoF(ctx, "订阅[ID:%d]续费成功: 订单[ID:%d]", subscription.ID, order.ID)
+		return enqueueSubscriptionNotify(subscription.ID, EventRenewed, order.ID)
+	case errors.Is(err, errRenewSkipped):
+		return nil
+	case isChargeFailure(err.Error()):
+		return markPastDue(ctx, subscription.ID, err.Error())
+	default:
+		return err
+	}
+}
+
+// markPastDue 记录续费失败；宽限期内进入 past_due 并安排重试，超过宽限期则终止订阅
+func markPastDue(ctx context.Context, subscriptionID uint64, reason string) error {
+	var event string
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var subscription model.Subscription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status IN ?", subscriptionID, model.SubscriptionLiveStatuses).
+			First(&subscription).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		now := time.Now()
+		if subscription.PastDueSince == nil {
+			subscription.PastDueSince = &now
+		}
+		graceEndsAt := subscription.GraceEndsAt()
+
+		if !now.Before(graceEndsAt) {
+			event = EventExpired
+			_, err := endSubscription(tx, subscription.ID, model.SubscriptionStatusExpired, reason)
+			return err
+		}
+
+		if subscription.Status == model.SubscriptionStatusActive {
+			event = EventPastDue
+		}
+
+		nextChargeAt := now.Add(pastDueRetryInterval)
+		if nextChargeAt.After(graceEndsAt) {
+			nextChargeAt = graceEndsAt
+		}
+
+		return tx.Model(&subscription).Updates(map[string]interface{}{
+			"status":              model.SubscriptionStatusPastDue,
+			"past_due_since":      subscription.PastDueSince,
+			"failed_attempts":     gorm.Expr("failed_attempts + 1"),
+			"last_failure_reason": reason,
+			"next_charge_at":      nextChargeAt,
+		}).Error
+	}); err != nil {
+		return err
+	}
+
+	logger.InfoF(ctx, "订阅[ID:%d]续费失败: %s", subscriptionID, reason)
+
+	if event == "" {
+		return nil
+	}
+	return enqueueSubscriptionNotify(subscriptionID, event, 0)
+}
+
+// HandleSubscriptionNotify 处理订阅事件商户回调任务
+func HandleSubscriptionNotify(ctx context.Context, t *asynq.Task) error {
+	var payload struct {
+		SubscriptionID uint64 `json:"subscription_id"`
+		Event          string `json:"event"`
+		OrderID        uint64 `json:"order_id"`
+	}
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.ErrorF(ctx, "解析订阅回调任务参数失败: %v", err)
+		return fmt.Errorf("解析任务参数失败: %w", err)
+	}
+
+	var subscription model.Subscription
+	if err := db.DB(ctx).Where("id = ?", payload.SubscriptionID).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.ErrorF(ctx, "订阅[ID:%d]不存在，跳过回调", payload.SubscriptionID)
+			return nil
+		}
+		return fmt.Errorf("查询订阅失败: %w", err)
+	}
+
+	var apiKey model.MerchantAPIKey
+	if err := apiKey.GetByID(db.DB(ctx), subscription.APIKeyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.ErrorF(ctx, "订阅[ID:%d]商户已删除，跳过回调", payload.SubscriptionID)
+			return nil
+		}
+		return fmt.Errorf("查询商户信息失败: %w", err)
+	}
+
+	if config.Config.App.IsProduction() && util.IsLocalhost(apiKey.NotifyURL) {
+		return nil
+	}
+
+	callbackParams := map[string]string{
+		"pid":                apiKey.ClientID,
+		"event":              payload.Event,
+		"subscription_id":    strconv.FormatUint(subscription.ID, 10),
+		"user_id":            strconv.FormatUint(subscription.UserID, 10),
+		"name":               subscription.ProductName,
+		"money":              subscription.Amount.Truncate(2).StringFixed(2),
+		"status":             string(subscription.Status),
+		"current_period_end": subscription.CurrentPeriodEnd.Format("2006-01-02 15:04:05"),
+		"sign_type":          "MD5",
+	}
+	if payload.OrderID != 0 {
+		callbackParams["trade_no"] = strconv.FormatUint(payload.OrderID, 10)
+	}
+
+	callbackParams["sign"] = payment.GenerateSignature(callbackParams, apiKey.ClientSecret)
+
+	if err := payment.SendCallbackRequest(ctx, apiKey.NotifyURL, callbackParams); err != nil {
+		retried, _ := asynq.GetRetryCount(ctx)
+		logger.ErrorF(ctx, "订阅回调失败: 订阅[ID:%d] 事件[%s] 重试次数[%d] 错误: %v",
+			payload.SubscriptionID, payload.Event, retried+1, err)
+		return err
+	}
+
+	logger.InfoF(ctx, "订阅回调成功: 订阅[ID:%d] 事件[%s]", payload.SubscriptionID, payload.Event)
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscription
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/task"
+	"github.com/linux-do/credit/internal/task/scheduler"
+	"gorm.io/gorm"
+)
+
+// enqueueSubscriptionNotify 下发订阅事件回调任务，orderID 为 0 表示事件无关联订单
+func enqueueSubscriptionNotify(subscriptionID uint64, event string, orderID uint64) error {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"subscription_id": subscriptionID,
+		"event":           event,
+		"order_id":        orderID,
+	})
+	if _, err := scheduler.AsynqClient.Enqueue(
+		asynq.NewTask(task.SubscriptionNotifyTask, payload),
+		asynq.Queue(task.QueueWebhook),
+		asynq.MaxRetry(10),
+		asynq.Timeout(30*time.Second),
+	); err != nil {
+		return fmt.Errorf("下发订阅回调任务失败: %w", err)
+	}
+	return nil
+}
+
+// checkSubscribeLimits 检查用户是否已订阅及链接订阅人数上限，需在已锁定付款人的事务中调用
+func checkSubscribeLimits(tx *gorm.DB, paymentLink *model.MerchantPaymentLink, userID uint64) error {
+	var existing int64
+	if err := tx.Model(&model.Subscription{}).
+		Where("payment_link_id = ? AND user_id = ? AND status IN ?", paymentLink.ID, userID, model.SubscriptionLiveStatuses).
+		Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return errors.New(AlreadySubscribed)
+	}
+
+	if paymentLink.TotalLimit == nil {
+		return nil
+	}
+
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", paymentLink.ID).Error; err != nil {
+		return err
+	}
+
+	var total int64
+	if err := tx.Model(&model.Subscription{}).
+		Where("payment_link_id = ? AND status IN ?", paymentLink.ID, model.SubscriptionLiveStatuses).
+		Count(&total).Error; err != nil {
+		return err
+	}
+	if total >= int64(*paymentLink.TotalLimit) {
+		return errors.New(SubscriptionTotalLimitExceeded)
+	}
+	return nil
+}
+
+// endSubscription 终止订阅
+func endSubscription(tx *gorm.DB, subscriptionID uint64, status model.SubscriptionStatus, reason string) (bool, error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":   status,
+		"ended_at": now,
+	}
+	if status == model.SubscriptionStatusCanceled {
+		updates["canceled_at"] = gorm.Expr("COALESCE(canceled_at, ?)", now)
+	}
+	if reason != "" {
+		updates["last_failure_reason"] = reason
+	}
+
+	result := tx.Model(&model.Subscription{}).
+		Where("id = ? AND status IN ?", subscriptionID, model.SubscriptionLiveStatuses).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// isChargeFailure 是否为续费扣款的业务失败（进入宽限期而非重试任务）
+func isChargeFailure(msg string) bool {
+	switch msg {
+	case common.InsufficientBalance, common.DailyLimitExceeded, common.BannedAccount,
+		common.MerchantUnavailable, common.CannotPaySelf, common.TestModeCannotProcessOrder,
+		common.PayKeyResetCoolingLimited:
+		return true
+	}
+	return false
+}
@@ -20,3 +20,10 @@ const (
 	// linuxDoAPIRateLimitKey Redis 限流 Key
 	linuxDoAPIRateLimitKey = "linux_do:api:rate_limit"
 )
+
+const (
+	// maxAccessTokensPerUser 每个用户可创建的个人访问令牌上限
+	maxAccessTokensPerUser = 10
+	// accessTokenDisplayPrefixLen 令牌列表中展示的前缀长度
+	accessTokenDisplayPrefixLen = 12
+)
@@ -17,5 +17,28 @@ limitations under the License.
 package user
 
 const (
-	EncryptPayKeyFailed = "加密支付密码失败"
+	UpdatePayKeyFailed = "更新支付密码失败"
+	OldPayKeyRequired  = "请输入原支付密码，忘记支付密码请通过重置流程修改"
+)
+
+const (
+	SessionNotFound     = "会话不存在或已失效"
+	RevokeSessionFailed = "撤销会话失败"
+)
+
+const (
+	AccessTokenNotFound         = "访问令牌不存在"
+	AccessTokenLimitReached     = "访问令牌数量已达上限"
+	AccessTokenScopeInvalid     = "访问令牌权限范围无效"
+	AccessTokenTransferLimitReq = "包含转账权限的令牌必须设置大于 0 的每日转账限额"
+	CreateAccessTokenFailed     = "创建访问令牌失败"
+)
+
+const (
+	TOTPAlreadyEnabled   = "已启用二次验证，请先关闭后再重新绑定"
+	TOTPNotEnabled       = "未启用二次验证"
+	TOTPNotSetup         = "请先生成二次验证密钥"
+	TOTPThresholdInvalid = "二次验证金额阈值不能小于 0"
+	TOTPSetupFailed      = "生成二次验证密钥失败"
+	TOTPUpdateFailed     = "更新二次验证配置失败"
 )
@@ -17,21 +17,35 @@ limitations under the License.
 package user
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
 	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UpdatePayKeyRequest 更新支付密钥请求
 type UpdatePayKeyRequest struct {
-	PayKey string `json:"pay_key" binding:"required,max=6"`
+	// OldPayKey 已设置支付密钥时必填，忘记时需通过重置接口修改
+	OldPayKey string `json:"old_pay_key" binding:"max=6"`
+	PayKey    string `json:"pay_key" binding:"required,max=6"`
 }
 
-// UpdatePayKey 更新用户支付密钥
+// UpdatePayKey 设置或修改用户支付密钥，修改时需验证原支付密钥
 // @Tags user
 // @Accept json
 // @Produce json
@@ -47,19 +61,632 @@ func UpdatePayKey(c *gin.Context) {
 
 	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
 
-	encryptedPayKey, err := util.Encrypt(user.SignKey, req.PayKey)
+	if user.PayKey != "" {
+		if req.OldPayKey == "" {
+			c.JSON(http.StatusBadRequest, util.Err(OldPayKeyRequired))
+			return
+		}
+		if err := service.VerifyPayKey(c.Request.Context(), user, req.OldPayKey, c.ClientIP()); err != nil {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+	}
+
+	if err := user.UpdatePayKey(db.DB(c.Request.Context()), req.PayKey); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(UpdatePayKeyFailed))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// ResetPayKeyRequest 重置支付密码请求
+type ResetPayKeyRequest struct {
+	StepUpToken string `json:"step_up_token" binding:"required"`
+	PayKey      string `json:"pay_key" binding:"required,max=6"`
+}
+
+// ResetPayKeyResponse 重置支付密码响应
+type ResetPayKeyResponse struct {
+	CoolingUntil *time.Time `json:"cooling_until"`
+}
+
+// ResetPayKey 通过 OAuth 再认证凭证重置支付密码，重置后进入冷静期
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body ResetPayKeyRequest true "request body"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/pay-key/reset [post]
+func ResetPayKey(c *gin.Context) {
+	var req ResetPayKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	if err := oauth.ConsumeStepUpToken(ctx, req.StepUpToken, user.ID, oauth.StepUpPurposePayKeyReset); err != nil {
+		if err.Error() == oauth.InvalidStepUpToken {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	if err := user.ResetPayKey(db.DB(ctx), req.PayKey); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(UpdatePayKeyFailed))
+		return
+	}
+
+	// 重置后清除之前的错误次数与锁定
+	if err := service.UnlockPayKey(ctx, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	model.RecordSecurityEvent(ctx, &model.UserSecurityLog{
+		UserID:    user.ID,
+		EventType: model.SecurityEventPayKeyReset,
+		IP:        c.ClientIP(),
+	})
+
+	coolingUntil, err := service.GetPayKeyCoolingUntil(ctx, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, util.Err(EncryptPayKeyFailed))
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(ResetPayKeyResponse{CoolingUntil: coolingUntil}))
+}
+
+// SessionResponse 登录会话
+type SessionResponse struct {
+	service.UserSession
+	IsCurrent bool `json:"is_current"`
+}
+
+// ListSessions 查询当前用户的全部登录会话
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/sessions [get]
+func ListSessions(c *gin.Context) {
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	currentSessionID := oauth.GetSessionIDFromContext(c)
+
+	userSessions, err := service.ListUserSessions(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	result := make([]SessionResponse, 0, len(userSessions))
+	for _, userSession := range userSessions {
+		result = append(result, SessionResponse{
+			UserSession: userSession,
+			IsCurrent:   userSession.ID == currentSessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, util.OK(result))
+}
+
+// RevokeSession 撤销指定登录会话
+// @Tags user
+// @Produce json
+// @Param id path string true "会话ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+	sessionID := c.Param("id")
+
+	revoked, err := service.RevokeUserSession(ctx, user.ID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(RevokeSessionFailed))
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, util.Err(SessionNotFound))
+		return
+	}
+
+	model.RecordSecurityEvent(ctx, &model.UserSecurityLog{
+		UserID:    user.ID,
+		EventType: model.SecurityEventSessionRevoked,
+		IP:        c.ClientIP(),
+		Remark:    fmt.Sprintf("撤销会话 %s", sessionID),
+	})
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// RevokeOtherSessions 撤销除当前会话外的全部登录会话
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/sessions [delete]
+func RevokeOtherSessions(c *gin.Context) {
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	if err := service.RevokeUserSessions(ctx, user.ID, oauth.GetSessionIDFromContext(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(RevokeSessionFailed))
 		return
 	}
 
+	model.RecordSecurityEvent(ctx, &model.UserSecurityLog{
+		UserID:    user.ID,
+		EventType: model.SecurityEventSessionRevoked,
+		IP:        c.ClientIP(),
+		Remark:    "撤销除当前会话外的全部会话",
+	})
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// CreateAccessTokenRequest 创建个人访问令牌请求
+type CreateAccessTokenRequest struct {
+	Name               string                   `json:"name" binding:"required,max=50"`
+	Scopes             []model.AccessTokenScope `json:"scopes" binding:"required,min=1"`
+	TransferDailyLimit decimal.Decimal          `json:"transfer_daily_limit"`
+	ExpiresInDays      int                      `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+// CreateAccessTokenResponse 创建个人访问令牌响应，明文令牌仅返回一次
+type CreateAccessTokenResponse struct {
+	model.UserAccessToken
+	Token string `json:"token"`
+}
+
+// ListAccessTokens 查询当前用户的个人访问令牌
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/access-tokens [get]
+func ListAccessTokens(c *gin.Context) {
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	var accessTokens []model.UserAccessToken
 	if err := db.DB(c.Request.Context()).
-		Model(&model.User{}).
-		Where("id = ?", user.ID).
-		Update("pay_key", encryptedPayKey).Error; err != nil {
+		Where("user_id = ?", user.ID).
+		Order("created_at DESC").
+		Find(&accessTokens).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
 		return
 	}
 
+	c.JSON(http.StatusOK, util.OK(accessTokens))
+}
+
+// CreateAccessToken 创建个人访问令牌
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body CreateAccessTokenRequest true "request body"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/access-tokens [post]
+func CreateAccessToken(c *gin.Context) {
+	var req CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	hasTransfer := false
+	for _, scope := range req.Scopes {
+		if !scope.Valid() {
+			c.JSON(http.StatusBadRequest, util.Err(AccessTokenScopeInvalid))
+			return
+		}
+		if slices.Contains(scopes, string(scope)) {
+			continue
+		}
+		scopes = append(scopes, string(scope))
+		hasTransfer = hasTransfer || scope == model.AccessTokenScopeTransfer
+	}
+
+	transferDailyLimit := decimal.Zero
+	if hasTransfer {
+		if err := util.ValidateAmount(req.TransferDailyLimit); err != nil {
+			c.JSON(http.StatusBadRequest, util.Err(AccessTokenTransferLimitReq))
+			return
+		}
+		transferDailyLimit = req.TransferDailyLimit
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	token := model.AccessTokenPrefix + util.GenerateUniqueIDSimple()
+	accessToken := model.UserAccessToken{
+		UserID:             user.ID,
+		Name:               req.Name,
+		TokenPrefix:        token[:accessTokenDisplayPrefixLen],
+		TokenHash:          model.HashAccessToken(token),
+		Scopes:             strings.Join(scopes, ","),
+		TransferDailyLimit: transferDailyLimit,
+		ExpiresAt:          time.Now().AddDate(0, 0, req.ExpiresInDays),
+	}
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定用户记录，避免并发创建超过上限
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", user.ID).
+			First(&model.User{}).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&model.UserAccessToken{}).
+			Where("user_id = ? AND expires_at > ?", user.ID, time.Now()).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxAccessTokensPerUser {
+			return errors.New(AccessTokenLimitReached)
+		}
+
+		return tx.Create(&accessToken).Error
+	}); err != nil {
+		if err.Error() == AccessTokenLimitReached {
+			c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(CreateAccessTokenFailed))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(CreateAccessTokenResponse{
+		UserAccessToken: accessToken,
+		Token:           token,
+	}))
+}
+
+// DeleteAccessToken 删除个人访问令牌，立即失效
+// @Tags user
+// @Produce json
+// @Param id path string true "令牌ID"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/access-tokens/{id} [delete]
+func DeleteAccessToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+
+	result := db.DB(c.Request.Context()).
+		Where("id = ? AND user_id = ?", id, user.ID).
+		Delete(&model.UserAccessToken{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(result.Error.Error()))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, util.Err(AccessTokenNotFound))
+		return
+	}
+
 	c.JSON(http.StatusOK, util.OKNil())
 }
+
+// TOTPStatusResponse 二次验证状态响应
+type TOTPStatusResponse struct {
+	Enabled                bool            `json:"enabled"`
+	Threshold              decimal.Decimal `json:"threshold"`
+	EnabledAt              *time.Time      `json:"enabled_at"`
+	RemainingRecoveryCodes int64           `json:"remaining_recovery_codes"`
+}
+
+// TOTPSetupResponse 二次验证绑定响应
+type TOTPSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURL string `json:"provisioning_url"`
+}
+
+// EnableTOTPRequest 启用二次验证请求
+type EnableTOTPRequest struct {
+	Code      string          `json:"code" binding:"required,max=16"`
+	Threshold decimal.Decimal `json:"threshold"`
+}
+
+// UpdateTOTPThresholdRequest 更新二次验证金额阈值请求
+type UpdateTOTPThresholdRequest struct {
+	Code      string          `json:"code" binding:"required,max=16"`
+	Threshold decimal.Decimal `json:"threshold"`
+}
+
+// TOTPCodeRequest 携带二次验证码的请求
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required,max=16"`
+}
+
+// DisableTOTPRequest 关闭二次验证请求
+type DisableTOTPRequest struct {
+	PayKey string `json:"pay_key" binding:"required,max=6"`
+	Code   string `json:"code" binding:"required,max=16"`
+}
+
+// TOTPRecoveryCodesResponse 恢复码响应（仅展示一次）
+type TOTPRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// GetTOTPStatus 获取二次验证状态
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/totp [get]
+func GetTOTPStatus(c *gin.Context) {
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var userTOTP model.UserTOTP
+	if err := db.DB(ctx).Where("user_id = ?", user.ID).First(&userTOTP).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusOK, util.OK(TOTPStatusResponse{Threshold: decimal.Zero}))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+		return
+	}
+
+	var remaining int64
+	if userTOTP.Enabled {
+		if err := db.DB(ctx).Model(&model.UserTOTPRecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Count(&remaining).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, util.OK(TOTPStatusResponse{
+		Enabled:                userTOTP.Enabled,
+		Threshold:              userTOTP.Threshold,
+		EnabledAt:              userTOTP.EnabledAt,
+		RemainingRecoveryCodes: remaining,
+	}))
+}
+
+// SetupTOTP 生成二次验证密钥（未启用前可重复生成，旧密钥作废）
+// @Tags user
+// @Produce json
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/totp/setup [post]
+func SetupTOTP(c *gin.Context) {
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(TOTPSetupFailed))
+		return
+	}
+	encryptedSecret, err := service.EncryptTOTPSecret(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(TOTPSetupFailed))
+		return
+	}
+
+	// 仅在未启用时覆盖密钥，已启用的配置不允许被替换
+	result := db.DB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"secret": encryptedSecret, "updated_at": time.Now()}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Eq{Column: clause.Column{Table: "user_totps", Name: "enabled"}, Value: false}}},
+	}).Create(&model.UserTOTP{UserID: user.ID, Secret: encryptedSecret, Threshold: decimal.Zero})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(TOTPSetupFailed))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, util.Err(TOTPAlreadyEnabled))
+		return
+	}
+
+	issuer := config.Config.App.AppName
+	c.JSON(http.StatusOK, util.OK(TOTPSetupResponse{
+		Secret:          secret,
+		ProvisioningURL: util.TOTPProvisioningURL(issuer, user.Username, secret),
+	}))
+}
+
+// EnableTOTP 校验验证码并启用二次验证，返回恢复码
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body EnableTOTPRequest true "request body"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/totp/enable [post]
+func EnableTOTP(c *gin.Context) {
+	var req EnableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	if req.Threshold.IsNegative() {
+		c.JSON(http.StatusBadRequest, util.Err(TOTPThresholdInvalid))
+		return
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	var recoveryCodes []string
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		var userTOTP model.UserTOTP
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", user.ID).
+			First(&userTOTP).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New(TOTPNotSetup)
+			}
+			return err
+		}
+		if userTOTP.Enabled {
+			return errors.New(TOTPAlreadyEnabled)
+		}
+
+		step, ok := service.MatchTOTPCode(userTOTP.Secret, strings.TrimSpace(req.Code), time.Now())
+		if !ok {
+			return errors.New(common.TOTPCodeIncorrect)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&userTOTP).Updates(map[string]interface{}{
+			"enabled":        true,
+			"threshold":      req.Threshold,
+			"last_used_step": step,
+			"enabled_at":     now,
+		}).Error; err != nil {
+			return err
+		}
+
+		codes, err := replaceRecoveryCodes(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		recoveryCodes = codes
+		return nil
+	}); err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	model.RecordSecurityEvent(ctx, &model.UserSecurityLog{
+		UserID:    user.ID,
+		EventType: model.SecurityEventTOTPEnabled,
+		IP:        c.ClientIP(),
+	})
+
+	c.JSON(http.StatusOK, util.OK(TOTPRecoveryCodesResponse{RecoveryCodes: recoveryCodes}))
+}
+
+// UpdateTOTPThreshold 更新需要二次验证的金额阈值
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body UpdateTOTPThresholdRequest true "request body"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/totp/threshold [put]
+func UpdateTOTPThreshold(c *gin.Context) {
+	var req UpdateTOTPThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+	if req.Threshold.IsNegative() {
+		c.JSON(http.StatusBadRequest, util.Err(TOTPThresholdInvalid))
+		return
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	userTOTP, err := verifyEnabledTOTP(c, user.ID, req.Code)
+	if err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	if err := db.DB(ctx).Model(userTOTP).Update("threshold", req.Threshold).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(TOTPUpdateFailed))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// DisableTOTP 关闭二次验证
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body DisableTOTPRequest true "request body"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/totp/disable [post]
+func DisableTOTP(c *gin.Context) {
+	var req DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	userTOTP, err := getEnabledTOTP(c, user.ID)
+	if err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+	if err := service.VerifyPayKeyAndTOTP(ctx, user, userTOTP, req.PayKey, req.Code, c.ClientIP()); err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.UserTOTPRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&model.UserTOTP{}).Error
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(TOTPUpdateFailed))
+		return
+	}
+
+	model.RecordSecurityEvent(ctx, &model.UserSecurityLog{
+		UserID:    user.ID,
+		EventType: model.SecurityEventTOTPDisabled,
+		IP:        c.ClientIP(),
+	})
+
+	c.JSON(http.StatusOK, util.OKNil())
+}
+
+// RegenerateTOTPRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body TOTPCodeRequest true "request body"
+// @Success 200 {object} util.ResponseAny
+// @Router /api/v1/user/totp/recovery-codes [post]
+func RegenerateTOTPRecoveryCodes(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+		return
+	}
+
+	user, _ := util.GetFromContext[*model.User](c, oauth.UserObjKey)
+	ctx := c.Request.Context()
+
+	if _, err := verifyEnabledTOTP(c, user.ID, req.Code); err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	var recoveryCodes []string
+	if err := db.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		codes, err := replaceRecoveryCodes(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		recoveryCodes = codes
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, util.Err(TOTPUpdateFailed))
+		return
+	}
+
+	c.JSON(http.StatusOK, util.OK(TOTPRecoveryCodesResponse{RecoveryCodes: recoveryCodes}))
+}
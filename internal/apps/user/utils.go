@@ -0,0 +1,82 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/service"
+	"github.com/linux-do/credit/internal/util"
+	"gorm.io/gorm"
+)
+
+// getEnabledTOTP 获取用户已启用的二次验证配置
+func getEnabledTOTP(c *gin.Context, userID uint64) (*model.UserTOTP, error) {
+	var userTOTP model.UserTOTP
+	if err := db.DB(c.Request.Context()).
+		Where("user_id = ? AND enabled = ?", userID, true).
+		First(&userTOTP).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(TOTPNotEnabled)
+		}
+		return nil, err
+	}
+	return &userTOTP, nil
+}
+
+// verifyEnabledTOTP 校验用户已启用二次验证且验证码（或恢复码）正确，失败计入支付验证失败次数
+func verifyEnabledTOTP(c *gin.Context, userID uint64, code string) (*model.UserTOTP, error) {
+	userTOTP, err := getEnabledTOTP(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.VerifyTOTPCode(c.Request.Context(), userTOTP, code, c.ClientIP()); err != nil {
+		return nil, err
+	}
+	return userTOTP, nil
+}
+
+// replaceRecoveryCodes 在事务中作废旧恢复码并生成新恢复码
+func replaceRecoveryCodes(tx *gorm.DB, userID uint64) ([]string, error) {
+	codes, records, err := service.GenerateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&model.UserTOTPRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// respondTOTPError 将二次验证相关的业务错误映射为 400，其余为 500
+func respondTOTPError(c *gin.Context, err error) {
+	switch err.Error() {
+	case TOTPNotSetup, TOTPNotEnabled, TOTPAlreadyEnabled, common.TOTPCodeIncorrect,
+		common.PayKeyIncorrect, common.PayKeyLocked, common.PayKeyAttemptTooFrequent:
+		c.JSON(http.StatusBadRequest, util.Err(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, util.Err(err.Error()))
+	}
+}
@@ -21,4 +21,10 @@ const (
 	PayTypeLDPay = "ldpay"
 	// PayTypeEPay Epay 支付类型
 	PayTypeEPay = "epay"
+	// PayTypeOAuth OAuth 授权应用免密扣款
+	PayTypeOAuth = "oauth"
+	// PayTypeMandate 自动扣款协议免密扣款
+	PayTypeMandate = "mandate"
+	// PayTypeInvoice 支付商户开具的账单
+	PayTypeInvoice = "invoice"
 )
@@ -17,23 +17,42 @@ limitations under the License.
 package common
 
 const (
-	BannedAccount                 = "账号已被封禁"
-	AmountMustBeGreaterThanZero   = "金额必须大于0"
-	AmountDecimalPlacesExceeded   = "金额小数位数不能超过2位"
-	RateMustBeBetweenZeroAndOne   = "比率必须在 0 到 1 之间"
-	RateDecimalPlacesExceeded     = "比率小数位数不能超过2位"
-	InsufficientBalance           = "余额不足"
-	DailyLimitExceeded            = "已超过每日限额"
-	PayKeyIncorrect               = "支付密钥错误"
-	CannotPaySelf                 = "不能给自己付款"
-	TestModeCannotProcessOrder    = "测试模式下无法处理订单"
-	TestModeOrderRemark           = "[测试模式] 此订单为测试订单，未实际扣款"
-	UnAuthorized                  = "未登录"
-	RedEnvelopeDisabled           = "红包功能未启用"
-	RedEnvelopeAmountExceeded     = "红包金额超过单个红包最大限额"
-	RedEnvelopeDailyLimitExceeded = "今日发红包数量已达上限"
-	RedEnvelopeRecipientsExceeded = "红包个数超过最大可领取人数上限"
-	RedEnvelopeMinAmountRequired  = "红包总金额不能低于1LDC"
+	BannedAccount                   = "账号已被封禁"
+	AmountMustBeGreaterThanZero     = "金额必须大于0"
+	AmountDecimalPlacesExceeded     = "金额小数位数不能超过2位"
+	RateMustBeBetweenZeroAndOne     = "比率必须在 0 到 1 之间"
+	RateDecimalPlacesExceeded       = "比率小数位数不能超过2位"
+	InsufficientBalance             = "余额不足"
+	HeldBalanceMismatch             = "冻结余额不足，资金状态异常"
+	DelayedTransferNotFound         = "转账不存在或已到账"
+	DelayedTransferUndoWindowClosed = "已超过撤回时间，转账即将到账"
+	TransferNotReturnable           = "转账不存在或已退回"
+	DailyLimitExceeded              = "已超过每日限额"
+	AccessTokenDailyLimitExceeded   = "已超过访问令牌每日转账限额"
+	PayKeyIncorrect                 = "支付密钥错误"
+	PayKeyLocked                    = "支付密钥错误次数过多，已被临时锁定"
+	PayKeyAttemptTooFrequent        = "支付密钥尝试过于频繁，请稍后再试"
+	PayKeyResetCoolingLimited       = "支付密钥重置后处于冷静期，转出金额超过冷静期限额"
+	TOTPCodeRequired                = "该操作需要二次验证码"
+	TOTPCodeIncorrect               = "二次验证码错误"
+	CannotPaySelf                   = "不能给自己付款"
+	MerchantUnavailable             = "商户不存在或已停用"
+	PaymentLinkOutOfStock           = "商品库存不足"
+	CouponNotFound                  = "优惠券不存在或已停用"
+	CouponNotStarted                = "优惠券尚未生效"
+	CouponExpired                   = "优惠券已过期"
+	CouponNotApplicable             = "该优惠券不适用于当前订单"
+	CouponMinAmountNotMet           = "订单金额未达到优惠券使用门槛"
+	CouponTotalLimitExceeded        = "优惠券已被领完"
+	CouponUserLimitExceeded         = "您已达到该优惠券的使用次数限制"
+	TestModeCannotProcessOrder      = "测试模式下无法处理订单"
+	TestModeOrderRemark             = "[测试模式] 此订单为测试订单，未实际扣款"
+	UnAuthorized                    = "未登录"
+	RedEnvelopeDisabled             = "红包功能未启用"
+	RedEnvelopeAmountExceeded       = "红包金额超过单个红包最大限额"
+	RedEnvelopeDailyLimitExceeded   = "今日发红包数量已达上限"
+	RedEnvelopeRecipientsExceeded   = "红包个数超过最大可领取人数上限"
+	RedEnvelopeMinAmountRequired    = "红包总金额不能低于1LDC"
 )
 
 const (
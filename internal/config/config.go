@@ -48,6 +48,11 @@ func init() {
 		log.Fatalf("[Config] parse config failed: %v\n", err)
 	}
 
+	// 静态数据加密密钥不能为空，否则派生出的密钥可被推算
+	if c.App.EncryptionKey == "" {
+		log.Fatalf("[Config] app.encryption_key is required\n")
+	}
+
 	// 设置全局配置
 	Config = &c
 
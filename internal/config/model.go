@@ -41,6 +41,7 @@ type appConfig struct {
 	FrontendPayURL          string `mapstructure:"frontend_pay_url"`
 	SessionCookieName       string `mapstructure:"session_cookie_name"`
 	SessionSecret           string `mapstructure:"session_secret"`
+	EncryptionKey           string `mapstructure:"encryption_key"`
 	SessionDomain           string `mapstructure:"session_domain"`
 	SessionAge              int    `mapstructure:"session_age"`
 	SessionHttpOnly         bool   `mapstructure:"session_http_only"`
@@ -148,6 +149,13 @@ type schedulerConfig struct {
 	SyncOrdersToClickHouseTaskCron           string `mapstructure:"sync_orders_to_clickhouse_task_cron"`
 	RefundExpiredRedEnvelopesTaskCron        string `mapstructure:"refund_expired_red_envelopes_task_cron"`
 	CleanupUnusedUploadsTaskCron             string `mapstructure:"cleanup_unused_uploads_task_cron"`
+	RenewSubscriptionsTaskCron               string `mapstructure:"renew_subscriptions_task_cron"`
+	InvoiceReminderTaskCron                  string `mapstructure:"invoice_reminder_task_cron"`
+	AutoSettleEscrowTradesTaskCron           string `mapstructure:"auto_settle_escrow_trades_task_cron"`
+	ExpireAuthorizationsTaskCron             string `mapstructure:"expire_authorizations_task_cron"`
+	DispatchScheduledTransfersTaskCron       string `mapstructure:"dispatch_scheduled_transfers_task_cron"`
+	SettleDelayedTransfersTaskCron           string `mapstructure:"settle_delayed_transfers_task_cron"`
+	ReconcileRedEnvelopeClaimsTaskCron       string `mapstructure:"reconcile_red_envelope_claims_task_cron"`
 }
 
 // workerConfig 工作配置
@@ -25,6 +25,7 @@ import (
 	"github.com/linux-do/credit/internal/config"
 	"github.com/linux-do/credit/internal/db"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm/clause"
 )
 
 func Migrate() {
@@ -37,12 +38,30 @@ func Migrate() {
 		&model.UserPayConfig{},
 		&model.MerchantAPIKey{},
 		&model.MerchantPaymentLink{},
+		&model.PaymentLinkItem{},
+		&model.MerchantCoupon{},
+		&model.MerchantCouponLink{},
+		&model.Invoice{},
+		&model.InvoiceItem{},
+		&model.SplitBill{},
+		&model.SplitBillShare{},
+		&model.EscrowTrade{},
+		&model.ScheduledTransfer{},
 		&model.Order{},
 		&model.SystemConfig{},
 		&model.Dispute{},
 		&model.RedEnvelope{},
 		&model.RedEnvelopeClaim{},
+		&model.RedEnvelopeRecipient{},
 		&model.Upload{},
+		&model.UserSecurityLog{},
+		&model.UserTOTP{},
+		&model.UserTOTPRecoveryCode{},
+		&model.UserAccessToken{},
+		&model.OAuthAuthorization{},
+		&model.OAuthToken{},
+		&model.PaymentMandate{},
+		&model.Subscription{},
 	); err != nil {
 		log.Fatalf("[PostgreSQL] auto migrate failed: %v\n", err)
 	}
@@ -59,16 +78,6 @@ func Migrate() {
 func initSystemConfigs() {
 	tx := db.DB(context.Background())
 
-	var count int64
-	if err := tx.Model(&model.SystemConfig{}).Count(&count).Error; err != nil {
-		log.Printf("[PostgreSQL] failed to check system_config table: %v\n", err)
-		return
-	}
-
-	if count > 0 {
-		return
-	}
-
 	defaultConfigs := []model.SystemConfig{
 		{
 			Key:         model.ConfigKeyMerchantOrderExpireMinutes,
@@ -135,12 +144,49 @@ func initSystemConfigs() {
 			Value:       "jpg,png,webp",
 			Description: "允许上传的图片扩展名（逗号分隔）",
 		},
+		{
+			Key:         model.ConfigKeyPayKeyResetCoolingHours,
+			Value:       "24",
+			Description: "支付密码重置后的冷静期（小时），期内限制转出",
+		},
+		{
+			Key:         model.ConfigKeyPayKeyResetTransferLimit,
+			Value:       "100",
+			Description: "支付密码重置冷静期内累计可转出的积分上限",
+		},
+		{
+			Key:         model.ConfigKeyEscrowDeliveryTimeoutDays,
+			Value:       "7",
+			Description: "担保交易卖家发货期限（天），超时未发货自动退款给买家",
+		},
+		{
+			Key:         model.ConfigKeyEscrowAutoReleaseDays,
+			Value:       "7",
+			Description: "担保交易发货后买家未确认收货，自动放款给卖家的天数",
+		},
+		{
+			Key:         model.ConfigKeyMerchantAuthHoldHours,
+			Value:       "168",
+			Description: "商户订单预授权冻结时长（小时），到期未扣款自动撤销并退回买家",
+		},
+		{
+			Key:         model.ConfigKeyTransferUndoWindowMinutes,
+			Value:       "5",
+			Description: "可撤回转账的撤回窗口（分钟），窗口内资金冻结且付款人可撤回，0 表示关闭",
+		},
+		{
+			Key:         model.ConfigKeyBatchTransferMaxRecipients,
+			Value:       "200",
+			Description: "单次批量转账的最大收款记录数",
+		},
 	}
 
-	if err := tx.Create(&defaultConfigs).Error; err != nil {
-		log.Printf("[PostgreSQL] failed to create default system configs: %v\n", err)
-	} else {
-		log.Printf("[PostgreSQL] initialized %d default system configs\n", len(defaultConfigs))
+	// 仅补充缺失的配置项，已存在的配置保留管理员修改后的值
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&defaultConfigs)
+	if result.Error != nil {
+		log.Printf("[PostgreSQL] failed to create default system configs: %v\n", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("[PostgreSQL] initialized %d default system configs\n", result.RowsAffected)
 	}
 }
 
@@ -0,0 +1,72 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// EscrowStatus 担保交易状态
+type EscrowStatus string
+
+const (
+	EscrowStatusFunded    EscrowStatus = "funded"    // 买家已付款，资金冻结中，待卖家发货
+	EscrowStatusDelivered EscrowStatus = "delivered" // 卖家已发货，待买家确认收货
+	EscrowStatusDisputing EscrowStatus = "disputing" // 争议中，等待管理员仲裁
+	EscrowStatusReleased  EscrowStatus = "released"  // 已放款给卖家
+	EscrowStatusRefunded  EscrowStatus = "refunded"  // 已退款给买家
+)
+
+// EscrowDisputableStatuses 可发起争议的状态
+var EscrowDisputableStatuses = []EscrowStatus{EscrowStatusFunded, EscrowStatusDelivered}
+
+// EscrowTrade 用户间担保交易，买家付款后资金冻结，确认收货后放款给卖家
+type EscrowTrade struct {
+	ID                uint64          `json:"id,string" gorm:"primaryKey"`
+	BuyerUserID       uint64          `json:"buyer_user_id,string" gorm:"not null;index:idx_escrow_trades_buyer_created,priority:1"`
+	SellerUserID      uint64          `json:"seller_user_id,string" gorm:"not null;index:idx_escrow_trades_seller_created,priority:1"`
+	OrderID           uint64          `json:"order_id,string" gorm:"not null;uniqueIndex"`
+	Title             string          `json:"title" gorm:"size:64;not null"`
+	Note              string          `json:"note" gorm:"size:255"`
+	Amount            decimal.Decimal `json:"amount" gorm:"type:numeric(20,2);not null"`
+	Status            EscrowStatus    `json:"status" gorm:"type:varchar(20);not null;index"`
+	DeliveryNote      string          `json:"delivery_note" gorm:"size:500"`    // 卖家填写的发货说明，如物流单号
+	DeliverBy         time.Time       `json:"deliver_by" gorm:"not null;index"` // 超过该时间仍未发货将自动退款
+	DeliveredAt       *time.Time      `json:"delivered_at"`
+	AutoReleaseAt     *time.Time      `json:"auto_release_at" gorm:"index"` // 发货后买家未确认，到期自动放款
+	DisputeReason     string          `json:"dispute_reason" gorm:"size:500"`
+	DisputedByUserID  *uint64         `json:"disputed_by_user_id,string"`
+	DisputedAt        *time.Time      `json:"disputed_at"`
+	ArbitratorUserID  *uint64         `json:"arbitrator_user_id,string"`
+	ArbitrationRemark string          `json:"arbitration_remark" gorm:"size:255"`
+	SettledAt         *time.Time      `json:"settled_at"`
+	BuyerUsername     string          `json:"buyer_username" gorm:"-:migration;->"`
+	SellerUsername    string          `json:"seller_username" gorm:"-:migration;->"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime;index:idx_escrow_trades_buyer_created,priority:2;index:idx_escrow_trades_seller_created,priority:2"`
+	UpdatedAt         time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (e *EscrowTrade) BeforeCreate(*gorm.DB) error {
+	if e.ID == 0 {
+		e.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// InvoiceStatus 账单状态
+type InvoiceStatus string
+
+const (
+	InvoiceStatusPending   InvoiceStatus = "pending"   // 待付款
+	InvoiceStatusOverdue   InvoiceStatus = "overdue"   // 已逾期，仍可付款
+	InvoiceStatusPaid      InvoiceStatus = "paid"      // 已付款
+	InvoiceStatusCancelled InvoiceStatus = "cancelled" // 已取消
+)
+
+// InvoicePayableStatuses 可付款的账单状态
+var InvoicePayableStatuses = []InvoiceStatus{InvoiceStatusPending, InvoiceStatusOverdue}
+
+// Invoice 向指定用户发起的收款账单
+type Invoice struct {
+	ID                uint64          `json:"id,string" gorm:"primaryKey"`
+	IssuerUserID      uint64          `json:"issuer_user_id,string" gorm:"not null;index:idx_invoices_issuer_status_created,priority:1"` // 收款方
+	RecipientUserID   uint64          `json:"recipient_user_id,string" gorm:"not null;index:idx_invoices_recipient_status_created,priority:1"`
+	ClientID          string          `json:"client_id" gorm:"size:64;uniqueIndex:idx_invoices_client_merchant_no,priority:1"` // 商户通过 API 开具时记录
+	MerchantInvoiceNo *string         `json:"merchant_invoice_no" gorm:"size:64;uniqueIndex:idx_invoices_client_merchant_no,priority:2"`
+	Title             string          `json:"title" gorm:"size:64;not null"`
+	Note              string          `json:"note" gorm:"size:255"`
+	Amount            decimal.Decimal `json:"amount" gorm:"type:numeric(20,2);not null"`
+	DueAt             time.Time       `json:"due_at" gorm:"not null;index"`
+	Status            InvoiceStatus   `json:"status" gorm:"type:varchar(20);not null;index:idx_invoices_issuer_status_created,priority:2;index:idx_invoices_recipient_status_created,priority:2"`
+	OrderID           *uint64         `json:"order_id,string" gorm:"index"`
+	PaidAt            *time.Time      `json:"paid_at"`
+	CancelledAt       *time.Time      `json:"cancelled_at"`
+	ReadAt            *time.Time      `json:"read_at"` // 收款人最近查看时间，提醒后重置为未读
+	RemindCount       uint            `json:"remind_count" gorm:"default:0"`
+	LastRemindedAt    *time.Time      `json:"last_reminded_at"`
+	IssuerUsername    string          `json:"issuer_username" gorm:"-:migration;->"`
+	RecipientUsername string          `json:"recipient_username" gorm:"-:migration;->"`
+	Items             []InvoiceItem   `json:"items,omitempty" gorm:"-"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime;index:idx_invoices_issuer_status_created,priority:3;index:idx_invoices_recipient_status_created,priority:3"`
+	UpdatedAt         time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (i *Invoice) BeforeCreate(*gorm.DB) error {
+	if i.ID == 0 {
+		i.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// InvoiceItem 账单明细
+type InvoiceItem struct {
+	ID        uint64          `json:"id,string" gorm:"primaryKey"`
+	InvoiceID uint64          `json:"invoice_id,string" gorm:"not null;index"`
+	Name      string          `json:"name" gorm:"size:64;not null"`
+	Quantity  uint            `json:"quantity" gorm:"not null"`
+	UnitPrice decimal.Decimal `json:"unit_price" gorm:"type:numeric(20,2);not null"`
+	Amount    decimal.Decimal `json:"amount" gorm:"type:numeric(20,2);not null"`
+}
+
+func (i *InvoiceItem) BeforeCreate(*gorm.DB) error {
+	if i.ID == 0 {
+		i.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// CouponDiscountType 优惠方式
+type CouponDiscountType string
+
+const (
+	CouponDiscountPercent CouponDiscountType = "percent" // 按比例折扣
+	CouponDiscountFixed   CouponDiscountType = "fixed"   // 固定金额立减
+)
+
+// Valid 是否为支持的优惠方式
+func (t CouponDiscountType) Valid() bool {
+	return t == CouponDiscountPercent || t == CouponDiscountFixed
+}
+
+// minPayAmount 使用优惠券后的最低实付金额
+var minPayAmount = decimal.New(1, -2)
+
+// MerchantCoupon 商户优惠券
+type MerchantCoupon struct {
+	ID               uint64             `json:"id,string" gorm:"primaryKey"`
+	MerchantAPIKeyID uint64             `json:"merchant_api_key_id,string" gorm:"not null;index:idx_merchant_coupons_key_code,priority:1"`
+	Code             string             `json:"code" gorm:"size:32;not null;index:idx_merchant_coupons_key_code,priority:2"` // 统一保存为大写
+	Name             string             `json:"name" gorm:"size:30"`
+	DiscountType     CouponDiscountType `json:"discount_type" gorm:"type:varchar(10);not null"`
+	DiscountValue    decimal.Decimal    `json:"discount_value" gorm:"type:numeric(20,2);not null"`   // 按比例时为减免百分比，固定金额时为立减金额
+	MaxDiscount      *decimal.Decimal   `json:"max_discount" gorm:"type:numeric(20,2);default:null"` // 按比例折扣的封顶金额
+	MinAmount        decimal.Decimal    `json:"min_amount" gorm:"type:numeric(20,2);default:0"`      // 订单金额达到该值才可使用
+	TotalLimit       *uint              `json:"total_limit" gorm:"default:null"`
+	UserLimit        *uint              `json:"user_limit" gorm:"default:null"`
+	Enabled          bool               `json:"enabled" gorm:"not null"`
+	StartsAt         *time.Time         `json:"starts_at"`
+	EndsAt           *time.Time         `json:"ends_at"`
+	PaymentLinkIDs   []string           `json:"payment_link_ids" gorm:"-"` // 限定可用的支付链接，为空表示不限
+	UsedCount        int64              `json:"used_count" gorm:"-:migration;->"`
+	CreatedAt        time.Time          `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt        time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt     `json:"deleted_at" gorm:"index"`
+}
+
+func (m *MerchantCoupon) BeforeCreate(*gorm.DB) error {
+	if m.ID == 0 {
+		m.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// Discount 计算指定金额可减免的金额，减免后实付金额不低于 0.01
+func (m *MerchantCoupon) Discount(amount decimal.Decimal) decimal.Decimal {
+	var discount decimal.Decimal
+	switch m.DiscountType {
+	case CouponDiscountPercent:
+		discount = amount.Mul(m.DiscountValue).Div(decimal.NewFromInt(100)).Truncate(2)
+		if m.MaxDiscount != nil && discount.GreaterThan(*m.MaxDiscount) {
+			discount = *m.MaxDiscount
+		}
+	case CouponDiscountFixed:
+		discount = m.DiscountValue
+	}
+
+	if limit := amount.Sub(minPayAmount); discount.GreaterThan(limit) {
+		discount = limit
+	}
+	if discount.IsNegative() {
+		return decimal.Zero
+	}
+	return discount
+}
+
+// MerchantCouponLink 优惠券适用的支付链接
+type MerchantCouponLink struct {
+	CouponID      uint64 `gorm:"primaryKey"`
+	PaymentLinkID uint64 `gorm:"primaryKey;index"`
+}
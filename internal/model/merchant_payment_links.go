@@ -25,17 +25,37 @@ import (
 )
 
 type MerchantPaymentLink struct {
-	ID               uint64          `json:"id,string" gorm:"primaryKey"`
-	MerchantAPIKeyID uint64          `json:"merchant_api_key_id,string" gorm:"not null;index"`
-	Token            string          `json:"token" gorm:"size:64;uniqueIndex;not null"`
-	Amount           decimal.Decimal `json:"amount" gorm:"type:numeric(20,2);not null"`
-	ProductName      string          `json:"product_name" gorm:"size:30;not null"`
-	Remark           string          `json:"remark" gorm:"size:100"`
-	TotalLimit       *uint           `json:"total_limit" gorm:"default:null"`
-	UserLimit        *uint           `json:"user_limit" gorm:"default:null"`
-	CreatedAt        time.Time       `json:"created_at" gorm:"autoCreateTime;index"`
-	UpdatedAt        time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt        gorm.DeletedAt  `json:"deleted_at" gorm:"index"`
+	ID               uint64                `json:"id,string" gorm:"primaryKey"`
+	MerchantAPIKeyID uint64                `json:"merchant_api_key_id,string" gorm:"not null;index"`
+	Token            string                `json:"token" gorm:"size:64;uniqueIndex;not null"`
+	Amount           decimal.Decimal       `json:"amount" gorm:"type:numeric(20,2);not null"`         // 固定金额；自定金额链接中为建议金额，0 表示无建议
+	MinAmount        *decimal.Decimal      `json:"min_amount" gorm:"type:numeric(20,2);default:null"` // 与 MaxAmount 任一非空即为自定金额链接
+	MaxAmount        *decimal.Decimal      `json:"max_amount" gorm:"type:numeric(20,2);default:null"`
+	ProductName      string                `json:"product_name" gorm:"size:30;not null"`
+	Remark           string                `json:"remark" gorm:"size:100"`
+	RequireRemark    bool                  `json:"require_remark" gorm:"default:false"` // 付款人必须填写备注
+	RemarkHint       string                `json:"remark_hint" gorm:"size:50"`          // 付款备注输入提示
+	AutoDelivery     bool                  `json:"auto_delivery" gorm:"default:false"`  // 付款成功后自动发放卡密
+	TotalLimit       *uint                 `json:"total_limit" gorm:"default:null"`
+	UserLimit        *uint                 `json:"user_limit" gorm:"default:null"`
+	Interval         *SubscriptionInterval `json:"interval" gorm:"type:varchar(10);default:null"` // 非空时为订阅链接
+	IntervalCount    uint                  `json:"interval_count" gorm:"default:0"`
+	GraceDays        uint                  `json:"grace_days" gorm:"default:0"` // 续费失败后的宽限天数
+	StartsAt         *time.Time            `json:"starts_at"`                   // 开放支付时间，为空表示立即开放
+	EndsAt           *time.Time            `json:"ends_at"`                     // 截止支付时间，为空表示长期有效
+	CreatedAt        time.Time             `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt        time.Time             `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt        `json:"deleted_at" gorm:"index"`
+}
+
+// IsVariableAmount 是否为付款人自定金额的链接
+func (m *MerchantPaymentLink) IsVariableAmount() bool {
+	return m.MinAmount != nil || m.MaxAmount != nil
+}
+
+// IsSubscription 是否为订阅链接
+func (m *MerchantPaymentLink) IsSubscription() bool {
+	return m.Interval != nil
 }
 
 // GetByToken 通过 Token 查询支付链接
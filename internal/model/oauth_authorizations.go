@@ -0,0 +1,142 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// OAuthScope 第三方应用授权范围
+type OAuthScope string
+
+const (
+	OAuthScopeProfile OAuthScope = "profile" // 读取用户 ID、用户名、昵称与头像
+	OAuthScopeBalance OAuthScope = "balance" // 读取可用余额
+	OAuthScopeCharge  OAuthScope = "charge"  // 在月度额度内免密扣款
+)
+
+// Valid 是否为支持的授权范围
+func (s OAuthScope) Valid() bool {
+	switch s {
+	case OAuthScopeProfile, OAuthScopeBalance, OAuthScopeCharge:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseOAuthScopes 解析空格分隔的授权范围并去重，存在不支持的范围时返回 false
+func ParseOAuthScopes(raw string) ([]OAuthScope, bool) {
+	fields := strings.Fields(raw)
+	scopes := make([]OAuthScope, 0, len(fields))
+	seen := make(map[OAuthScope]struct{}, len(fields))
+	for _, field := range fields {
+		scope := OAuthScope(field)
+		if !scope.Valid() {
+			return nil, false
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		scopes = append(scopes, scope)
+	}
+	return scopes, len(scopes) > 0
+}
+
+// JoinOAuthScopes 将授权范围拼接为空格分隔的字符串
+func JoinOAuthScopes(scopes []OAuthScope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, " ")
+}
+
+// hasOAuthScope 空格分隔的授权范围中是否包含指定范围
+func hasOAuthScope(scopes string, scope OAuthScope) bool {
+	for _, s := range strings.Fields(scopes) {
+		if OAuthScope(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthAuthorization 用户对第三方应用（MerchantAPIKey）的授权记录
+type OAuthAuthorization struct {
+	ID                 uint64          `json:"id,string" gorm:"primaryKey"`
+	UserID             uint64          `json:"user_id" gorm:"not null;uniqueIndex:idx_oauth_authorizations_user_app,priority:1"`
+	APIKeyID           uint64          `json:"api_key_id,string" gorm:"not null;uniqueIndex:idx_oauth_authorizations_user_app,priority:2;index"`
+	ClientID           string          `json:"client_id" gorm:"size:64;not null;index"`
+	Scopes             string          `json:"scopes" gorm:"size:255;not null"` // 空格分隔
+	MonthlyChargeLimit decimal.Decimal `json:"monthly_charge_limit" gorm:"type:numeric(20,2);default:0"`
+	RevokedAt          *time.Time      `json:"revoked_at"`
+	CreatedAt          time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (a *OAuthAuthorization) BeforeCreate(*gorm.DB) error {
+	if a.ID == 0 {
+		a.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// HasScope 授权是否包含指定范围
+func (a *OAuthAuthorization) HasScope(scope OAuthScope) bool {
+	return hasOAuthScope(a.Scopes, scope)
+}
+
+// OAuthToken 第三方应用的访问令牌与刷新令牌
+type OAuthToken struct {
+	ID               uint64     `json:"id,string" gorm:"primaryKey"`
+	AuthorizationID  uint64     `json:"authorization_id,string" gorm:"not null;index"`
+	UserID           uint64     `json:"user_id" gorm:"not null;index"`
+	APIKeyID         uint64     `json:"api_key_id,string" gorm:"not null;index"`
+	ClientID         string     `json:"client_id" gorm:"size:64;not null"`
+	Scopes           string     `json:"scopes" gorm:"size:255;not null"`
+	AccessTokenHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	AccessExpiresAt  time.Time  `json:"access_expires_at" gorm:"not null"`
+	RefreshTokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	RefreshExpiresAt time.Time  `json:"refresh_expires_at" gorm:"not null;index"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (t *OAuthToken) BeforeCreate(*gorm.DB) error {
+	if t.ID == 0 {
+		t.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// HasScope 令牌是否包含指定范围
+func (t *OAuthToken) HasScope(scope OAuthScope) bool {
+	return hasOAuthScope(t.Scopes, scope)
+}
+
+// GetByAccessToken 通过明文访问令牌查询有效令牌
+func (t *OAuthToken) GetByAccessToken(tx *gorm.DB, accessToken string) error {
+	return tx.Where("access_token_hash = ? AND access_expires_at > ? AND revoked_at IS NULL", HashAccessToken(accessToken), time.Now()).
+		First(t).Error
+}
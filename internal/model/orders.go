@@ -42,6 +42,9 @@ const (
 	OrderTypeRedEnvelopeSend    OrderType = "red_envelope_send"
 	OrderTypeRedEnvelopeReceive OrderType = "red_envelope_receive"
 	OrderTypeRedEnvelopeRefund  OrderType = "red_envelope_refund"
+	OrderTypeSplitBill          OrderType = "split_bill"
+	OrderTypeEscrow             OrderType = "escrow"
+	OrderTypeTransferReturn     OrderType = "transfer_return" // 收款人将转账原路退回付款人
 )
 
 type OrderStatus string
@@ -54,28 +57,53 @@ const (
 	OrderStatusDisputing OrderStatus = "disputing"
 	OrderStatusRefund    OrderStatus = "refund"
 	OrderStatusRefused   OrderStatus = "refused"
+	OrderStatusHeld      OrderStatus = "held"      // 资金已冻结，尚未结算
+	OrderStatusVoided    OrderStatus = "voided"    // 预授权被商户撤销
+	OrderStatusCancelled OrderStatus = "cancelled" // 可撤回转账在到账前被付款人撤回
+)
+
+// CaptureMode 商户订单扣款方式
+type CaptureMode string
+
+const (
+	CaptureModeAuto   CaptureMode = "auto"   // 支付后立即入账
+	CaptureModeManual CaptureMode = "manual" // 支付时仅冻结资金，由商户确认扣款或撤销
 )
 
 type Order struct {
-	ID              uint64          `json:"id,string" gorm:"primaryKey"`
-	OrderNo         string          `json:"order_no" gorm:"-"`
-	OrderName       string          `json:"order_name" gorm:"size:64;not null;index"`
-	MerchantOrderNo *string         `json:"merchant_order_no" gorm:"size:64;uniqueIndex:idx_orders_client_merchant_order,priority:2"`
-	ClientID        string          `json:"client_id" gorm:"size:64;index:idx_orders_client_status_created,priority:1;index:idx_orders_client_payee,priority:1;index:idx_orders_client_payer,priority:1;uniqueIndex:idx_orders_client_merchant_order,priority:1"`
-	PayerUserID     uint64          `json:"payer_user_id" gorm:"index:idx_orders_payer_status_type_created,priority:1;index:idx_orders_payer_status_type_trade,priority:1;index:idx_orders_client_payer,priority:2"`
-	PayeeUserID     uint64          `json:"payee_user_id" gorm:"index:idx_orders_payee_status_type_created,priority:1;index:idx_orders_client_payee,priority:2"`
-	PayerUsername   string          `json:"payer_username" gorm:"-:migration;->"`
-	PayeeUsername   string          `json:"payee_username" gorm:"-:migration;->"`
-	Amount          decimal.Decimal `json:"amount" gorm:"type:numeric(20,2);not null;index"`
-	Status          OrderStatus     `json:"status" gorm:"type:varchar(20);not null;index:idx_orders_payee_status_type_created,priority:2;index:idx_orders_payer_status_type_created,priority:2;index:idx_orders_client_status_created,priority:2;index:idx_orders_payer_status_type_trade,priority:2;index:idx_orders_payment_link_status,priority:2"`
-	Type            OrderType       `json:"type" gorm:"type:varchar(20);not null;index:idx_orders_payee_status_type_created,priority:3;index:idx_orders_payer_status_type_created,priority:3;index:idx_orders_payer_status_type_trade,priority:3"`
-	Remark          string          `json:"remark" gorm:"size:255"`
-	PaymentType     string          `json:"payment_type" gorm:"size:20"`
-	PaymentLinkID   *uint64         `json:"payment_link_id,string" gorm:"index:idx_orders_payment_link_status,priority:1"`
-	TradeTime       time.Time       `json:"trade_time" gorm:"index:idx_orders_payer_status_type_trade,priority:4"`
-	ExpiresAt       time.Time       `json:"expires_at" gorm:"not null"`
-	CreatedAt       time.Time       `json:"created_at" gorm:"autoCreateTime;index:idx_orders_payee_status_type_created,priority:4;index:idx_orders_payer_status_type_created,priority:4;index:idx_orders_client_status_created,priority:3"`
-	UpdatedAt       time.Time       `json:"updated_at" gorm:"autoUpdateTime;index"`
+	ID                  uint64           `json:"id,string" gorm:"primaryKey"`
+	OrderNo             string           `json:"order_no" gorm:"-"`
+	OrderName           string           `json:"order_name" gorm:"size:64;not null;index"`
+	MerchantOrderNo     *string          `json:"merchant_order_no" gorm:"size:64;uniqueIndex:idx_orders_client_merchant_order,priority:2"`
+	ClientID            string           `json:"client_id" gorm:"size:64;index:idx_orders_client_status_created,priority:1;index:idx_orders_client_payee,priority:1;index:idx_orders_client_payer,priority:1;uniqueIndex:idx_orders_client_merchant_order,priority:1"`
+	PayerUserID         uint64           `json:"payer_user_id" gorm:"index:idx_orders_payer_status_type_created,priority:1;index:idx_orders_payer_status_type_trade,priority:1;index:idx_orders_client_payer,priority:2"`
+	PayeeUserID         uint64           `json:"payee_user_id" gorm:"index:idx_orders_payee_status_type_created,priority:1;index:idx_orders_client_payee,priority:2"`
+	PayerUsername       string           `json:"payer_username" gorm:"-:migration;->"`
+	PayeeUsername       string           `json:"payee_username" gorm:"-:migration;->"`
+	Amount              decimal.Decimal  `json:"amount" gorm:"type:numeric(20,2);not null;index"`
+	OriginalAmount      *decimal.Decimal `json:"original_amount" gorm:"type:numeric(20,2);default:null"` // 使用优惠券时记录优惠前金额
+	DiscountAmount      decimal.Decimal  `json:"discount_amount" gorm:"type:numeric(20,2);default:0"`
+	CouponID            *uint64          `json:"coupon_id,string" gorm:"index"`
+	CaptureMode         CaptureMode      `json:"capture_mode" gorm:"type:varchar(10)"`
+	AuthorizedAmount    *decimal.Decimal `json:"authorized_amount" gorm:"type:numeric(20,2);default:null"` // 预授权冻结金额，部分扣款时 Amount 为实际扣款金额
+	AuthExpiresAt       *time.Time       `json:"auth_expires_at" gorm:"index"`                             // 预授权到期时间，到期未扣款自动撤销
+	Status              OrderStatus      `json:"status" gorm:"type:varchar(20);not null;index:idx_orders_payee_status_type_created,priority:2;index:idx_orders_payer_status_type_created,priority:2;index:idx_orders_client_status_created,priority:2;index:idx_orders_payer_status_type_trade,priority:2;index:idx_orders_payment_link_status,priority:2"`
+	Type                OrderType        `json:"type" gorm:"type:varchar(20);not null;index:idx_orders_payee_status_type_created,priority:3;index:idx_orders_payer_status_type_created,priority:3;index:idx_orders_payer_status_type_trade,priority:3"`
+	Remark              string           `json:"remark" gorm:"size:255"`
+	PaymentType         string           `json:"payment_type" gorm:"size:20"`
+	PaymentLinkID       *uint64          `json:"payment_link_id,string" gorm:"index:idx_orders_payment_link_status,priority:1"`
+	AccessTokenID       *uint64          `json:"-" gorm:"index"`                                 // 通过个人访问令牌发起时记录令牌 ID
+	MandateID           *uint64          `json:"mandate_id,string" gorm:"index"`                 // 通过自动扣款协议扣款时记录协议 ID
+	SubscriptionID      *uint64          `json:"subscription_id,string" gorm:"index"`            // 订阅开通及续费订单记录订阅 ID
+	SplitBillID         *uint64          `json:"split_bill_id,string" gorm:"index"`              // AA 收款订单记录所属账单 ID
+	EscrowTradeID       *uint64          `json:"escrow_trade_id,string" gorm:"index"`            // 担保交易订单记录所属交易 ID
+	ScheduledTransferID *uint64          `json:"scheduled_transfer_id,string" gorm:"index"`      // 定时转账执行生成的订单记录计划 ID
+	ReversalOfOrderID   *uint64          `json:"reversal_of_order_id,string" gorm:"uniqueIndex"` // 退回订单记录被退回的原转账订单 ID，每笔转账只能退回一次
+	SettlesAt           *time.Time       `json:"settles_at" gorm:"index"`                        // 可撤回转账的到账时间，到账前资金冻结且付款人可撤回
+	TradeTime           time.Time        `json:"trade_time" gorm:"index:idx_orders_payer_status_type_trade,priority:4"`
+	ExpiresAt           time.Time        `json:"expires_at" gorm:"not null"`
+	CreatedAt           time.Time        `json:"created_at" gorm:"autoCreateTime;index:idx_orders_payee_status_type_created,priority:4;index:idx_orders_payer_status_type_created,priority:4;index:idx_orders_client_status_created,priority:3"`
+	UpdatedAt           time.Time        `json:"updated_at" gorm:"autoUpdateTime;index"`
 }
 
 func (o *Order) BeforeCreate(*gorm.DB) error {
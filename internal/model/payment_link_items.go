@@ -0,0 +1,43 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"gorm.io/gorm"
+)
+
+// PaymentLinkItem 支付链接的卡密库存，付款成功后自动发放给买家
+type PaymentLinkItem struct {
+	ID            uint64     `json:"id,string" gorm:"primaryKey"`
+	PaymentLinkID uint64     `json:"payment_link_id,string" gorm:"not null;uniqueIndex:idx_payment_link_items_link_hash,priority:1;index:idx_payment_link_items_link_order,priority:1"`
+	Content       string     `json:"-" gorm:"type:text;not null"`                                                       // 加密存储
+	ContentHash   string     `json:"-" gorm:"size:64;not null;uniqueIndex:idx_payment_link_items_link_hash,priority:2"` // 用于同一链接内去重
+	OrderID       *uint64    `json:"order_id,string" gorm:"index;index:idx_payment_link_items_link_order,priority:2"`
+	BuyerUserID   *uint64    `json:"buyer_user_id" gorm:"index"`
+	AssignedAt    *time.Time `json:"assigned_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (i *PaymentLinkItem) BeforeCreate(*gorm.DB) error {
+	if i.ID == 0 {
+		i.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
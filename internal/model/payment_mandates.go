@@ -0,0 +1,99 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// MandateStatus 代扣协议状态
+type MandateStatus string
+
+const (
+	MandateStatusPending MandateStatus = "pending" // 待用户签约
+	MandateStatusActive  MandateStatus = "active"  // 已签约，可扣款
+	MandateStatusRevoked MandateStatus = "revoked" // 已解约
+)
+
+// MandatePeriod 代扣额度周期
+type MandatePeriod string
+
+const (
+	MandatePeriodDay   MandatePeriod = "day"
+	MandatePeriodWeek  MandatePeriod = "week"
+	MandatePeriodMonth MandatePeriod = "month"
+)
+
+// Valid 是否为支持的周期
+func (p MandatePeriod) Valid() bool {
+	switch p {
+	case MandatePeriodDay, MandatePeriodWeek, MandatePeriodMonth:
+		return true
+	}
+	return false
+}
+
+// PeriodStart 返回指定时间所在周期的起始时间（周从周一开始）
+func (p MandatePeriod) PeriodStart(now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch p {
+	case MandatePeriodWeek:
+		offset := (int(today.Weekday()) + 6) % 7
+		return today.AddDate(0, 0, -offset)
+	case MandatePeriodMonth:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	default:
+		return today
+	}
+}
+
+// PaymentMandate 商户自动扣款协议，用户签约后商户可在周期额度内免密扣款
+type PaymentMandate struct {
+	ID                uint64          `json:"id,string" gorm:"primaryKey"`
+	Token             string          `json:"-" gorm:"size:64;uniqueIndex;not null"` // 签约页凭证
+	APIKeyID          uint64          `json:"api_key_id,string" gorm:"not null;index"`
+	ClientID          string          `json:"client_id" gorm:"size:64;not null;uniqueIndex:idx_payment_mandates_client_mandate_no,priority:1"`
+	MerchantMandateNo *string         `json:"merchant_mandate_no" gorm:"size:64;uniqueIndex:idx_payment_mandates_client_mandate_no,priority:2"`
+	MerchantUserID    uint64          `json:"merchant_user_id" gorm:"not null;index"`
+	UserID            *uint64         `json:"user_id" gorm:"index"` // 签约用户，签约前为空
+	Name              string          `json:"name" gorm:"size:64;not null"`
+	Period            MandatePeriod   `json:"period" gorm:"type:varchar(10);not null"`
+	PeriodLimit       decimal.Decimal `json:"period_limit" gorm:"type:numeric(20,2);not null"`      // 每周期累计扣款上限
+	PerChargeLimit    decimal.Decimal `json:"per_charge_limit" gorm:"type:numeric(20,2);default:0"` // 单笔扣款上限，0 表示不单独限制
+	Status            MandateStatus   `json:"status" gorm:"type:varchar(20);not null;index"`
+	SignExpiresAt     time.Time       `json:"sign_expires_at" gorm:"not null"`
+	SignedAt          *time.Time      `json:"signed_at"`
+	RevokedAt         *time.Time      `json:"revoked_at"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt         time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (m *PaymentMandate) BeforeCreate(*gorm.DB) error {
+	if m.ID == 0 {
+		m.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// GetByToken 通过签约凭证查询协议
+func (m *PaymentMandate) GetByToken(tx *gorm.DB, token string) error {
+	return tx.Where("token = ?", token).First(m).Error
+}
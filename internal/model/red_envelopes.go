@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type RedEnvelopeType string
@@ -49,14 +50,27 @@ type RedEnvelope struct {
 	TotalCount          int               `json:"total_count" gorm:"not null"`
 	RemainingCount      int               `json:"remaining_count" gorm:"not null"`
 	Greeting            string            `json:"greeting" gorm:"size:100"`
+	Exclusive           bool              `json:"exclusive" gorm:"not null;default:false"`         // 专属红包，仅领取名单内的用户可领取
+	MinTrustLevel       TrustLevel        `json:"min_trust_level" gorm:"not null;default:0"`       // 领取所需的最低信任等级
+	MinAccountAgeDays   int               `json:"min_account_age_days" gorm:"not null;default:0"`  // 领取所需的最短注册天数
+	RequirePayScore     bool              `json:"require_pay_score" gorm:"not null;default:false"` // 排除支付积分为 0 的用户
+	Passphrase          string            `json:"-" gorm:"size:32"`                                // 口令红包的口令，不对外返回
+	HasPassphrase       bool              `json:"has_passphrase" gorm:"-"`
 	Status              RedEnvelopeStatus `json:"status" gorm:"type:varchar(20);not null"`
 	CoverUploadID       *uint64           `json:"cover_upload_id,string,omitempty" gorm:"index"`
 	HeterotypicUploadID *uint64           `json:"heterotypic_upload_id,string,omitempty" gorm:"index"`
-	ExpiresAt           time.Time         `json:"expires_at" gorm:"not null;index"`
+	OpensAt             *time.Time        `json:"opens_at"`                         // 定时开启时间，为空表示创建后立即可领取
+	ExpiresAt           time.Time         `json:"expires_at" gorm:"not null;index"` // 过期时间，定时红包从开启时间起算
 	CreatedAt           time.Time         `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt           time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
+// AfterFind 标记是否为口令红包，口令本身不对外返回
+func (r *RedEnvelope) AfterFind(*gorm.DB) error {
+	r.HasPassphrase = r.Passphrase != ""
+	return nil
+}
+
 // RedEnvelopeClaim 红包领取记录
 type RedEnvelopeClaim struct {
 	ID            uint64          `json:"id,string" gorm:"primaryKey"`
@@ -67,3 +81,12 @@ type RedEnvelopeClaim struct {
 	Amount        decimal.Decimal `json:"amount" gorm:"type:numeric(20,2);not null"`
 	ClaimedAt     time.Time       `json:"claimed_at" gorm:"autoCreateTime"`
 }
+
+// RedEnvelopeRecipient 专属红包的领取名单
+type RedEnvelopeRecipient struct {
+	RedEnvelopeID uint64    `json:"red_envelope_id,string" gorm:"primaryKey"`
+	UserID        uint64    `json:"user_id,string" gorm:"primaryKey;index"`
+	Username      string    `json:"username" gorm:"-:migration;->"`
+	AvatarURL     string    `json:"avatar_url" gorm:"-:migration;->"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ScheduledTransferFrequency 定时转账执行频率
+type ScheduledTransferFrequency string
+
+const (
+	ScheduledTransferOnce    ScheduledTransferFrequency = "once"    // 指定时间执行一次
+	ScheduledTransferWeekly  ScheduledTransferFrequency = "weekly"  // 每周执行
+	ScheduledTransferMonthly ScheduledTransferFrequency = "monthly" // 每月执行
+)
+
+// RunAt 返回从 start 起第 seq 次执行的时间，按起始时间推算避免月末日期漂移累积
+func (f ScheduledTransferFrequency) RunAt(start time.Time, seq uint) time.Time {
+	n := int(seq)
+	switch f {
+	case ScheduledTransferWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case ScheduledTransferMonthly:
+		return start.AddDate(0, n, 0)
+	default:
+		return start
+	}
+}
+
+// ScheduledTransferStatus 定时转账状态
+type ScheduledTransferStatus string
+
+const (
+	ScheduledTransferStatusActive    ScheduledTransferStatus = "active"    // 等待执行
+	ScheduledTransferStatusPaused    ScheduledTransferStatus = "paused"    // 用户暂停或连续失败后自动暂停
+	ScheduledTransferStatusCompleted ScheduledTransferStatus = "completed" // 单次转账已执行或周期转账已到截止时间
+	ScheduledTransferStatusFailed    ScheduledTransferStatus = "failed"    // 单次转账执行失败
+	ScheduledTransferStatusCancelled ScheduledTransferStatus = "cancelled" // 用户取消
+)
+
+// ScheduledTransferLiveStatuses 尚未结束的定时转账状态
+var ScheduledTransferLiveStatuses = []ScheduledTransferStatus{ScheduledTransferStatusActive, ScheduledTransferStatusPaused}
+
+// ScheduledTransfer 定时转账与周期转账
+type ScheduledTransfer struct {
+	ID                uint64                     `json:"id,string" gorm:"primaryKey"`
+	UserID            uint64                     `json:"user_id,string" gorm:"not null;index:idx_scheduled_transfers_user_created,priority:1"`
+	RecipientUserID   uint64                     `json:"recipient_user_id,string" gorm:"not null"`
+	Amount            decimal.Decimal            `json:"amount" gorm:"type:numeric(20,2);not null"`
+	Remark            string                     `json:"remark" gorm:"size:100"`
+	Frequency         ScheduledTransferFrequency `json:"frequency" gorm:"type:varchar(10);not null"`
+	StartAt           time.Time                  `json:"start_at" gorm:"not null"`
+	EndsAt            *time.Time                 `json:"ends_at"`                   // 周期转账截止时间，为空表示不限
+	Sequence          uint                       `json:"sequence" gorm:"default:0"` // 已经过的执行周期数，含失败跳过的周期
+	NextRunAt         time.Time                  `json:"next_run_at" gorm:"not null;index:idx_scheduled_transfers_status_next_run,priority:2"`
+	Status            ScheduledTransferStatus    `json:"status" gorm:"type:varchar(20);not null;index:idx_scheduled_transfers_status_next_run,priority:1"`
+	RunCount          uint                       `json:"run_count" gorm:"default:0"` // 成功执行次数
+	LastRunAt         *time.Time                 `json:"last_run_at"`
+	LastOrderID       *uint64                    `json:"last_order_id,string"`
+	FailureCount      uint                       `json:"failure_count" gorm:"default:0"` // 连续失败次数，成功后清零
+	LastError         string                     `json:"last_error" gorm:"size:255"`
+	LastFailedAt      *time.Time                 `json:"last_failed_at"`
+	FailureReadAt     *time.Time                 `json:"failure_read_at"` // 用户最近一次查看失败通知的时间
+	RecipientUsername string                     `json:"recipient_username" gorm:"-:migration;->"`
+	CreatedAt         time.Time                  `json:"created_at" gorm:"autoCreateTime;index:idx_scheduled_transfers_user_created,priority:2"`
+	UpdatedAt         time.Time                  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (s *ScheduledTransfer) BeforeCreate(*gorm.DB) error {
+	if s.ID == 0 {
+		s.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// NextRunAfter 返回晚于 now 的下一次执行序号与时间，错过的周期直接跳过
+// ok 为 false 表示没有后续执行：单次转账或已超过截止时间
+func (s *ScheduledTransfer) NextRunAfter(now time.Time) (seq uint, runAt time.Time, ok bool) {
+	if s.Frequency == ScheduledTransferOnce {
+		return s.Sequence, s.NextRunAt, false
+	}
+
+	seq = s.Sequence + 1
+	runAt = s.Frequency.RunAt(s.StartAt, seq)
+	for !runAt.After(now) {
+		seq++
+		runAt = s.Frequency.RunAt(s.StartAt, seq)
+	}
+	if s.EndsAt != nil && runAt.After(*s.EndsAt) {
+		return seq, runAt, false
+	}
+	return seq, runAt, true
+}
+
+// HasUnreadFailure 是否有用户尚未查看的执行失败
+func (s *ScheduledTransfer) HasUnreadFailure() bool {
+	return s.LastFailedAt != nil && (s.FailureReadAt == nil || s.FailureReadAt.Before(*s.LastFailedAt))
+}
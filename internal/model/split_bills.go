@@ -0,0 +1,100 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// SplitBillMode 分摊方式
+type SplitBillMode string
+
+const (
+	SplitBillModeEqual  SplitBillMode = "equal"  // 平均分摊
+	SplitBillModeCustom SplitBillMode = "custom" // 自定义每人金额
+)
+
+// SplitBillStatus AA 收款状态
+type SplitBillStatus string
+
+const (
+	SplitBillStatusOpen      SplitBillStatus = "open"      // 收款中
+	SplitBillStatusCompleted SplitBillStatus = "completed" // 所有份额均已支付或取消
+	SplitBillStatusCancelled SplitBillStatus = "cancelled" // 发起人关闭收款
+)
+
+// SplitBillShareStatus 参与人份额状态
+type SplitBillShareStatus string
+
+const (
+	SplitBillShareStatusPending   SplitBillShareStatus = "pending"
+	SplitBillShareStatusPaid      SplitBillShareStatus = "paid"
+	SplitBillShareStatusCancelled SplitBillShareStatus = "cancelled"
+)
+
+// SplitBill AA 收款
+type SplitBill struct {
+	ID                uint64           `json:"id,string" gorm:"primaryKey"`
+	OrganizerUserID   uint64           `json:"organizer_user_id,string" gorm:"not null;index:idx_split_bills_organizer_created,priority:1"`
+	Title             string           `json:"title" gorm:"size:64;not null"`
+	Note              string           `json:"note" gorm:"size:255"`
+	Mode              SplitBillMode    `json:"mode" gorm:"type:varchar(10);not null"`
+	TotalAmount       decimal.Decimal  `json:"total_amount" gorm:"type:numeric(20,2);not null"`   // 账单总金额
+	CollectAmount     decimal.Decimal  `json:"collect_amount" gorm:"type:numeric(20,2);not null"` // 需向参与人收取的金额，不含发起人自付部分
+	IncludeOrganizer  bool             `json:"include_organizer" gorm:"default:false"`            // 平均分摊时发起人是否计入人数
+	Status            SplitBillStatus  `json:"status" gorm:"type:varchar(20);not null;index"`
+	ClosedAt          *time.Time       `json:"closed_at"`
+	OrganizerUsername string           `json:"organizer_username" gorm:"-:migration;->"`
+	Shares            []SplitBillShare `json:"shares,omitempty" gorm:"-"`
+	CreatedAt         time.Time        `json:"created_at" gorm:"autoCreateTime;index:idx_split_bills_organizer_created,priority:2"`
+	UpdatedAt         time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (b *SplitBill) BeforeCreate(*gorm.DB) error {
+	if b.ID == 0 {
+		b.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// SplitBillShare 参与人应付份额
+type SplitBillShare struct {
+	ID             uint64               `json:"id,string" gorm:"primaryKey"`
+	BillID         uint64               `json:"bill_id,string" gorm:"not null;uniqueIndex:idx_split_bill_shares_bill_user,priority:1"`
+	UserID         uint64               `json:"user_id,string" gorm:"not null;uniqueIndex:idx_split_bill_shares_bill_user,priority:2;index:idx_split_bill_shares_user_status,priority:1"`
+	Amount         decimal.Decimal      `json:"amount" gorm:"type:numeric(20,2);not null"`
+	Status         SplitBillShareStatus `json:"status" gorm:"type:varchar(20);not null;index:idx_split_bill_shares_user_status,priority:2"`
+	OrderID        *uint64              `json:"order_id,string"`
+	PaidAt         *time.Time           `json:"paid_at"`
+	CancelledAt    *time.Time           `json:"cancelled_at"`
+	RemindCount    uint                 `json:"remind_count" gorm:"default:0"`
+	LastRemindedAt *time.Time           `json:"last_reminded_at"`
+	Username       string               `json:"username" gorm:"-:migration;->"`
+	CreatedAt      time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (s *SplitBillShare) BeforeCreate(*gorm.DB) error {
+	if s.ID == 0 {
+		s.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// SubscriptionInterval 订阅计费周期单位
+type SubscriptionInterval string
+
+const (
+	SubscriptionIntervalDay   SubscriptionInterval = "day"
+	SubscriptionIntervalWeek  SubscriptionInterval = "week"
+	SubscriptionIntervalMonth SubscriptionInterval = "month"
+	SubscriptionIntervalYear  SubscriptionInterval = "year"
+)
+
+// Valid 是否为支持的计费周期
+func (i SubscriptionInterval) Valid() bool {
+	switch i {
+	case SubscriptionIntervalDay, SubscriptionIntervalWeek, SubscriptionIntervalMonth, SubscriptionIntervalYear:
+		return true
+	}
+	return false
+}
+
+// Add 返回 t 之后 count 个周期的时间
+func (i SubscriptionInterval) Add(t time.Time, count uint) time.Time {
+	n := int(count)
+	switch i {
+	case SubscriptionIntervalWeek:
+		return t.AddDate(0, 0, 7*n)
+	case SubscriptionIntervalMonth:
+		return t.AddDate(0, n, 0)
+	case SubscriptionIntervalYear:
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+// SubscriptionStatus 订阅状态
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive   SubscriptionStatus = "active"   // 正常
+	SubscriptionStatusPastDue  SubscriptionStatus = "past_due" // 续费失败，处于宽限期
+	SubscriptionStatusCanceled SubscriptionStatus = "canceled" // 已取消
+	SubscriptionStatusExpired  SubscriptionStatus = "expired"  // 宽限期内未能续费，已终止
+)
+
+// SubscriptionLiveStatuses 仍在计费周期内的订阅状态
+var SubscriptionLiveStatuses = []SubscriptionStatus{SubscriptionStatusActive, SubscriptionStatusPastDue}
+
+// Subscription 用户通过订阅支付链接开通的周期扣费订阅
+type Subscription struct {
+	ID                 uint64               `json:"id,string" gorm:"primaryKey"`
+	PaymentLinkID      uint64               `json:"payment_link_id,string" gorm:"not null;index"`
+	APIKeyID           uint64               `json:"api_key_id,string" gorm:"not null;index"`
+	ClientID           string               `json:"client_id" gorm:"size:64;not null;index"`
+	MerchantUserID     uint64               `json:"merchant_user_id" gorm:"not null;index"`
+	UserID             uint64               `json:"user_id" gorm:"not null;index"`
+	ProductName        string               `json:"product_name" gorm:"size:30;not null"`
+	Amount             decimal.Decimal      `json:"amount" gorm:"type:numeric(20,2);not null"` // 开通时锁定的单期金额
+	Interval           SubscriptionInterval `json:"interval" gorm:"type:varchar(10);not null"`
+	IntervalCount      uint                 `json:"interval_count" gorm:"not null"`
+	GraceDays          uint                 `json:"grace_days" gorm:"not null"`
+	Status             SubscriptionStatus   `json:"status" gorm:"type:varchar(20);not null;index:idx_subscriptions_status_next_charge,priority:1"`
+	CurrentPeriodStart time.Time            `json:"current_period_start"`
+	CurrentPeriodEnd   time.Time            `json:"current_period_end"`
+	NextChargeAt       time.Time            `json:"next_charge_at" gorm:"index:idx_subscriptions_status_next_charge,priority:2"`
+	CancelAtPeriodEnd  bool                 `json:"cancel_at_period_end" gorm:"default:false"`
+	PastDueSince       *time.Time           `json:"past_due_since"`
+	FailedAttempts     int                  `json:"failed_attempts" gorm:"default:0"`
+	LastFailureReason  string               `json:"last_failure_reason" gorm:"size:255"`
+	CanceledAt         *time.Time           `json:"canceled_at"`
+	EndedAt            *time.Time           `json:"ended_at"`
+	CreatedAt          time.Time            `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt          time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (s *Subscription) BeforeCreate(*gorm.DB) error {
+	if s.ID == 0 {
+		s.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// GraceEndsAt 宽限期截止时间，仅在 past_due 状态下有意义
+func (s *Subscription) GraceEndsAt() time.Time {
+	if s.PastDueSince == nil {
+		return s.CurrentPeriodEnd.AddDate(0, 0, int(s.GraceDays))
+	}
+	return s.PastDueSince.AddDate(0, 0, int(s.GraceDays))
+}
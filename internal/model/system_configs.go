@@ -44,6 +44,13 @@ const (
 	ConfigKeyRedEnvelopeMaxRecipients   = "red_envelope_max_recipients"   // 每个红包的最大可领取人数上限
 	ConfigKeyUserBalanceStatsCacheTTL   = "user_balance_stats_cache_ttl"  // 用户余额统计缓存过期时间（秒)
 	ConfigKeyUploadAllowedExtensions    = "upload_allowed_extensions"     // 允许上传的文件扩展名，逗号分隔
+	ConfigKeyPayKeyResetCoolingHours    = "pay_key_reset_cooling_hours"   // 支付密码重置后的冷静期（小时）
+	ConfigKeyPayKeyResetTransferLimit   = "pay_key_reset_transfer_limit"  // 冷静期内累计可转出的积分上限
+	ConfigKeyEscrowDeliveryTimeoutDays  = "escrow_delivery_timeout_days"  // 担保交易卖家发货期限（天），超时自动退款
+	ConfigKeyEscrowAutoReleaseDays      = "escrow_auto_release_days"      // 担保交易发货后自动确认收货天数
+	ConfigKeyMerchantAuthHoldHours      = "merchant_auth_hold_hours"      // 商户订单预授权冻结时长（小时），到期未扣款自动撤销
+	ConfigKeyTransferUndoWindowMinutes  = "transfer_undo_window_minutes"  // 可撤回转账的撤回窗口（分钟），0 表示关闭
+	ConfigKeyBatchTransferMaxRecipients = "batch_transfer_max_recipients" // 单次批量转账的最大收款记录数
 )
 
 const (
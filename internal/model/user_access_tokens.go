@@ -0,0 +1,93 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// AccessTokenPrefix 个人访问令牌前缀，便于识别与泄露扫描
+const AccessTokenPrefix = "ldc_pat_"
+
+// AccessTokenScope 个人访问令牌权限范围
+type AccessTokenScope string
+
+const (
+	AccessTokenScopeBalanceRead      AccessTokenScope = "balance:read"      // 查询余额与账户信息
+	AccessTokenScopeTransactionsRead AccessTokenScope = "transactions:read" // 查询交易记录
+	AccessTokenScopeTransfer         AccessTokenScope = "transfer"          // 发起转账（受每日限额约束）
+)
+
+// Valid 是否为支持的权限范围
+func (s AccessTokenScope) Valid() bool {
+	switch s {
+	case AccessTokenScopeBalanceRead, AccessTokenScopeTransactionsRead, AccessTokenScopeTransfer:
+		return true
+	default:
+		return false
+	}
+}
+
+// UserAccessToken 用户个人访问令牌
+type UserAccessToken struct {
+	ID                 uint64          `json:"id,string" gorm:"primaryKey"`
+	UserID             uint64          `json:"user_id" gorm:"not null;index"`
+	Name               string          `json:"name" gorm:"size:50;not null"`
+	TokenPrefix        string          `json:"token_prefix" gorm:"size:16;not null"` // 令牌前几位，仅用于展示
+	TokenHash          string          `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Scopes             string          `json:"scopes" gorm:"size:255;not null"` // 逗号分隔
+	TransferDailyLimit decimal.Decimal `json:"transfer_daily_limit" gorm:"type:numeric(20,2);default:0"`
+	ExpiresAt          time.Time       `json:"expires_at" gorm:"not null;index"`
+	LastUsedAt         *time.Time      `json:"last_used_at"`
+	CreatedAt          time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (t *UserAccessToken) BeforeCreate(*gorm.DB) error {
+	if t.ID == 0 {
+		t.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// HasScope 令牌是否拥有指定权限范围
+func (t *UserAccessToken) HasScope(scope AccessTokenScope) bool {
+	for _, s := range strings.Split(t.Scopes, ",") {
+		if AccessTokenScope(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GetByToken 通过明文令牌查询未过期的访问令牌
+func (t *UserAccessToken) GetByToken(tx *gorm.DB, token string) error {
+	return tx.Where("token_hash = ? AND expires_at > ?", HashAccessToken(token), time.Now()).First(t).Error
+}
+
+// HashAccessToken 计算访问令牌的存储哈希
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/linux-do/credit/internal/logger"
+	"gorm.io/gorm"
+)
+
+// SecurityEventType 安全事件类型
+type SecurityEventType string
+
+const (
+	SecurityEventPayKeyFailed   SecurityEventType = "pay_key_failed"   // 支付密码验证失败
+	SecurityEventPayKeyLocked   SecurityEventType = "pay_key_locked"   // 支付密码被锁定
+	SecurityEventPayKeyUnlocked SecurityEventType = "pay_key_unlocked" // 管理员解除支付密码锁定
+	SecurityEventPayKeyReset    SecurityEventType = "pay_key_reset"    // 再认证后重置支付密码
+	SecurityEventSessionRevoked SecurityEventType = "session_revoked"  // 登录会话被撤销
+	SecurityEventTOTPEnabled    SecurityEventType = "totp_enabled"     // 启用 TOTP 二次验证
+	SecurityEventTOTPDisabled   SecurityEventType = "totp_disabled"    // 关闭 TOTP 二次验证
+	SecurityEventTOTPRecovery   SecurityEventType = "totp_recovery"    // 使用 TOTP 恢复码
+)
+
+// UserSecurityLog 用户安全审计记录
+type UserSecurityLog struct {
+	ID         uint64            `json:"id,string" gorm:"primaryKey"`
+	UserID     uint64            `json:"user_id" gorm:"not null;index:idx_user_security_logs_user_created,priority:1"`
+	EventType  SecurityEventType `json:"event_type" gorm:"type:varchar(32);not null;index"`
+	OperatorID *uint64           `json:"operator_id" gorm:"index"`
+	IP         string            `json:"ip" gorm:"size:64"`
+	Remark     string            `json:"remark" gorm:"size:255"`
+	CreatedAt  time.Time         `json:"created_at" gorm:"autoCreateTime;index:idx_user_security_logs_user_created,priority:2"`
+}
+
+func (l *UserSecurityLog) BeforeCreate(*gorm.DB) error {
+	if l.ID == 0 {
+		l.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
+
+// RecordSecurityEvent 写入安全审计记录，写入失败仅记录日志，不影响主流程
+func RecordSecurityEvent(ctx context.Context, log *UserSecurityLog) {
+	if err := db.DB(ctx).Create(log).Error; err != nil {
+		logger.ErrorF(ctx, "写入用户[%d]安全审计记录[%s]失败: %v", log.UserID, log.EventType, err)
+	}
+}
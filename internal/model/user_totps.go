@@ -0,0 +1,58 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/linux-do/credit/internal/db/idgen"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// UserTOTP 用户 TOTP 二次验证配置
+type UserTOTP struct {
+	UserID       uint64          `json:"user_id" gorm:"primaryKey"`
+	Secret       string          `json:"-" gorm:"size:255;not null"` // 使用应用密钥加密存储
+	Enabled      bool            `json:"enabled" gorm:"default:false"`
+	Threshold    decimal.Decimal `json:"threshold" gorm:"type:numeric(20,2);default:0"` // 金额达到该值时需要验证码，0 表示全部需要
+	LastUsedStep int64           `json:"-" gorm:"default:0"`                            // 最近一次使用的时间步，防止验证码重放
+	EnabledAt    *time.Time      `json:"enabled_at"`
+	CreatedAt    time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// RequiresCode 指定金额的操作是否需要 TOTP 验证码
+func (t *UserTOTP) RequiresCode(amount decimal.Decimal) bool {
+	return t.Enabled && amount.GreaterThanOrEqual(t.Threshold)
+}
+
+// UserTOTPRecoveryCode TOTP 恢复码
+type UserTOTPRecoveryCode struct {
+	ID        uint64     `json:"id,string" gorm:"primaryKey"`
+	UserID    uint64     `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (r *UserTOTPRecoveryCode) BeforeCreate(*gorm.DB) error {
+	if r.ID == 0 {
+		r.ID = idgen.NextUint64ID()
+	}
+	return nil
+}
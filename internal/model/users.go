@@ -105,9 +105,11 @@ type User struct {
 	TotalCommunity   decimal.Decimal `json:"total_community" gorm:"type:numeric(20,2);default:0"`
 	CommunityBalance decimal.Decimal `json:"community_balance" gorm:"type:numeric(20,2);default:0"`
 	AvailableBalance decimal.Decimal `json:"available_balance" gorm:"type:numeric(20,2);default:0;index:idx_users_avail_bal_id,priority:1"`
+	HeldBalance      decimal.Decimal `json:"held_balance" gorm:"type:numeric(20,2);default:0"` // 担保交易等场景下冻结、暂不可用的余额
 	IsActive         bool            `json:"is_active" gorm:"default:true"`
 	IsAdmin          bool            `json:"is_admin" gorm:"default:false"`
 	LastLoginAt      time.Time       `json:"last_login_at" gorm:"index"`
+	PayKeyResetAt    *time.Time      `json:"pay_key_reset_at"`
 	CreatedAt        time.Time       `json:"created_at" gorm:"autoCreateTime;index"`
 	UpdatedAt        time.Time       `json:"updated_at" gorm:"autoUpdateTime;index"`
 }
@@ -129,11 +131,17 @@ func GetByIDs(tx *gorm.DB, ids []uint64) ([]User, error) {
 }
 
 // VerifyPayKey 验证用户支付密码
-// 使用用户的 SignKey 解密存储的加密密码，然后与输入的明文密码比较
+// 优先按 argon2id 哈希校验；历史数据仍为 SignKey 加密存储，解密后与输入的明文密码比较
 func (u *User) VerifyPayKey(inputPayKey string) bool {
 	if u.PayKey == "" {
 		return false
 	}
+
+	if util.IsPasswordHash(u.PayKey) {
+		ok, err := util.VerifyPassword(u.PayKey, inputPayKey)
+		return err == nil && ok
+	}
+
 	decrypted, err := util.Decrypt(u.SignKey, u.PayKey)
 	if err != nil {
 		return false
@@ -142,6 +150,50 @@ func (u *User) VerifyPayKey(inputPayKey string) bool {
 	return subtle.ConstantTimeCompare([]byte(decrypted), []byte(inputPayKey)) == 1
 }
 
+// PayKeyNeedsRehash 支付密码是否仍为旧的可逆加密存储，需要重新哈希
+func (u *User) PayKeyNeedsRehash() bool {
+	return u.PayKey != "" && !util.IsPasswordHash(u.PayKey)
+}
+
+// UpdatePayKey 使用 argon2id 哈希并更新用户支付密码
+func (u *User) UpdatePayKey(tx *gorm.DB, payKey string) error {
+	hashedPayKey, err := util.HashPassword(payKey)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(&User{}).
+		Where("id = ?", u.ID).
+		Update("pay_key", hashedPayKey).Error; err != nil {
+		return err
+	}
+
+	u.PayKey = hashedPayKey
+	return nil
+}
+
+// ResetPayKey 通过身份再认证重置支付密码，并记录重置时间用于冷静期限制
+func (u *User) ResetPayKey(tx *gorm.DB, payKey string) error {
+	hashedPayKey, err := util.HashPassword(payKey)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Model(&User{}).
+		Where("id = ?", u.ID).
+		UpdateColumns(map[string]interface{}{
+			"pay_key":          hashedPayKey,
+			"pay_key_reset_at": now,
+		}).Error; err != nil {
+		return err
+	}
+
+	u.PayKey = hashedPayKey
+	u.PayKeyResetAt = &now
+	return nil
+}
+
 func (u *User) GetUserGamificationScore(ctx context.Context) (*UserGamificationScoreResponse, error) {
 	url := fmt.Sprintf("https://linux.do/u/%s.json", u.Username)
 	resp, err := util.Request(ctx, http.MethodGet, url, nil, nil, nil)
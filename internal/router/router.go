@@ -28,16 +28,24 @@ import (
 	"time"
 
 	"github.com/linux-do/credit/internal/apps/admin"
+	admin_escrow "github.com/linux-do/credit/internal/apps/admin/escrow"
 	admin_task "github.com/linux-do/credit/internal/apps/admin/task"
 	admin_user "github.com/linux-do/credit/internal/apps/admin/user"
+	"github.com/linux-do/credit/internal/apps/batchtransfer"
 	publicconfig "github.com/linux-do/credit/internal/apps/config"
 	"github.com/linux-do/credit/internal/apps/dispute"
+	"github.com/linux-do/credit/internal/apps/escrow"
 	"github.com/linux-do/credit/internal/apps/health"
+	"github.com/linux-do/credit/internal/apps/invoice"
 	"github.com/linux-do/credit/internal/apps/merchant/api_key"
+	"github.com/linux-do/credit/internal/apps/merchant/coupon"
 	"github.com/linux-do/credit/internal/apps/merchant/link"
 	"github.com/linux-do/credit/internal/apps/redenvelope"
+	"github.com/linux-do/credit/internal/apps/scheduledtransfer"
+	"github.com/linux-do/credit/internal/apps/splitbill"
 	"github.com/linux-do/credit/internal/apps/upload"
 	"github.com/linux-do/credit/internal/listener"
+	"github.com/linux-do/credit/internal/model"
 	"github.com/linux-do/credit/internal/util"
 
 	"github.com/linux-do/credit/internal/apps/payment"
@@ -50,8 +58,11 @@ import (
 	"github.com/linux-do/credit/internal/apps/admin/user_pay_config"
 	"github.com/linux-do/credit/internal/apps/dashboard"
 	"github.com/linux-do/credit/internal/apps/leaderboard"
+	"github.com/linux-do/credit/internal/apps/mandate"
 	"github.com/linux-do/credit/internal/apps/oauth"
+	"github.com/linux-do/credit/internal/apps/oauthprovider"
 	"github.com/linux-do/credit/internal/apps/order"
+	"github.com/linux-do/credit/internal/apps/subscription"
 	"github.com/linux-do/credit/internal/apps/user"
 	"github.com/linux-do/credit/internal/config"
 	"github.com/linux-do/credit/internal/otel_trace"
@@ -110,12 +121,30 @@ func Serve() {
 	r.GET("/api.php", payment.QueryMerchantOrder)
 	// 退款接口
 	r.POST("/api.php", payment.RefundMerchantOrder)
+	// 预授权扣款与撤销接口
+	r.POST("/pay/capture", payment.RequireMerchantAuth(), payment.CaptureMerchantOrder)
+	r.POST("/pay/void", payment.RequireMerchantAuth(), payment.VoidMerchantOrder)
 	// 商户分发接口
 	r.POST("/pay/distribute", payment.RequireMerchantAuth(), payment.MerchantDistribute)
+	// 自动扣款协议接口
+	r.POST("/pay/mandate", payment.RequireMerchantAuth(), mandate.CreateMandate)
+	r.GET("/pay/mandate", payment.RequireMerchantAuth(), mandate.QueryMandate)
+	r.POST("/pay/mandate/charge", payment.RequireMerchantAuth(), mandate.ChargeMandate)
+	// 订阅接口
+	r.GET("/pay/subscription", payment.RequireMerchantAuth(), subscription.QuerySubscription)
+	r.POST("/pay/subscription/cancel", payment.RequireMerchantAuth(), subscription.MerchantCancelSubscription)
+	// 账单接口
+	r.POST("/pay/invoice", payment.RequireMerchantAuth(), invoice.CreateMerchantInvoice)
+	r.GET("/pay/invoice", payment.RequireMerchantAuth(), invoice.QueryMerchantInvoice)
+	r.POST("/pay/invoice/cancel", payment.RequireMerchantAuth(), invoice.CancelMerchantInvoice)
 
 	// Serve files by ID
 	r.GET("/f/:id", upload.ServeFileByID)
 
+	// OAuth2 授权服务（RFC 6749）
+	r.POST("/oauth2/token", oauthprovider.Token)
+	r.POST("/oauth2/introspect", oauthprovider.Introspect)
+
 	apiGroup := r.Group(config.Config.App.APIPrefix)
 	{
 		if !config.Config.App.IsProduction() {
@@ -133,13 +162,96 @@ func Serve() {
 			apiV1Router.GET("/oauth/login", oauth.GetLoginURL)
 			apiV1Router.GET("/oauth/logout", oauth.LoginRequired(), oauth.Logout)
 			apiV1Router.POST("/oauth/callback", oauth.Callback)
-			apiV1Router.GET("/oauth/user-info", oauth.LoginRequired(), oauth.UserInfo)
+			apiV1Router.GET("/oauth/user-info", oauth.LoginRequired(model.AccessTokenScopeBalanceRead), oauth.UserInfo)
+
+			// OAuth2 Provider
+			oauth2Router := apiV1Router.Group("/oauth2")
+			{
+				oauth2Router.GET("/authorize", oauth.LoginRequired(), oauthprovider.GetAuthorizeInfo)
+				oauth2Router.POST("/authorize", oauth.LoginRequired(), oauthprovider.Authorize)
+				oauth2Router.GET("/userinfo", oauthprovider.RequireOAuthToken(model.OAuthScopeProfile), oauthprovider.UserInfo)
+				oauth2Router.GET("/balance", oauthprovider.RequireOAuthToken(model.OAuthScopeBalance), oauthprovider.Balance)
+				oauth2Router.POST("/charge", oauthprovider.RequireOAuthToken(model.OAuthScopeCharge), oauthprovider.Charge)
+			}
 
 			// User
 			userRouter := apiV1Router.Group("/user")
 			userRouter.Use(oauth.LoginRequired())
 			{
 				userRouter.PUT("/pay-key", user.UpdatePayKey)
+				userRouter.POST("/pay-key/reset", user.ResetPayKey)
+				userRouter.GET("/sessions", user.ListSessions)
+				userRouter.DELETE("/sessions", user.RevokeOtherSessions)
+				userRouter.DELETE("/sessions/:id", user.RevokeSession)
+				userRouter.GET("/access-tokens", user.ListAccessTokens)
+				userRouter.POST("/access-tokens", user.CreateAccessToken)
+				userRouter.DELETE("/access-tokens/:id", user.DeleteAccessToken)
+				userRouter.GET("/oauth-authorizations", oauthprovider.ListAuthorizations)
+				userRouter.DELETE("/oauth-authorizations/:id", oauthprovider.RevokeAuthorization)
+				userRouter.GET("/mandates", mandate.ListMandates)
+				userRouter.DELETE("/mandates/:id", mandate.RevokeMandate)
+				userRouter.GET("/subscriptions", subscription.ListMySubscriptions)
+				userRouter.POST("/subscriptions/:id/cancel", subscription.CancelMySubscription)
+				userRouter.GET("/totp", user.GetTOTPStatus)
+				userRouter.POST("/totp/setup", user.SetupTOTP)
+				userRouter.POST("/totp/enable", user.EnableTOTP)
+				userRouter.PUT("/totp/threshold", user.UpdateTOTPThreshold)
+				userRouter.POST("/totp/disable", user.DisableTOTP)
+				userRouter.POST("/totp/recovery-codes", user.RegenerateTOTPRecoveryCodes)
+			}
+
+			// Invoice
+			invoiceRouter := apiV1Router.Group("/invoices")
+			invoiceRouter.Use(oauth.LoginRequired())
+			{
+				invoiceRouter.POST("", invoice.CreateInvoice)
+				invoiceRouter.GET("/inbox", invoice.ListInboxInvoices)
+				invoiceRouter.GET("/inbox/summary", invoice.GetInboxSummary)
+				invoiceRouter.GET("/issued", invoice.ListIssuedInvoices)
+				invoiceRouter.GET("/:id", invoice.GetInvoice)
+				invoiceRouter.POST("/:id/pay", invoice.PayInvoice)
+				invoiceRouter.POST("/:id/cancel", invoice.CancelInvoice)
+				invoiceRouter.POST("/:id/remind", invoice.RemindInvoice)
+			}
+
+			// Split Bill
+			splitBillRouter := apiV1Router.Group("/split-bills")
+			splitBillRouter.Use(oauth.LoginRequired())
+			{
+				splitBillRouter.POST("", splitbill.CreateSplitBill)
+				splitBillRouter.GET("", splitbill.ListSplitBills)
+				splitBillRouter.GET("/shares", splitbill.ListMyShares)
+				splitBillRouter.GET("/:id", splitbill.GetSplitBill)
+				splitBillRouter.POST("/:id/pay", splitbill.PaySplitBillShare)
+				splitBillRouter.POST("/:id/cancel", splitbill.CancelSplitBill)
+				splitBillRouter.POST("/:id/shares/:shareId/remind", splitbill.RemindSplitBillShare)
+				splitBillRouter.POST("/:id/shares/:shareId/cancel", splitbill.CancelSplitBillShare)
+			}
+
+			// Escrow
+			escrowRouter := apiV1Router.Group("/escrow")
+			escrowRouter.Use(oauth.LoginRequired())
+			{
+				escrowRouter.POST("", escrow.CreateEscrowTrade)
+				escrowRouter.GET("", escrow.ListEscrowTrades)
+				escrowRouter.GET("/:id", escrow.GetEscrowTrade)
+				escrowRouter.POST("/:id/deliver", escrow.DeliverEscrowTrade)
+				escrowRouter.POST("/:id/confirm", escrow.ConfirmEscrowTrade)
+				escrowRouter.POST("/:id/refund", escrow.RefundEscrowTrade)
+				escrowRouter.POST("/:id/dispute", escrow.DisputeEscrowTrade)
+			}
+
+			// Scheduled Transfer
+			scheduledTransferRouter := apiV1Router.Group("/scheduled-transfers")
+			scheduledTransferRouter.Use(oauth.LoginRequired())
+			{
+				scheduledTransferRouter.POST("", scheduledtransfer.CreateScheduledTransfer)
+				scheduledTransferRouter.GET("", scheduledtransfer.ListScheduledTransfers)
+				scheduledTransferRouter.GET("/summary", scheduledtransfer.GetScheduledTransferSummary)
+				scheduledTransferRouter.GET("/:id", scheduledtransfer.GetScheduledTransfer)
+				scheduledTransferRouter.POST("/:id/pause", scheduledtransfer.PauseScheduledTransfer)
+				scheduledTransferRouter.POST("/:id/resume", scheduledtransfer.ResumeScheduledTransfer)
+				scheduledTransferRouter.POST("/:id/cancel", scheduledtransfer.CancelScheduledTransfer)
 			}
 
 			// Dashboard
@@ -163,27 +275,32 @@ func Serve() {
 
 			// Order
 			orderRouter := apiV1Router.Group("/order")
-			orderRouter.Use(oauth.LoginRequired())
 			{
-				orderRouter.POST("/transactions", order.ListTransactions)
-				orderRouter.POST("/dispute", dispute.CreateDispute)
-				orderRouter.POST("/disputes/merchant", dispute.ListMerchantDisputes)
-				orderRouter.POST("/disputes", dispute.ListDisputes)
-				orderRouter.POST("/refund-review", dispute.RefundReview)
-				orderRouter.POST("/dispute/close", dispute.CloseDispute)
+				orderRouter.POST("/transactions", oauth.LoginRequired(model.AccessTokenScopeTransactionsRead), order.ListTransactions)
+				orderRouter.GET("/:id", oauth.LoginRequired(), order.GetOrderDetail)
+				orderRouter.POST("/dispute", oauth.LoginRequired(), dispute.CreateDispute)
+				orderRouter.POST("/disputes/merchant", oauth.LoginRequired(), dispute.ListMerchantDisputes)
+				orderRouter.POST("/disputes", oauth.LoginRequired(), dispute.ListDisputes)
+				orderRouter.POST("/refund-review", oauth.LoginRequired(), dispute.RefundReview)
+				orderRouter.POST("/dispute/close", oauth.LoginRequired(), dispute.CloseDispute)
 			}
 
 			// Payment
 			paymentRouter := apiV1Router.Group("/payment")
-			paymentRouter.Use(oauth.LoginRequired())
 			{
-				paymentRouter.POST("/transfer", payment.Transfer)
+				paymentRouter.POST("/transfer", oauth.LoginRequired(model.AccessTokenScopeTransfer), payment.Transfer)
+				paymentRouter.POST("/transfer/:id/cancel", oauth.LoginRequired(model.AccessTokenScopeTransfer), payment.CancelDelayedTransfer)
+				paymentRouter.POST("/transfer/:id/return", oauth.LoginRequired(), payment.ReturnTransfer)
+				paymentRouter.POST("/transfer/batch", oauth.LoginRequired(model.AccessTokenScopeTransfer), batchtransfer.CreateBatchTransfer)
+				paymentRouter.POST("/transfer/batch/csv", oauth.LoginRequired(model.AccessTokenScopeTransfer), batchtransfer.CreateBatchTransferFromCSV)
 			}
 
 			// Red Envelope
 			redEnvelopeRouter := apiV1Router.Group("/redenvelope")
 			{
 				redEnvelopeRouter.GET("/covers", oauth.LoginRequired(), upload.ListRedEnvelopeCovers)
+				redEnvelopeRouter.GET("/stats", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.GetStats)
+				redEnvelopeRouter.GET("/leaderboard", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.GetLeaderboard)
 				redEnvelopeRouter.GET("/:id", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.GetDetail)
 				redEnvelopeRouter.POST("/create", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Create)
 				redEnvelopeRouter.POST("/claim", oauth.LoginRequired(), redenvelope.CheckRedEnvelopeEnabled(), redenvelope.Claim)
@@ -224,17 +341,45 @@ func Serve() {
 						linkRouter.POST("", link.CreatePaymentLink)
 						linkRouter.PUT("/:linkId", link.UpdatePaymentLink)
 						linkRouter.DELETE("/:linkId", link.DeletePaymentLink)
+
+						// 发卡库存
+						itemRouter := linkRouter.Group("/:linkId/items")
+						itemRouter.Use(link.RequireOwnedPaymentLink())
+						{
+							itemRouter.GET("", link.ListPaymentLinkItems)
+							itemRouter.POST("", link.UploadPaymentLinkItems)
+							itemRouter.DELETE("/:itemId", link.DeletePaymentLinkItem)
+						}
+					}
+
+					// Subscriptions
+					apiKeyRouter.GET("/subscriptions", subscription.ListMerchantSubscriptions)
+
+					// Coupons
+					couponRouter := apiKeyRouter.Group("/coupons")
+					{
+						couponRouter.GET("", coupon.ListCoupons)
+						couponRouter.POST("", coupon.CreateCoupon)
+						couponRouter.PUT("/:couponId", coupon.UpdateCoupon)
+						couponRouter.DELETE("/:couponId", coupon.DeleteCoupon)
 					}
 				}
 
 				merchantRouter.GET("/payment-links/:token", oauth.LoginRequired(), link.GetPaymentLinkByToken)
 				merchantRouter.POST("/payment-links/pay", oauth.LoginRequired(), link.PayByLink)
+				merchantRouter.POST("/payment-links/coupon", oauth.LoginRequired(), link.PreviewLinkCoupon)
+				merchantRouter.POST("/payment-links/subscribe", oauth.LoginRequired(), subscription.Subscribe)
+
+				// 代扣协议签约
+				merchantRouter.GET("/mandates/:token", oauth.LoginRequired(), mandate.GetMandateByToken)
+				merchantRouter.POST("/mandates/sign", oauth.LoginRequired(), mandate.SignMandate)
 
 				// MerchantAPIKey Payment
 				MerchantPaymentRouter := merchantRouter.Group("/payment")
 				{
 					MerchantPaymentRouter.GET("/order", oauth.LoginRequired(), payment.GetPaymentPageDetails)
 					MerchantPaymentRouter.POST("", oauth.LoginRequired(), payment.PayMerchantOrder)
+					MerchantPaymentRouter.POST("/coupon", oauth.LoginRequired(), payment.PreviewOrderCoupon)
 				}
 			}
 
@@ -249,6 +394,11 @@ func Serve() {
 				// Users
 				adminRouter.GET("/users", admin_user.ListUsers)
 				adminRouter.PUT("/users/:id/status", admin_user.UpdateUserStatus)
+				adminRouter.PUT("/users/:id/pay-key/unlock", admin_user.UnlockUserPayKey)
+
+				// Escrow Trades
+				adminRouter.GET("/escrow-trades", admin_escrow.ListEscrowTrades)
+				adminRouter.POST("/escrow-trades/:id/arbitrate", admin_escrow.ArbitrateEscrowTrade)
 
 				// System Config
 				adminRouter.POST("/system-configs", system_config.CreateSystemConfig)
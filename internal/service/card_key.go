@@ -0,0 +1,84 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"gorm.io/gorm"
+)
+
+// cardKeyEncryptKey 卡密内容的加密密钥
+func cardKeyEncryptKey() string {
+	return util.DeriveKey(config.Config.App.EncryptionKey, "card_key")
+}
+
+// EncryptCardKey 加密卡密内容
+func EncryptCardKey(content string) (string, error) {
+	return util.Encrypt(cardKeyEncryptKey(), content)
+}
+
+// DecryptCardKey 解密卡密内容
+func DecryptCardKey(ciphertext string) (string, error) {
+	return util.Decrypt(cardKeyEncryptKey(), ciphertext)
+}
+
+// HashCardKey 计算卡密去重哈希，使用带密钥的 HMAC 避免短卡密被离线枚举
+func HashCardKey(content string) string {
+	mac := hmac.New(sha256.New, []byte(util.DeriveKey(config.Config.App.EncryptionKey, "card_key_hash")))
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AssignPaymentLinkItem 为订单原子分配一个未售出的卡密，需在创建订单的事务中调用
+// 并发购买时跳过已被其他事务锁定的卡密，库存不足返回 PaymentLinkOutOfStock
+func AssignPaymentLinkItem(tx *gorm.DB, paymentLinkID uint64, orderID uint64, buyerID uint64) error {
+	result := tx.Exec(`
+		UPDATE payment_link_items SET order_id = ?, buyer_user_id = ?, assigned_at = ?
+		WHERE id = (
+			SELECT id FROM payment_link_items
+			WHERE payment_link_id = ? AND order_id IS NULL
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)`,
+		orderID, buyerID, time.Now(), paymentLinkID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(common.PaymentLinkOutOfStock)
+	}
+	return nil
+}
+
+// CountPaymentLinkStock 统计支付链接剩余卡密数量
+func CountPaymentLinkStock(tx *gorm.DB, paymentLinkID uint64) (int64, error) {
+	var stock int64
+	err := tx.Model(&model.PaymentLinkItem{}).
+		Where("payment_link_id = ? AND order_id IS NULL", paymentLinkID).
+		Count(&stock).Error
+	return stock, err
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DirectChargeOptions 商户免密扣款参数
+type DirectChargeOptions struct {
+	PayerID         uint64
+	APIKey          *model.MerchantAPIKey
+	Amount          decimal.Decimal
+	OrderName       string
+	MerchantOrderNo *string
+	Remark          string
+	PaymentType     string
+	MandateID       *uint64
+	// OrderType 订单类型，默认为商户支付订单
+	OrderType      model.OrderType
+	PaymentLinkID  *uint64
+	SubscriptionID *uint64
+	// BeforeCharge 锁定付款人后、扣款前执行的额外额度检查，如授权的月度上限
+	BeforeCharge func(tx *gorm.DB, payer *model.User) error
+}
+
+// DirectCharge 基于用户事先授权完成一次免密扣款
+// 在事务中锁定付款人，校验每日限额，按商户费率结算并创建成功订单
+func DirectCharge(tx *gorm.DB, opts DirectChargeOptions) (*model.Order, error) {
+	var merchantUser model.User
+	if err := tx.Where("id = ? AND is_active = ?", opts.APIKey.UserID, true).First(&merchantUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(common.MerchantUnavailable)
+		}
+		return nil, err
+	}
+
+	if err := ValidateTestModePayment(opts.PayerID, merchantUser.ID, opts.APIKey.TestMode); err != nil {
+		return nil, err
+	}
+
+	var payer model.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND is_active = ?", opts.PayerID, true).
+		First(&payer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(common.BannedAccount)
+		}
+		return nil, err
+	}
+
+	if opts.BeforeCharge != nil {
+		if err := opts.BeforeCharge(tx, &payer); err != nil {
+			return nil, err
+		}
+	}
+
+	var payerPayConfig model.UserPayConfig
+	if err := payerPayConfig.GetByPayScore(tx, payer.PayScore); err != nil {
+		return nil, err
+	}
+	var merchantPayConfig model.UserPayConfig
+	if err := merchantPayConfig.GetByPayScore(tx, merchantUser.PayScore); err != nil {
+		return nil, err
+	}
+
+	isTestMode := opts.APIKey.TestMode
+	now := time.Now()
+
+	orderType := opts.OrderType
+	if orderType == "" {
+		orderType = model.OrderTypePayment
+	}
+
+	order := model.Order{
+		OrderName:       opts.OrderName,
+		ClientID:        opts.APIKey.ClientID,
+		MerchantOrderNo: opts.MerchantOrderNo,
+		PayerUserID:     payer.ID,
+		PayeeUserID:     merchantUser.ID,
+		Amount:          opts.Amount,
+		Status:          model.OrderStatusSuccess,
+		Type:            orderType,
+		Remark:          opts.Remark,
+		PaymentType:     opts.PaymentType,
+		MandateID:       opts.MandateID,
+		PaymentLinkID:   opts.PaymentLinkID,
+		SubscriptionID:  opts.SubscriptionID,
+		TradeTime:       now,
+		ExpiresAt:       now,
+	}
+
+	if isTestMode {
+		order.Type = model.OrderTypeTest
+		order.Remark = common.TestModeOrderRemark
+		order.PaymentLinkID = nil
+		if err := tx.Create(&order).Error; err != nil {
+			return nil, err
+		}
+		return &order, EnqueueMerchantNotify(order.ID, order.ClientID)
+	}
+
+	if err := CheckDailyLimit(tx, payer.ID, opts.Amount, payerPayConfig.DailyLimit); err != nil {
+		return nil, err
+	}
+	if err := CheckPayKeyResetCooling(tx, &payer, opts.Amount); err != nil {
+		return nil, err
+	}
+
+	_, merchantAmount, feePercent := CalculateFee(opts.Amount, merchantPayConfig.FeeRate)
+	feeRemark := fmt.Sprintf("[系统]: 收取商家%d%%手续费", feePercent)
+	if order.Remark != "" {
+		order.Remark = order.Remark + " " + feeRemark
+	} else {
+		order.Remark = feeRemark
+	}
+
+	if err := tx.Create(&order).Error; err != nil {
+		return nil, err
+	}
+
+	if err := UpdateBalance(tx, BalanceUpdateOptions{
+		UserID:       payer.ID,
+		Amount:       opts.Amount,
+		Operation:    BalanceDeduct,
+		ScoreChange:  opts.Amount.Round(0).IntPart(),
+		TotalField:   "total_payment",
+		CheckBalance: true,
+	}); err != nil {
+		return nil, err
+	}
+
+	merchantScoreIncrease := opts.Amount.Mul(merchantPayConfig.ScoreRate).Round(0).IntPart()
+	if err := UpdateBalance(tx, BalanceUpdateOptions{
+		UserID:       merchantUser.ID,
+		Amount:       merchantAmount,
+		Operation:    BalanceAdd,
+		ScoreChange:  merchantScoreIncrease,
+		TotalField:   "total_receive",
+		CheckBalance: false,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &order, EnqueueMerchantNotify(order.ID, order.ClientID)
+}
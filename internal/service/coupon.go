@@ -0,0 +1,155 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApplyCouponOptions 使用优惠券参数
+type ApplyCouponOptions struct {
+	APIKeyID      uint64
+	Code          string
+	PayerID       uint64
+	Amount        decimal.Decimal // 优惠前金额
+	PaymentLinkID *uint64         // 商户订单为空
+	// Lock 在支付事务中锁定优惠券，保证使用次数校验与订单创建的原子性
+	Lock bool
+}
+
+// CouponQuote 优惠券试算结果
+type CouponQuote struct {
+	CouponID       uint64          `json:"coupon_id,string"`
+	CouponName     string          `json:"coupon_name"`
+	OriginalAmount decimal.Decimal `json:"original_amount"`
+	DiscountAmount decimal.Decimal `json:"discount_amount"`
+	Amount         decimal.Decimal `json:"amount"`
+}
+
+// NormalizeCouponCode 优惠码不区分大小写
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// IsCouponError 是否为优惠券校验失败
+func IsCouponError(msg string) bool {
+	switch msg {
+	case common.CouponNotFound, common.CouponNotStarted, common.CouponExpired, common.CouponNotApplicable,
+		common.CouponMinAmountNotMet, common.CouponTotalLimitExceeded, common.CouponUserLimitExceeded:
+		return true
+	}
+	return false
+}
+
+// ApplyCoupon 校验优惠券并计算减免金额
+// 使用次数按非测试的成功订单统计，订单退款后名额自动释放
+func ApplyCoupon(tx *gorm.DB, opts ApplyCouponOptions) (*CouponQuote, error) {
+	query := tx.Where("merchant_api_key_id = ? AND code = ? AND enabled = ?", opts.APIKeyID, NormalizeCouponCode(opts.Code), true)
+	if opts.Lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var coupon model.MerchantCoupon
+	if err := query.First(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(common.CouponNotFound)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return nil, errors.New(common.CouponNotStarted)
+	}
+	if coupon.EndsAt != nil && !now.Before(*coupon.EndsAt) {
+		return nil, errors.New(common.CouponExpired)
+	}
+
+	var linkIDs []uint64
+	if err := tx.Model(&model.MerchantCouponLink{}).
+		Where("coupon_id = ?", coupon.ID).
+		Pluck("payment_link_id", &linkIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(linkIDs) > 0 {
+		applicable := false
+		for _, id := range linkIDs {
+			if opts.PaymentLinkID != nil && *opts.PaymentLinkID == id {
+				applicable = true
+				break
+			}
+		}
+		if !applicable {
+			return nil, errors.New(common.CouponNotApplicable)
+		}
+	}
+
+	if opts.Amount.LessThan(coupon.MinAmount) {
+		return nil, errors.New(common.CouponMinAmountNotMet)
+	}
+
+	usage := func() *gorm.DB {
+		return tx.Model(&model.Order{}).
+			Where("coupon_id = ? AND status = ? AND type <> ?", coupon.ID, model.OrderStatusSuccess, model.OrderTypeTest)
+	}
+
+	if coupon.TotalLimit != nil {
+		var totalCount int64
+		if err := usage().Count(&totalCount).Error; err != nil {
+			return nil, err
+		}
+		if totalCount >= int64(*coupon.TotalLimit) {
+			return nil, errors.New(common.CouponTotalLimitExceeded)
+		}
+	}
+
+	if coupon.UserLimit != nil {
+		var userCount int64
+		if err := usage().Where("payer_user_id = ?", opts.PayerID).Count(&userCount).Error; err != nil {
+			return nil, err
+		}
+		if userCount >= int64(*coupon.UserLimit) {
+			return nil, errors.New(common.CouponUserLimitExceeded)
+		}
+	}
+
+	discount := coupon.Discount(opts.Amount)
+	return &CouponQuote{
+		CouponID:       coupon.ID,
+		CouponName:     coupon.Name,
+		OriginalAmount: opts.Amount,
+		DiscountAmount: discount,
+		Amount:         opts.Amount.Sub(discount),
+	}, nil
+}
+
+// ApplyTo 将优惠结果写入订单
+func (q *CouponQuote) ApplyTo(order *model.Order) {
+	originalAmount := q.OriginalAmount
+	order.OriginalAmount = &originalAmount
+	order.DiscountAmount = q.DiscountAmount
+	order.Amount = q.Amount
+	order.CouponID = &q.CouponID
+}
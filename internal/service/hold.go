@@ -0,0 +1,122 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"errors"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HoldBalance 将用户可用余额转入冻结余额
+// 锁定用户并校验余额，返回锁定时读取的用户，调用方可在同一事务内继续做额度检查
+func HoldBalance(tx *gorm.DB, userID uint64, amount decimal.Decimal) (*model.User, error) {
+	var user model.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+		Where("id = ?", userID).
+		First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	if user.AvailableBalance.LessThan(amount) {
+		return nil, errors.New(common.InsufficientBalance)
+	}
+
+	if err := tx.Model(&model.User{}).
+		Where("id = ?", user.ID).
+		UpdateColumns(map[string]interface{}{
+			"available_balance": gorm.Expr("available_balance - ?", amount),
+			"held_balance":      gorm.Expr("held_balance + ?", amount),
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// SettleHeldOptions 冻结资金结算参数
+type SettleHeldOptions struct {
+	PayerID uint64
+	PayeeID uint64
+	Amount  decimal.Decimal
+	// PayerTotalField 付款人累计统计字段，默认为 total_transfer
+	PayerTotalField  string
+	PayerScoreChange int64
+	// PayeeAmount 收款人实收金额，为空时等于 Amount，如商户扣除手续费后的金额
+	PayeeAmount      *decimal.Decimal
+	PayeeScoreChange int64
+}
+
+// SettleHeldBalance 将付款人的冻结余额划转给收款人
+func SettleHeldBalance(tx *gorm.DB, opts SettleHeldOptions) error {
+	totalField := opts.PayerTotalField
+	if totalField == "" {
+		totalField = "total_transfer"
+	}
+
+	payerUpdates := map[string]interface{}{
+		"held_balance": gorm.Expr("held_balance - ?", opts.Amount),
+		totalField:     gorm.Expr(totalField+" + ?", opts.Amount),
+	}
+	if opts.PayerScoreChange != 0 {
+		payerUpdates["pay_score"] = gorm.Expr("pay_score + ?", opts.PayerScoreChange)
+	}
+
+	result := tx.Model(&model.User{}).
+		Where("id = ? AND held_balance >= ?", opts.PayerID, opts.Amount).
+		UpdateColumns(payerUpdates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(common.HeldBalanceMismatch)
+	}
+
+	payeeAmount := opts.Amount
+	if opts.PayeeAmount != nil {
+		payeeAmount = *opts.PayeeAmount
+	}
+
+	return UpdateBalance(tx, BalanceUpdateOptions{
+		UserID:      opts.PayeeID,
+		Amount:      payeeAmount,
+		Operation:   BalanceAdd,
+		ScoreChange: opts.PayeeScoreChange,
+		TotalField:  "total_receive",
+	})
+}
+
+// ReturnHeldBalance 将冻结余额退回用户可用余额
+func ReturnHeldBalance(tx *gorm.DB, userID uint64, amount decimal.Decimal) error {
+	result := tx.Model(&model.User{}).
+		Where("id = ? AND held_balance >= ?", userID, amount).
+		UpdateColumns(map[string]interface{}{
+			"held_balance":      gorm.Expr("held_balance - ?", amount),
+			"available_balance": gorm.Expr("available_balance + ?", amount),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New(common.HeldBalanceMismatch)
+	}
+	return nil
+}
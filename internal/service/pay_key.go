@@ -0,0 +1,277 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/logger"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 同一用户的 key 使用相同 hash tag，保证 Cluster 模式下可在同一命令中操作
+const (
+	// payKeyFailCountKeyFormat 支付密码连续失败次数
+	payKeyFailCountKeyFormat = "user:pay_key:{%d}:fail"
+	// payKeyCooldownKeyFormat 支付密码失败后的退避等待
+	payKeyCooldownKeyFormat = "user:pay_key:{%d}:cooldown"
+	// payKeyLockKeyFormat 支付密码临时锁定
+	payKeyLockKeyFormat = "user:pay_key:{%d}:lock"
+)
+
+const (
+	payKeyMaxAttempts    = 5                // 连续失败达到该次数后锁定
+	payKeyDelayThreshold = 2                // 连续失败超过该次数后开始退避
+	payKeyBaseDelay      = 5 * time.Second  // 退避基础时长，每多失败一次翻倍
+	payKeyFailWindow     = 24 * time.Hour   // 失败次数统计窗口
+	payKeyLockDuration   = 30 * time.Minute // 锁定时长
+)
+
+// reserveAuthAttemptScript 原子地检查锁定与退避并预占一次验证尝试
+// 预占后计数即增加，超过阈值时提前设置退避，避免并发请求绕过退避与锁定
+// 返回本次尝试的序号，-1 表示已锁定，-2 表示处于退避中
+var reserveAuthAttemptScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return -1
+end
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return -2
+end
+local count = redis.call('INCR', KEYS[3])
+redis.call('EXPIRE', KEYS[3], ARGV[1])
+if count > tonumber(ARGV[2]) then
+	return -1
+end
+if count > tonumber(ARGV[3]) then
+	redis.call('PSETEX', KEYS[2], math.floor(tonumber(ARGV[4]) * 2 ^ (count - tonumber(ARGV[3]) - 1)), 1)
+end
+return count
+`)
+
+// authAttemptKeys 返回支付验证相关的 Redis key：锁定、退避、失败次数
+func authAttemptKeys(userID uint64) []string {
+	return []string{
+		db.PrefixedKey(fmt.Sprintf(payKeyLockKeyFormat, userID)),
+		db.PrefixedKey(fmt.Sprintf(payKeyCooldownKeyFormat, userID)),
+		db.PrefixedKey(fmt.Sprintf(payKeyFailCountKeyFormat, userID)),
+	}
+}
+
+// reserveAuthAttempt 在校验支付密码或二次验证码前预占一次尝试，返回本次尝试的序号
+func reserveAuthAttempt(ctx context.Context, userID uint64) (int64, error) {
+	attempt, err := reserveAuthAttemptScript.Run(ctx, db.Redis, authAttemptKeys(userID),
+		int64(payKeyFailWindow.Seconds()),
+		payKeyMaxAttempts,
+		payKeyDelayThreshold,
+		payKeyBaseDelay.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	switch attempt {
+	case -1:
+		return 0, errors.New(common.PayKeyLocked)
+	case -2:
+		return 0, errors.New(common.PayKeyAttemptTooFrequent)
+	}
+	return attempt, nil
+}
+
+// resetAuthAttempts 验证通过后清空失败次数与退避
+func resetAuthAttempts(ctx context.Context, userID uint64) error {
+	keys := authAttemptKeys(userID)
+	return db.Redis.Del(ctx, keys[2], keys[1]).Err()
+}
+
+// VerifyPayKey 验证用户支付密码，并进行失败次数统计、退避与临时锁定
+func VerifyPayKey(ctx context.Context, user *model.User, inputPayKey string, ip string) error {
+	if _, err := checkPayKey(ctx, user, inputPayKey, ip); err != nil {
+		return err
+	}
+	return resetAuthAttempts(ctx, user.ID)
+}
+
+// checkPayKey 预占一次尝试并校验支付密码，失败时记录；成功时不清空计数，由调用方在全部校验通过后清空
+// 返回本次尝试的序号，供后续二次验证失败时沿用
+func checkPayKey(ctx context.Context, user *model.User, inputPayKey string, ip string) (int64, error) {
+	attempt, err := reserveAuthAttempt(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !user.VerifyPayKey(inputPayKey) {
+		if err := recordAuthFailure(ctx, user.ID, ip, "支付密码错误", attempt); err != nil {
+			return 0, err
+		}
+		return 0, errors.New(common.PayKeyIncorrect)
+	}
+
+	// 旧的可逆加密存储：验证成功后透明地迁移为哈希存储
+	if user.PayKeyNeedsRehash() {
+		if err := user.UpdatePayKey(db.DB(ctx), inputPayKey); err != nil {
+			logger.ErrorF(ctx, "用户[%d]支付密码重新哈希失败: %v", user.ID, err)
+		}
+	}
+	return attempt, nil
+}
+
+// recordAuthFailure 记录一次支付验证失败，达到上限后锁定
+// attempt 为 reserveAuthAttempt 预占的尝试序号；返回 nil 表示仅记录失败，已锁定时返回 PayKeyLocked 错误
+func recordAuthFailure(ctx context.Context, userID uint64, ip string, reason string, attempt int64) error {
+	// 达到上限：锁定并清空计数
+	if attempt >= payKeyMaxAttempts {
+		keys := authAttemptKeys(userID)
+		if err := db.Redis.Set(ctx, keys[0], time.Now().Add(payKeyLockDuration).Unix(), payKeyLockDuration).Err(); err != nil {
+			return err
+		}
+		db.Redis.Del(ctx, keys[2], keys[1])
+
+		model.RecordSecurityEvent(ctx, &model.UserSecurityLog{
+			UserID:    userID,
+			EventType: model.SecurityEventPayKeyLocked,
+			IP:        ip,
+			Remark:    fmt.Sprintf("连续%d次支付验证失败，锁定%d分钟", attempt, int(payKeyLockDuration.Minutes())),
+		})
+		return errors.New(common.PayKeyLocked)
+	}
+
+	model.RecordSecurityEvent(ctx, &model.UserSecurityLog{
+		UserID:    userID,
+		EventType: model.SecurityEventPayKeyFailed,
+		IP:        ip,
+		Remark:    fmt.Sprintf("%s，连续第%d次失败", reason, attempt),
+	})
+	return nil
+}
+
+// GetPayKeyLockedUntil 获取支付密码锁定截止时间，未锁定返回 nil
+func GetPayKeyLockedUntil(ctx context.Context, userID uint64) (*time.Time, error) {
+	ttl, err := db.Redis.TTL(ctx, db.PrefixedKey(fmt.Sprintf(payKeyLockKeyFormat, userID))).Result()
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		return nil, nil
+	}
+
+	lockedUntil := time.Now().Add(ttl)
+	return &lockedUntil, nil
+}
+
+// UnlockPayKey 解除支付密码锁定并清空失败次数
+func UnlockPayKey(ctx context.Context, userID uint64) error {
+	return db.Redis.Del(
+		ctx,
+		db.PrefixedKey(fmt.Sprintf(payKeyLockKeyFormat, userID)),
+		db.PrefixedKey(fmt.Sprintf(payKeyCooldownKeyFormat, userID)),
+		db.PrefixedKey(fmt.Sprintf(payKeyFailCountKeyFormat, userID)),
+	).Err()
+}
+
+// payKeyCoolingOrderTypes 冷静期内计入转出限额的订单类型
+var payKeyCoolingOrderTypes = []model.OrderType{
+	model.OrderTypeTransfer,
+	model.OrderTypeRedEnvelopeSend,
+	model.OrderTypeSplitBill,
+	model.OrderTypeEscrow,
+	model.OrderTypePayment,
+	model.OrderTypeOnline,
+}
+
+// GetPayKeyCoolingUntil 获取支付密码重置冷静期截止时间，不在冷静期返回 nil
+func GetPayKeyCoolingUntil(ctx context.Context, user *model.User) (*time.Time, error) {
+	if user.PayKeyResetAt == nil {
+		return nil, nil
+	}
+
+	coolingHours, err := model.GetIntByKey(ctx, model.ConfigKeyPayKeyResetCoolingHours)
+	if err != nil {
+		return nil, err
+	}
+
+	coolingUntil := user.PayKeyResetAt.Add(time.Duration(coolingHours) * time.Hour)
+	if !time.Now().Before(coolingUntil) {
+		return nil, nil
+	}
+	return &coolingUntil, nil
+}
+
+// CheckPayKeyResetCooling 检查支付密码重置冷静期内的累计转出金额
+// 需在已锁定付款人记录的事务中调用，避免并发转出绕过限额
+func CheckPayKeyResetCooling(tx *gorm.DB, user *model.User, amount decimal.Decimal) error {
+	ctx := tx.Statement.Context
+
+	coolingUntil, err := GetPayKeyCoolingUntil(ctx, user)
+	if err != nil {
+		return err
+	}
+	if coolingUntil == nil {
+		return nil
+	}
+
+	limit, err := model.GetDecimalByKey(ctx, model.ConfigKeyPayKeyResetTransferLimit, 2)
+	if err != nil {
+		return err
+	}
+
+	var used decimal.Decimal
+	if err := tx.Model(&model.Order{}).
+		Where("payer_user_id = ? AND status IN ? AND type IN ? AND trade_time >= ?",
+			user.ID,
+			[]model.OrderStatus{model.OrderStatusSuccess, model.OrderStatusHeld},
+			payKeyCoolingOrderTypes,
+			*user.PayKeyResetAt).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&used).Error; err != nil {
+		return err
+	}
+
+	if used.Add(amount).GreaterThan(limit) {
+		return errors.New(common.PayKeyResetCoolingLimited)
+	}
+	return nil
+}
+
+// LockAndCheckPayKeyResetCooling 用于事务中尚未锁定付款人的支付路径
+// 处于冷静期时锁定付款人记录后再检查累计转出金额
+func LockAndCheckPayKeyResetCooling(tx *gorm.DB, user *model.User, amount decimal.Decimal) error {
+	coolingUntil, err := GetPayKeyCoolingUntil(tx.Statement.Context, user)
+	if err != nil {
+		return err
+	}
+	if coolingUntil == nil {
+		return nil
+	}
+
+	var payer model.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", user.ID).
+		First(&payer).Error; err != nil {
+		return err
+	}
+	return CheckPayKeyResetCooling(tx, &payer, amount)
+}
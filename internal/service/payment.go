@@ -109,7 +109,31 @@ func CheckDailyLimit(tx *gorm.DB, userID uint64, amount decimal.Decimal, dailyLi
 	return nil
 }
 
-// GetTodayUsedAmount 获取用户当日已使用的支付额度
+// CheckAccessTokenTransferLimit 检查个人访问令牌的每日转账限额
+// 需在已锁定付款人记录的事务中调用
+func CheckAccessTokenTransferLimit(tx *gorm.DB, accessToken *model.UserAccessToken, amount decimal.Decimal) error {
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var todayUsed decimal.Decimal
+	if err := tx.Model(&model.Order{}).
+		Where("access_token_id = ? AND status IN ? AND type = ? AND trade_time >= ?",
+			accessToken.ID,
+			[]model.OrderStatus{model.OrderStatusSuccess, model.OrderStatusHeld},
+			model.OrderTypeTransfer,
+			todayStart).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&todayUsed).Error; err != nil {
+		return err
+	}
+
+	if todayUsed.Add(amount).GreaterThan(accessToken.TransferDailyLimit) {
+		return errors.New(common.AccessTokenDailyLimitExceeded)
+	}
+	return nil
+}
+
+// GetTodayUsedAmount 获取用户当日已使用的支付额度，预授权冻结中的金额同样计入
 func GetTodayUsedAmount(db *gorm.DB, userID uint64) (decimal.Decimal, error) {
 	now := time.Now()
 	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
@@ -117,9 +141,9 @@ func GetTodayUsedAmount(db *gorm.DB, userID uint64) (decimal.Decimal, error) {
 
 	var total decimal.Decimal
 	err := db.Model(&model.Order{}).
-		Where("payer_user_id = ? AND status = ? AND type IN ? AND trade_time >= ? AND trade_time < ?",
+		Where("payer_user_id = ? AND status IN ? AND type IN ? AND trade_time >= ? AND trade_time < ?",
 			userID,
-			model.OrderStatusSuccess,
+			[]model.OrderStatus{model.OrderStatusSuccess, model.OrderStatusHeld},
 			[]model.OrderType{model.OrderTypePayment, model.OrderTypeOnline},
 			todayStart,
 			todayEnd).
@@ -0,0 +1,184 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/redis/go-redis/v9"
+)
+
+// 同一用户的会话 key 使用相同 hash tag，保证 Cluster 模式下可批量操作
+const (
+	// userSessionSetKeyFormat 用户全部会话 ID 集合
+	userSessionSetKeyFormat = "user:sessions:{%d}"
+	// userSessionKeyFormat 单个会话详情
+	userSessionKeyFormat = "user:sessions:{%d}:%s"
+)
+
+// userSessionTouchInterval 最近活跃时间的更新间隔，避免每次请求都写 Redis
+const userSessionTouchInterval = time.Minute
+
+// UserSession 用户登录会话
+type UserSession struct {
+	ID         string    `json:"id"`
+	UserID     uint64    `json:"-"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func userSessionTTL() time.Duration {
+	return time.Duration(config.Config.App.SessionAge) * time.Second
+}
+
+// CreateUserSession 登记新的登录会话
+func CreateUserSession(ctx context.Context, userID uint64, ip string, userAgent string) (*UserSession, error) {
+	now := time.Now()
+	session := &UserSession{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		Device:     util.ParseDevice(userAgent),
+		IP:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	ttl := userSessionTTL()
+	if err := db.SetJSON(ctx, fmt.Sprintf(userSessionKeyFormat, userID, session.ID), session, ttl); err != nil {
+		return nil, err
+	}
+
+	setKey := db.PrefixedKey(fmt.Sprintf(userSessionSetKeyFormat, userID))
+	pipe := db.Redis.Pipeline()
+	pipe.SAdd(ctx, setKey, session.ID)
+	pipe.Expire(ctx, setKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// TouchUserSession 校验会话仍然有效并更新最近活跃时间
+// 会话已被撤销或过期时返回 false
+func TouchUserSession(ctx context.Context, userID uint64, sessionID string, ip string) (bool, error) {
+	key := fmt.Sprintf(userSessionKeyFormat, userID, sessionID)
+
+	var session UserSession
+	if err := db.GetJSON(ctx, key, &session); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < userSessionTouchInterval && session.IP == ip {
+		return true, nil
+	}
+
+	session.LastSeenAt = now
+	session.IP = ip
+	if err := db.SetJSON(ctx, key, session, redis.KeepTTL); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListUserSessions 查询用户全部有效会话，按最近活跃时间倒序
+func ListUserSessions(ctx context.Context, userID uint64) ([]UserSession, error) {
+	setKey := db.PrefixedKey(fmt.Sprintf(userSessionSetKeyFormat, userID))
+	sessionIDs, err := db.Redis.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]UserSession, 0, len(sessionIDs))
+	var expired []interface{}
+	for _, sessionID := range sessionIDs {
+		var session UserSession
+		if err := db.GetJSON(ctx, fmt.Sprintf(userSessionKeyFormat, userID, sessionID), &session); err != nil {
+			if errors.Is(err, redis.Nil) {
+				expired = append(expired, sessionID)
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	// 清理已过期的会话 ID
+	if len(expired) > 0 {
+		db.Redis.SRem(ctx, setKey, expired...)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeUserSession 撤销指定会话，会话不存在时返回 false
+func RevokeUserSession(ctx context.Context, userID uint64, sessionID string) (bool, error) {
+	pipe := db.Redis.TxPipeline()
+	delCmd := pipe.Del(ctx, db.PrefixedKey(fmt.Sprintf(userSessionKeyFormat, userID, sessionID)))
+	pipe.SRem(ctx, db.PrefixedKey(fmt.Sprintf(userSessionSetKeyFormat, userID)), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return delCmd.Val() > 0, nil
+}
+
+// RevokeUserSessions 撤销用户全部会话，exceptSessionID 非空时保留该会话
+func RevokeUserSessions(ctx context.Context, userID uint64, exceptSessionID string) error {
+	setKey := db.PrefixedKey(fmt.Sprintf(userSessionSetKeyFormat, userID))
+	sessionIDs, err := db.Redis.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(sessionIDs))
+	members := make([]interface{}, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		if sessionID == exceptSessionID {
+			continue
+		}
+		keys = append(keys, db.PrefixedKey(fmt.Sprintf(userSessionKeyFormat, userID, sessionID)))
+		members = append(members, sessionID)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := db.Redis.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.SRem(ctx, setKey, members...)
+	_, err = pipe.Exec(ctx)
+	return err
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/config"
+	"github.com/linux-do/credit/internal/db"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/linux-do/credit/internal/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	totpRecoveryCodeCount    = 10
+	totpRecoveryCodeLength   = 10
+	totpRecoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// totpEncryptKey TOTP 密钥的加密密钥，与用户数据分离存储
+func totpEncryptKey() string {
+	return util.DeriveKey(config.Config.App.EncryptionKey, "totp")
+}
+
+// EncryptTOTPSecret 加密 TOTP 密钥
+func EncryptTOTPSecret(secret string) (string, error) {
+	return util.Encrypt(totpEncryptKey(), secret)
+}
+
+// MatchTOTPCode 校验验证码，允许前后各一个时间步的时钟偏差
+// 返回匹配的时间步，用于防重放
+func MatchTOTPCode(encryptedSecret string, code string, now time.Time) (int64, bool) {
+	if len(code) != util.TOTPDigits {
+		return 0, false
+	}
+
+	secret, err := util.Decrypt(totpEncryptKey(), encryptedSecret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := util.TOTPStep(now)
+	for _, step := range []int64{current, current - 1, current + 1} {
+		expected, err := util.GenerateTOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes 生成一组恢复码，返回明文（仅展示一次）和待入库的哈希记录
+func GenerateRecoveryCodes(userID uint64) ([]string, []model.UserTOTPRecoveryCode, error) {
+	codes := make([]string, 0, totpRecoveryCodeCount)
+	records := make([]model.UserTOTPRecoveryCode, 0, totpRecoveryCodeCount)
+
+	alphabetLen := big.NewInt(int64(len(totpRecoveryCodeAlphabet)))
+	for i := 0; i < totpRecoveryCodeCount; i++ {
+		var builder strings.Builder
+		for j := 0; j < totpRecoveryCodeLength; j++ {
+			if j == totpRecoveryCodeLength/2 {
+				builder.WriteByte('-')
+			}
+			n, err := rand.Int(rand.Reader, alphabetLen)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+			}
+			builder.WriteByte(totpRecoveryCodeAlphabet[n.Int64()])
+		}
+
+		code := builder.String()
+		codes = append(codes, code)
+		records = append(records, model.UserTOTPRecoveryCode{
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(code),
+		})
+	}
+
+	return codes, records, nil
+}
+
+// hashRecoveryCode 恢复码哈希（忽略大小写和分隔符）
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyTOTP 校验已启用用户的 TOTP 验证码或恢复码，验证通过后即被消费，不可重复使用
+func VerifyTOTP(ctx context.Context, userTOTP *model.UserTOTP, code string, ip string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if step, ok := MatchTOTPCode(userTOTP.Secret, code, time.Now()); ok {
+		result := db.DB(ctx).Model(&model.UserTOTP{}).
+			Where("user_id = ? AND last_used_step < ?", userTOTP.UserID, step).
+			Update("last_used_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		return result.RowsAffected > 0, nil
+	}
+
+	result := db.DB(ctx).Model(&model.UserTOTPRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userTOTP.UserID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	model.RecordSecurityEvent(ctx, &model.UserSecurityLog{
+		UserID:    userTOTP.UserID,
+		EventType: model.SecurityEventTOTPRecovery,
+		IP:        ip,
+		Remark:    "使用恢复码完成二次验证",
+	})
+	return true, nil
+}
+
+// VerifyPaymentAuth 校验支付密码，并在用户启用 TOTP 且金额达到阈值时校验二次验证码
+// 支付密码与二次验证码共用一次尝试，全部通过后才清空失败次数
+func VerifyPaymentAuth(ctx context.Context, user *model.User, payKey string, totpCode string, amount decimal.Decimal, ip string) error {
+	attempt, err := checkPayKey(ctx, user, payKey, ip)
+	if err != nil {
+		return err
+	}
+
+	var userTOTP model.UserTOTP
+	if err := db.DB(ctx).Where("user_id = ?", user.ID).First(&userTOTP).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resetAuthAttempts(ctx, user.ID)
+		}
+		return err
+	}
+
+	if !userTOTP.RequiresCode(amount) {
+		return resetAuthAttempts(ctx, user.ID)
+	}
+
+	if strings.TrimSpace(totpCode) == "" {
+		return errors.New(common.TOTPCodeRequired)
+	}
+
+	return verifyTOTPAttempt(ctx, &userTOTP, totpCode, ip, attempt)
+}
+
+// VerifyTOTPCode 校验二次验证码或恢复码，与支付密码共用失败次数统计、退避与锁定
+func VerifyTOTPCode(ctx context.Context, userTOTP *model.UserTOTP, code string, ip string) error {
+	attempt, err := reserveAuthAttempt(ctx, userTOTP.UserID)
+	if err != nil {
+		return err
+	}
+	return verifyTOTPAttempt(ctx, userTOTP, code, ip, attempt)
+}
+
+// VerifyPayKeyAndTOTP 同时校验支付密码与二次验证码，两者共用一次尝试
+func VerifyPayKeyAndTOTP(ctx context.Context, user *model.User, userTOTP *model.UserTOTP, payKey string, code string, ip string) error {
+	attempt, err := checkPayKey(ctx, user, payKey, ip)
+	if err != nil {
+		return err
+	}
+	return verifyTOTPAttempt(ctx, userTOTP, code, ip, attempt)
+}
+
+// verifyTOTPAttempt 使用已预占的尝试校验二次验证码，失败时记录，成功时清空失败次数
+func verifyTOTPAttempt(ctx context.Context, userTOTP *model.UserTOTP, code string, ip string, attempt int64) error {
+	ok, err := VerifyTOTP(ctx, userTOTP, code, ip)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := recordAuthFailure(ctx, userTOTP.UserID, ip, "二次验证码错误", attempt); err != nil {
+			return err
+		}
+		return errors.New(common.TOTPCodeIncorrect)
+	}
+
+	return resetAuthAttempts(ctx, userTOTP.UserID)
+}
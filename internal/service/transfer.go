@@ -0,0 +1,276 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/linux-do/credit/internal/common"
+	"github.com/linux-do/credit/internal/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TransferOptions 用户间转账参数
+type TransferOptions struct {
+	PayerID   uint64
+	PayeeID   uint64
+	Amount    decimal.Decimal
+	OrderName string
+	// OrderType 订单类型，默认为转账
+	OrderType     model.OrderType
+	Remark        string
+	AccessTokenID *uint64
+	SplitBillID   *uint64
+	// ScheduledTransferID 由定时转账执行时记录计划 ID
+	ScheduledTransferID *uint64
+	// SettlesAt 可撤回转账的到账时间，设置后仅冻结付款人资金，到账前付款人可撤回
+	SettlesAt *time.Time
+	// BeforeTransfer 锁定付款人后、扣款前执行的额外额度检查，如访问令牌的每日限额
+	BeforeTransfer func(tx *gorm.DB, payer *model.User) error
+}
+
+// Transfer 在事务中完成一次用户间转账
+// 锁定付款人，校验余额与支付密码重置冷静期限额，创建成功订单并更新双方余额
+// 设置 SettlesAt 时创建冻结状态的订单，由 SettleDelayedTransfer 到账或 CancelDelayedTransfer 撤回
+func Transfer(tx *gorm.DB, opts TransferOptions) (*model.Order, error) {
+	var payer *model.User
+	if opts.SettlesAt != nil {
+		var err error
+		if payer, err = HoldBalance(tx, opts.PayerID, opts.Amount); err != nil {
+			return nil, err
+		}
+	} else {
+		payer = &model.User{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+			Where("id = ?", opts.PayerID).
+			First(payer).Error; err != nil {
+			return nil, err
+		}
+
+		if payer.AvailableBalance.LessThan(opts.Amount) {
+			return nil, errors.New(common.InsufficientBalance)
+		}
+	}
+
+	if err := CheckPayKeyResetCooling(tx, payer, opts.Amount); err != nil {
+		return nil, err
+	}
+
+	if opts.BeforeTransfer != nil {
+		if err := opts.BeforeTransfer(tx, payer); err != nil {
+			return nil, err
+		}
+	}
+
+	orderType := opts.OrderType
+	if orderType == "" {
+		orderType = model.OrderTypeTransfer
+	}
+
+	status := model.OrderStatusSuccess
+	if opts.SettlesAt != nil {
+		status = model.OrderStatusHeld
+	}
+
+	order := model.Order{
+		OrderName:           opts.OrderName,
+		PayerUserID:         payer.ID,
+		PayeeUserID:         opts.PayeeID,
+		Amount:              opts.Amount,
+		Status:              status,
+		Type:                orderType,
+		Remark:              opts.Remark,
+		AccessTokenID:       opts.AccessTokenID,
+		SplitBillID:         opts.SplitBillID,
+		ScheduledTransferID: opts.ScheduledTransferID,
+		SettlesAt:           opts.SettlesAt,
+		TradeTime:           time.Now(),
+		ExpiresAt:           time.Now().Add(24 * time.Hour),
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		return nil, err
+	}
+
+	// 可撤回转账资金已冻结，到账时再入账收款人
+	if opts.SettlesAt != nil {
+		return &order, nil
+	}
+
+	// 扣减付款人余额
+	if err := tx.Model(&model.User{}).
+		Where("id = ?", payer.ID).
+		UpdateColumns(map[string]interface{}{
+			"available_balance": gorm.Expr("available_balance - ?", opts.Amount),
+			"total_transfer":    gorm.Expr("total_transfer + ?", opts.Amount),
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	// 增加收款人余额
+	if err := tx.Model(&model.User{}).
+		Where("id = ?", opts.PayeeID).
+		UpdateColumns(map[string]interface{}{
+			"available_balance": gorm.Expr("available_balance + ?", opts.Amount),
+			"total_receive":     gorm.Expr("total_receive + ?", opts.Amount),
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// lockDelayedTransfer 锁定冻结中的可撤回转账订单
+func lockDelayedTransfer(tx *gorm.DB, query string, args ...interface{}) (*model.Order, error) {
+	var order model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status = ? AND type = ? AND settles_at IS NOT NULL", model.OrderStatusHeld, model.OrderTypeTransfer).
+		Where(query, args...).
+		First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(common.DelayedTransferNotFound)
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// SettleDelayedTransfer 可撤回转账到账，将冻结资金划转给收款人
+func SettleDelayedTransfer(tx *gorm.DB, orderID uint64) (*model.Order, error) {
+	order, err := lockDelayedTransfer(tx, "id = ?", orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SettleHeldBalance(tx, SettleHeldOptions{
+		PayerID: order.PayerUserID,
+		PayeeID: order.PayeeUserID,
+		Amount:  order.Amount,
+	}); err != nil {
+		return nil, err
+	}
+
+	order.Status = model.OrderStatusSuccess
+	if err := tx.Model(order).Update("status", order.Status).Error; err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// CancelDelayedTransfer 付款人在到账前撤回转账，冻结资金退回可用余额
+func CancelDelayedTransfer(tx *gorm.DB, orderID, payerID uint64) (*model.Order, error) {
+	order, err := lockDelayedTransfer(tx, "id = ? AND payer_user_id = ?", orderID, payerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !order.SettlesAt.After(time.Now()) {
+		return nil, errors.New(common.DelayedTransferUndoWindowClosed)
+	}
+
+	if err := ReturnHeldBalance(tx, order.PayerUserID, order.Amount); err != nil {
+		return nil, err
+	}
+
+	order.Status = model.OrderStatusCancelled
+	if err := tx.Model(order).Update("status", order.Status).Error; err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetIncomingDelayedAmount 获取用户尚未到账的可撤回转入金额
+func GetIncomingDelayedAmount(tx *gorm.DB, userID uint64) (decimal.Decimal, error) {
+	var amount decimal.Decimal
+	if err := tx.Model(&model.Order{}).
+		Where("payee_user_id = ? AND status = ? AND type = ? AND settles_at IS NOT NULL", userID, model.OrderStatusHeld, model.OrderTypeTransfer).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&amount).Error; err != nil {
+		return decimal.Zero, err
+	}
+	return amount, nil
+}
+
+// ReturnTransfer 收款人将已到账的转账原路退回付款人
+// 创建关联原订单的退回订单，冲减双方的累计转出、转入金额，原订单标记为已退款
+// 转账不计入支付积分，退回同样不变更双方积分
+func ReturnTransfer(tx *gorm.DB, orderID, payeeID uint64, remark string) (*model.Order, error) {
+	var original model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND payee_user_id = ? AND type = ? AND status = ?", orderID, payeeID, model.OrderTypeTransfer, model.OrderStatusSuccess).
+		First(&original).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(common.TransferNotReturnable)
+		}
+		return nil, err
+	}
+
+	var payee model.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
+		Where("id = ?", payeeID).
+		First(&payee).Error; err != nil {
+		return nil, err
+	}
+
+	if payee.AvailableBalance.LessThan(original.Amount) {
+		return nil, errors.New(common.InsufficientBalance)
+	}
+
+	reversal := model.Order{
+		OrderName:         "转账退回",
+		PayerUserID:       payee.ID,
+		PayeeUserID:       original.PayerUserID,
+		Amount:            original.Amount,
+		Status:            model.OrderStatusSuccess,
+		Type:              model.OrderTypeTransferReturn,
+		Remark:            remark,
+		ReversalOfOrderID: &original.ID,
+		TradeTime:         time.Now(),
+		ExpiresAt:         time.Now().Add(24 * time.Hour),
+	}
+	if err := tx.Create(&reversal).Error; err != nil {
+		return nil, err
+	}
+
+	// 冲减收款人的余额与累计转入
+	if err := tx.Model(&model.User{}).
+		Where("id = ?", payee.ID).
+		UpdateColumns(map[string]interface{}{
+			"available_balance": gorm.Expr("available_balance - ?", original.Amount),
+			"total_receive":     gorm.Expr("total_receive - ?", original.Amount),
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	// 退回付款人余额并冲减累计转出
+	if err := tx.Model(&model.User{}).
+		Where("id = ?", original.PayerUserID).
+		UpdateColumns(map[string]interface{}{
+			"available_balance": gorm.Expr("available_balance + ?", original.Amount),
+			"total_transfer":    gorm.Expr("total_transfer - ?", original.Amount),
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&original).Update("status", model.OrderStatusRefund).Error; err != nil {
+		return nil, err
+	}
+
+	return &reversal, nil
+}
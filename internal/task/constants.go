@@ -24,7 +24,19 @@ const (
 	MerchantPaymentNotifyTask             = "payment:merchant_notify"
 	SyncOrdersToClickHouseTask            = "order:sync_to_clickhouse"
 	RefundExpiredRedEnvelopesTask         = "redenvelope:refund_expired"
+	PersistRedEnvelopeClaimsTask          = "redenvelope:persist_claims"
+	ReconcileRedEnvelopeClaimsTask        = "redenvelope:reconcile_claims"
 	CleanupUnusedUploadsTask              = "upload:cleanup_unused"
+	RenewDueSubscriptionsTask             = "subscription:renew_due"
+	RenewSingleSubscriptionTask           = "subscription:renew_single"
+	SubscriptionNotifyTask                = "subscription:merchant_notify"
+	InvoiceReminderTask                   = "invoice:remind"
+	AutoSettleEscrowTradesTask            = "escrow:auto_settle"
+	ExpireAuthorizationsTask              = "payment:expire_authorizations"
+	DispatchScheduledTransfersTask        = "transfer:scheduled:dispatch"
+	ExecuteScheduledTransferTask          = "transfer:scheduled:execute"
+	SettleDelayedTransferTask             = "payment:settle_delayed_transfer"
+	SettleDueDelayedTransfersTask         = "payment:settle_due_delayed_transfers"
 )
 
 const (
@@ -40,6 +52,13 @@ const (
 	TaskTypeDisputeRefund     = "dispute_auto_refund"
 	TaskTypeRedEnvelopeRefund = "redenvelope_auto_refund"
 	TaskTypeCleanupUploads    = "cleanup_unused_uploads"
+	TaskTypeSubscriptionRenew = "subscription_renew"
+	TaskTypeInvoiceReminder   = "invoice_reminder"
+	TaskTypeEscrowAutoSettle  = "escrow_auto_settle"
+	TaskTypeAuthExpire        = "payment_auth_expire"
+	TaskTypeScheduledTransfer = "scheduled_transfer_dispatch"
+	TaskTypeDelayedTransfer   = "delayed_transfer_settle"
+	TaskTypeRedEnvelopeClaims = "redenvelope_reconcile_claims"
 )
 
 // TaskMeta 任务元数据
@@ -100,6 +119,69 @@ var DispatchableTasks = []TaskMeta{
 		MaxRetry:     3,
 		Queue:        QueueDefault,
 	},
+	{
+		Type:         TaskTypeSubscriptionRenew,
+		AsynqTask:    RenewDueSubscriptionsTask,
+		Name:         "订阅续费",
+		Description:  "为到期的订阅发起续费扣款",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeInvoiceReminder,
+		AsynqTask:    InvoiceReminderTask,
+		Name:         "账单提醒",
+		Description:  "标记逾期账单并提醒接收人付款",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeEscrowAutoSettle,
+		AsynqTask:    AutoSettleEscrowTradesTask,
+		Name:         "担保交易自动结算",
+		Description:  "超时未确认收货的交易自动放款，超时未发货的交易自动退款",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeAuthExpire,
+		AsynqTask:    ExpireAuthorizationsTask,
+		Name:         "预授权到期撤销",
+		Description:  "撤销到期未扣款的商户预授权订单并退回冻结资金",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeScheduledTransfer,
+		AsynqTask:    DispatchScheduledTransfersTask,
+		Name:         "定时转账补偿下发",
+		Description:  "扫描已到执行时间的定时转账并下发执行任务",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeDelayedTransfer,
+		AsynqTask:    SettleDueDelayedTransfersTask,
+		Name:         "可撤回转账到账",
+		Description:  "为撤回窗口已结束仍处于冻结状态的转账完成到账",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
+	{
+		Type:         TaskTypeRedEnvelopeClaims,
+		AsynqTask:    ReconcileRedEnvelopeClaimsTask,
+		Name:         "红包领取对账",
+		Description:  "将 Redis 中尚未落库的红包领取记录补录到数据库",
+		SupportsTime: false,
+		MaxRetry:     3,
+		Queue:        QueueDefault,
+	},
 }
 
 // GetTaskMeta 根据任务类型获取元数据
@@ -102,6 +102,76 @@ func StartScheduler() error {
 			return
 		}
 
+		// 订阅续费任务
+		if _, err = scheduler.Register(
+			config.Config.Scheduler.RenewSubscriptionsTaskCron,
+			asynq.NewTask(task.RenewDueSubscriptionsTask, nil),
+			asynq.Unique(9*time.Minute),
+			asynq.MaxRetry(3),
+		); err != nil {
+			return
+		}
+
+		// 账单提醒任务
+		if _, err = scheduler.Register(
+			config.Config.Scheduler.InvoiceReminderTaskCron,
+			asynq.NewTask(task.InvoiceReminderTask, nil),
+			asynq.Unique(50*time.Minute),
+			asynq.MaxRetry(3),
+		); err != nil {
+			return
+		}
+
+		// 担保交易自动结算任务
+		if _, err = scheduler.Register(
+			config.Config.Scheduler.AutoSettleEscrowTradesTaskCron,
+			asynq.NewTask(task.AutoSettleEscrowTradesTask, nil),
+			asynq.Unique(50*time.Minute),
+			asynq.MaxRetry(3),
+		); err != nil {
+			return
+		}
+
+		// 预授权到期撤销任务
+		if _, err = scheduler.Register(
+			config.Config.Scheduler.ExpireAuthorizationsTaskCron,
+			asynq.NewTask(task.ExpireAuthorizationsTask, nil),
+			asynq.Unique(9*time.Minute),
+			asynq.MaxRetry(3),
+		); err != nil {
+			return
+		}
+
+		// 定时转账补偿下发任务
+		if _, err = scheduler.Register(
+			config.Config.Scheduler.DispatchScheduledTransfersTaskCron,
+			asynq.NewTask(task.DispatchScheduledTransfersTask, nil),
+			asynq.Unique(4*time.Minute),
+			asynq.MaxRetry(3),
+		); err != nil {
+			return
+		}
+
+		// 红包领取对账任务
+		if _, err = scheduler.Register(
+			config.Config.Scheduler.ReconcileRedEnvelopeClaimsTaskCron,
+			asynq.NewTask(task.ReconcileRedEnvelopeClaimsTask, nil),
+			asynq.Unique(4*time.Minute),
+			asynq.MaxRetry(3),
+		); err != nil {
+			return
+		}
+
+		// 可撤回转账到账兜底任务
+		if _, err = scheduler.Register(
+			config.Config.Scheduler.SettleDelayedTransfersTaskCron,
+			asynq.NewTask(task.SettleDueDelayedTransfersTask, nil),
+			asynq.Unique(9*time.Minute),
+			asynq.MaxRetry(3),
+		); err != nil {
+			return
+		}
+
 		// 启动调度器
 		err = scheduler.Run()
 	})
@@ -24,9 +24,13 @@ import (
 
 	"github.com/hibiken/asynq"
 	"github.com/linux-do/credit/internal/apps/dispute"
+	"github.com/linux-do/credit/internal/apps/escrow"
+	"github.com/linux-do/credit/internal/apps/invoice"
 	"github.com/linux-do/credit/internal/apps/order"
 	"github.com/linux-do/credit/internal/apps/payment"
 	"github.com/linux-do/credit/internal/apps/redenvelope"
+	"github.com/linux-do/credit/internal/apps/scheduledtransfer"
+	"github.com/linux-do/credit/internal/apps/subscription"
 	"github.com/linux-do/credit/internal/apps/upload"
 	"github.com/linux-do/credit/internal/apps/user"
 	"github.com/linux-do/credit/internal/config"
@@ -80,7 +84,19 @@ func StartWorker() error {
 	mux.HandleFunc(task.MerchantPaymentNotifyTask, payment.HandleMerchantPaymentNotify)
 	mux.HandleFunc(task.SyncOrdersToClickHouseTask, order.HandleSyncOrdersToClickHouse)
 	mux.HandleFunc(task.RefundExpiredRedEnvelopesTask, redenvelope.HandleRefundExpiredRedEnvelopes)
+	mux.HandleFunc(task.PersistRedEnvelopeClaimsTask, redenvelope.HandlePersistRedEnvelopeClaims)
+	mux.HandleFunc(task.ReconcileRedEnvelopeClaimsTask, redenvelope.HandleReconcileRedEnvelopeClaims)
 	mux.HandleFunc(task.CleanupUnusedUploadsTask, upload.HandleCleanupUnusedUploads)
+	mux.HandleFunc(task.RenewDueSubscriptionsTask, subscription.HandleRenewDueSubscriptions)
+	mux.HandleFunc(task.RenewSingleSubscriptionTask, subscription.HandleRenewSingleSubscription)
+	mux.HandleFunc(task.SubscriptionNotifyTask, subscription.HandleSubscriptionNotify)
+	mux.HandleFunc(task.InvoiceReminderTask, invoice.HandleInvoiceReminders)
+	mux.HandleFunc(task.AutoSettleEscrowTradesTask, escrow.HandleAutoSettleEscrowTrades)
+	mux.HandleFunc(task.ExpireAuthorizationsTask, payment.HandleExpireAuthorizations)
+	mux.HandleFunc(task.DispatchScheduledTransfersTask, scheduledtransfer.HandleDispatchDueScheduledTransfers)
+	mux.HandleFunc(task.ExecuteScheduledTransferTask, scheduledtransfer.HandleExecuteScheduledTransfer)
+	mux.HandleFunc(task.SettleDelayedTransferTask, payment.HandleSettleDelayedTransfer)
+	mux.HandleFunc(task.SettleDueDelayedTransfersTask, payment.HandleSettleDueDelayedTransfers)
 	// 启动服务器
 	return asynqServer.Run(mux)
 }
@@ -20,6 +20,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -47,6 +48,15 @@ func Decrypt(signKey string, ciphertext string) (string, error) {
 	return string(plaintext), nil
 }
 
+// DeriveKey 从应用密钥派生指定用途的加密密钥
+// secret: 应用级密钥（如 EncryptionKey）
+// purpose: 用途标识，不同用途得到不同密钥
+// return: 64 字符 hex 编码的密钥，可直接用于 Encrypt/Decrypt
+func DeriveKey(secret string, purpose string) string {
+	sum := sha256.Sum256([]byte(purpose + ":" + secret))
+	return hex.EncodeToString(sum[:])
+}
+
 // encryptBytes 加密函数，处理字节数据
 func encryptBytes(signKey string, plaintext []byte) (string, error) {
 	// 将 hex 编码的密钥转换为字节
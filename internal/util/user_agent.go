@@ -0,0 +1,71 @@
+/*
+Copyright 2025 linux.do
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import "strings"
+
+// uaRule User-Agent 关键字与名称映射，按顺序匹配
+type uaRule struct {
+	keyword string
+	name    string
+}
+
+var (
+	uaOSRules = []uaRule{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"HarmonyOS", "HarmonyOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+	uaBrowserRules = []uaRule{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+)
+
+// ParseDevice 从 User-Agent 中解析简要的设备描述，如 "Windows / Chrome"
+func ParseDevice(userAgent string) string {
+	osName := matchUARule(userAgent, uaOSRules)
+	browser := matchUARule(userAgent, uaBrowserRules)
+
+	switch {
+	case osName != "" && browser != "":
+		return osName + " / " + browser
+	case osName != "":
+		return osName
+	case browser != "":
+		return browser
+	default:
+		return "未知设备"
+	}
+}
+
+func matchUARule(userAgent string, rules []uaRule) string {
+	for _, rule := range rules {
+		if strings.Contains(userAgent, rule.keyword) {
+			return rule.name
+		}
+	}
+	return ""
+}